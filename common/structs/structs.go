@@ -13,6 +13,20 @@ type RecordOnlyId struct {
 	ID primitive.ObjectID `json:"_id" bson:"_id"`
 }
 
+// UpdateResult is the result of an update: the number of records matching the query, the
+// number of those the update changed, and the _id of the record an upsert inserted, nil when
+// it inserted none.
+type UpdateResult struct {
+	MatchedCount  int64       `json:"matchedCount" bson:"matchedCount"`
+	ModifiedCount int64       `json:"modifiedCount" bson:"modifiedCount"`
+	UpsertedID    interface{} `json:"upsertedId,omitempty" bson:"upsertedId,omitempty"`
+}
+
+// DeleteResult is the result of a delete: the number of records deleted.
+type DeleteResult struct {
+	DeletedCount int64 `json:"deletedCount" bson:"deletedCount"`
+}
+
 type Option struct {
 	Type string `json:"type,omitempty"` // http content type
 	//Region string `json:"region"` // region of storage
@@ -6,6 +6,8 @@ require (
 	github.com/byted-apaas/server-common-go v0.0.42
 	github.com/stretchr/testify v1.9.0
 	github.com/tidwall/gjson v1.14.2
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	github.com/yuin/gopher-lua v1.1.0
 	go.mongodb.org/mongo-driver v1.12.1
 )
 
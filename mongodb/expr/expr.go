@@ -0,0 +1,246 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package expr builds the aggregation expressions computing values from the fields of the
+// records, which GroupBy, Sum, Avg, Project and the stages of Aggregate accept in place of
+// field names.
+//
+//	err := baas.MongoDB.Table("orders").
+//		GroupBy(expr.DateToString("%Y-%m-%d", "$createdAt", "+08:00"), "day").
+//		Sum(expr.Multiply("$price", "$qty"), "revenue").
+//		Find(ctx, &days)
+//
+// The arguments are expressions themselves: "$field" references to the fields of the record,
+// literal values, or expressions built by this package. Literal strings starting with "$"
+// must be wrapped with Literal.
+package expr
+
+import (
+	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+)
+
+// Field returns the reference to the field name, e.g. "info.city".
+func Field(name string) string {
+	return "$" + name
+}
+
+// Literal returns v itself, not evaluated as an expression.
+func Literal(v interface{}) cond.M {
+	return cond.M{op.Literal: v}
+}
+
+// call returns the expression operator applied to args, which are always sent as an array
+// so that a single array argument is not taken for the list of arguments.
+func call(operator string, args ...interface{}) cond.M {
+	return cond.M{operator: cond.A(args)}
+}
+
+// Arithmetic
+
+func Add(args ...interface{}) cond.M {
+	return call(op.Add, args...)
+}
+
+func Subtract(a, b interface{}) cond.M {
+	return call(op.Subtract, a, b)
+}
+
+func Multiply(args ...interface{}) cond.M {
+	return call(op.Multiply, args...)
+}
+
+func Divide(a, b interface{}) cond.M {
+	return call(op.Divide, a, b)
+}
+
+func Mod(a, b interface{}) cond.M {
+	return call(op.Mod, a, b)
+}
+
+func Abs(v interface{}) cond.M {
+	return call(op.Abs, v)
+}
+
+func Ceil(v interface{}) cond.M {
+	return call(op.Ceil, v)
+}
+
+func Floor(v interface{}) cond.M {
+	return call(op.Floor, v)
+}
+
+// Round rounds v to place decimal places, or to a multiple of 10^-place when place is negative.
+func Round(v interface{}, place int) cond.M {
+	return call(op.Round, v, place)
+}
+
+// Comparison and boolean, for the conditions of Cond and Filter
+
+func Eq(a, b interface{}) cond.M {
+	return call(op.Eq, a, b)
+}
+
+func Ne(a, b interface{}) cond.M {
+	return call(op.Ne, a, b)
+}
+
+func Gt(a, b interface{}) cond.M {
+	return call(op.Gt, a, b)
+}
+
+func Gte(a, b interface{}) cond.M {
+	return call(op.Gte, a, b)
+}
+
+func Lt(a, b interface{}) cond.M {
+	return call(op.Lt, a, b)
+}
+
+func Lte(a, b interface{}) cond.M {
+	return call(op.Lte, a, b)
+}
+
+func And(args ...interface{}) cond.M {
+	return call(op.And, args...)
+}
+
+func Or(args ...interface{}) cond.M {
+	return call(op.Or, args...)
+}
+
+func Not(v interface{}) cond.M {
+	return call(op.Not, v)
+}
+
+// Conditional
+
+// Cond returns then when condition is true, and otherwise else.
+func Cond(condition, then, otherwise interface{}) cond.M {
+	return call(op.Cond, condition, then, otherwise)
+}
+
+// IfNull returns v, or replacement when v is null or missing.
+func IfNull(v, replacement interface{}) cond.M {
+	return call(op.IfNull, v, replacement)
+}
+
+// String
+
+func Concat(args ...interface{}) cond.M {
+	return call(op.Concat, args...)
+}
+
+// Substr returns the length characters of s starting at start, counting UTF-8 code points,
+// so that multi-byte characters are never split.
+func Substr(s interface{}, start, length int) cond.M {
+	return call(op.SubstrCP, s, start, length)
+}
+
+// StrLen returns the number of UTF-8 code points of s.
+func StrLen(s interface{}) cond.M {
+	return call(op.StrLenCP, s)
+}
+
+func ToLower(s interface{}) cond.M {
+	return call(op.ToLower, s)
+}
+
+func ToUpper(s interface{}) cond.M {
+	return call(op.ToUpper, s)
+}
+
+// Split returns the array of the parts of s separated by delimiter.
+func Split(s interface{}, delimiter string) cond.M {
+	return call(op.Split, s, delimiter)
+}
+
+// Date
+
+// DateToString formats date with format, e.g. "%Y-%m-%d", in timezone, UTC by default. The
+// timezone is an Olson name such as "Asia/Shanghai", or an offset such as "+08:00".
+func DateToString(format string, date interface{}, timezone ...string) cond.M {
+	m := cond.M{"format": format, "date": date}
+	if len(timezone) > 0 {
+		m["timezone"] = timezone[0]
+	}
+	return cond.M{op.DateToString: m}
+}
+
+// Year returns the year of date in timezone, UTC by default, as DateToString.
+func Year(date interface{}, timezone ...string) cond.M {
+	return datePart(op.Year, date, timezone)
+}
+
+// Month returns the month of date, between 1 and 12.
+func Month(date interface{}, timezone ...string) cond.M {
+	return datePart(op.Month, date, timezone)
+}
+
+// DayOfMonth returns the day of the month of date, between 1 and 31.
+func DayOfMonth(date interface{}, timezone ...string) cond.M {
+	return datePart(op.DayOfMonth, date, timezone)
+}
+
+// DayOfWeek returns the day of the week of date, between 1 for Sunday and 7 for Saturday.
+func DayOfWeek(date interface{}, timezone ...string) cond.M {
+	return datePart(op.DayOfWeek, date, timezone)
+}
+
+func Hour(date interface{}, timezone ...string) cond.M {
+	return datePart(op.Hour, date, timezone)
+}
+
+func Minute(date interface{}, timezone ...string) cond.M {
+	return datePart(op.Minute, date, timezone)
+}
+
+func Second(date interface{}, timezone ...string) cond.M {
+	return datePart(op.Second, date, timezone)
+}
+
+func datePart(operator string, date interface{}, timezone []string) cond.M {
+	if len(timezone) > 0 {
+		return cond.M{operator: cond.M{"date": date, "timezone": timezone[0]}}
+	}
+	return call(operator, date)
+}
+
+// Array
+
+func Size(arr interface{}) cond.M {
+	return call(op.Size, arr)
+}
+
+// ArrayElemAt returns the element of arr at index i, counting from the end when i is negative.
+func ArrayElemAt(arr interface{}, i int) cond.M {
+	return call(op.ArrayElemAt, arr, i)
+}
+
+// In reports whether arr holds v.
+func In(v, arr interface{}) cond.M {
+	return call(op.In, v, arr)
+}
+
+func ConcatArrays(arrs ...interface{}) cond.M {
+	return call(op.ConcatArrays, arrs...)
+}
+
+// Slice returns the first n elements of arr, or the last -n ones when n is negative.
+func Slice(arr interface{}, n int) cond.M {
+	return call(op.Slice, arr, n)
+}
+
+// Filter returns the elements of arr for which condition is true, condition referring to the
+// element as "$$" + as.
+//
+//	expr.Filter("$grades", "g", expr.Gte("$$g.score", 60))
+func Filter(arr interface{}, as string, condition interface{}) cond.M {
+	return cond.M{op.Filter: cond.M{"input": arr, "as": as, "cond": condition}}
+}
+
+// Map returns the values of in for each element of arr, in referring to the element as
+// "$$" + as.
+func Map(arr interface{}, as string, in interface{}) cond.M {
+	return cond.M{op.Map: cond.M{"input": arr, "as": as, "in": in}}
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
 	"context"
 	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/byted-apaas/baas-sdk-go/mongodb"
 	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
@@ -16,9 +19,12 @@ import (
 
 type AggQuery struct {
 	*MongodbParam
+	// conditions filter the records before grouping, and having the groups.
 	conditions   []interface{}
+	having       []interface{}
 	group        map[string]interface{}
 	groupIDAlias string
+	transport    faasinfra.MongodbTransport
 }
 
 func NewAggQuery(tableName string) *AggQuery {
@@ -37,8 +43,10 @@ func (a *AggQuery) Find(ctx context.Context, records interface{}) error {
 		return a.Err
 	}
 	a.SetOp(OpType_Aggregate)
-	a.buildPipeline()
-	return faasinfra.Find(ctx, a.MongodbParam, records)
+	if err := a.buildPipeline(); err != nil {
+		return err
+	}
+	return faasinfra.Find(ctx, a.transport, a.MongodbParam, records)
 }
 
 func (a *AggQuery) FindOne(ctx context.Context, record interface{}) error {
@@ -47,8 +55,10 @@ func (a *AggQuery) FindOne(ctx context.Context, record interface{}) error {
 	}
 	a.SetOp(OpType_Aggregate)
 	a.SetOne(true)
-	a.buildPipeline()
-	return faasinfra.FindOne(ctx, a.MongodbParam, record)
+	if err := a.buildPipeline(); err != nil {
+		return err
+	}
+	return faasinfra.FindOne(ctx, a.transport, a.MongodbParam, record)
 }
 
 func (a *AggQuery) GroupBy(field interface{}, alias ...interface{}) mongodb.IAggQuery {
@@ -91,31 +101,38 @@ func (a *AggQuery) Push(field interface{}, alias ...interface{}) mongodb.IAggQue
 	return a
 }
 
-func (a *AggQuery) Having(condition interface{}) mongodb.IAggQuery {
-	if a.Err != nil {
+func (a *AggQuery) Where(condition interface{}) mongodb.IAggQuery {
+	if a.Err != nil || condition == nil {
 		return a
 	}
+	a.conditions, a.Err = appendCondition(a.conditions, condition, "Where")
+	return a
+}
 
-	if condition == nil {
+func (a *AggQuery) Having(condition interface{}) mongodb.IAggQuery {
+	if a.Err != nil || condition == nil {
 		return a
 	}
+	a.having, a.Err = appendCondition(a.having, condition, "Having")
+	return a
+}
 
+// appendCondition appends condition, a slice of conditions all required or a struct or map,
+// to conditions.
+func appendCondition(conditions []interface{}, condition interface{}, method string) ([]interface{}, error) {
 	typ := reflect.TypeOf(condition)
-	val := reflect.ValueOf(condition)
 	if typ.Kind() == reflect.Ptr {
-		val = val.Elem()
 		typ = typ.Elem()
 	}
 
 	switch typ.Kind() {
 	case reflect.Slice:
-		a.conditions = append(a.conditions, cond.M{op.And: condition})
+		return append(conditions, cond.M{op.And: condition}), nil
 	case reflect.Struct, reflect.Map:
-		a.conditions = append(a.conditions, condition)
+		return append(conditions, condition), nil
 	default:
-		a.Err = cExceptions.InvalidParamError("Having received invalid type, should be slice, struct or map, but received %s ", typ)
+		return conditions, cExceptions.InvalidParamError("%s received invalid type, should be slice, struct or map, but received %s ", method, typ)
 	}
-	return a
 }
 
 func (a *AggQuery) First(field interface{}, alias ...interface{}) mongodb.IAggQuery {
@@ -126,7 +143,7 @@ func (a *AggQuery) Last(field interface{}, alias ...interface{}) mongodb.IAggQue
 	return a.appendGroup(op.Last, field, alias...)
 }
 
-func (a *AggQuery) Sum(field string, alias ...interface{}) mongodb.IAggQuery {
+func (a *AggQuery) Sum(field interface{}, alias ...interface{}) mongodb.IAggQuery {
 	return a.appendGroup(op.Sum, field, alias...)
 }
 
@@ -138,19 +155,19 @@ func (a *AggQuery) Num(field string) mongodb.IAggQuery {
 	return a
 }
 
-func (a *AggQuery) Avg(field string, alias ...interface{}) mongodb.IAggQuery {
+func (a *AggQuery) Avg(field interface{}, alias ...interface{}) mongodb.IAggQuery {
 	return a.appendGroup(op.Avg, field, alias...)
 }
 
-func (a *AggQuery) StdDevPop(field string, alias ...interface{}) mongodb.IAggQuery {
+func (a *AggQuery) StdDevPop(field interface{}, alias ...interface{}) mongodb.IAggQuery {
 	return a.appendGroup(op.StdDevPop, field, alias...)
 }
 
-func (a *AggQuery) StdDevSamp(field string, alias ...interface{}) mongodb.IAggQuery {
+func (a *AggQuery) StdDevSamp(field interface{}, alias ...interface{}) mongodb.IAggQuery {
 	return a.appendGroup(op.StdDevSamp, field, alias...)
 }
 
-func (a *AggQuery) AddToSet(field string, alias ...interface{}) mongodb.IAggQuery {
+func (a *AggQuery) AddToSet(field interface{}, alias ...interface{}) mongodb.IAggQuery {
 	return a.appendGroup(op.AddToSet, field, alias...)
 }
 
@@ -225,7 +242,7 @@ func (a *AggQuery) parseKeyValue(field interface{}, alias ...interface{}) (key s
 		}
 		value = m
 	default:
-		// struct or others
+		// expressions, structs or others
 		if len(key) == 0 {
 			a.Err = cExceptions.InvalidParamError("The first element of alias must be string type and not empty")
 			return
@@ -242,9 +259,83 @@ func (p *AggQuery) addGroup(key string, value interface{}) {
 	p.group[key] = value
 }
 
-func (p *AggQuery) buildPipeline() {
-	p.conditionAddPipeline()
+func (p *AggQuery) buildPipeline() error {
+	p.conditionAddPipeline(p.conditions)
 	p.groupAddPipeLine()
+	having := p.having
+	if p.groupIDAlias != "" {
+		having = make([]interface{}, len(p.having))
+		for i, condition := range p.having {
+			var err error
+			if having[i], err = groupIDCondition(condition, p.groupIDAlias); err != nil {
+				return err
+			}
+		}
+	}
+	p.conditionAddPipeline(having)
+	return nil
+}
+
+// groupIDCondition returns condition with the fields alias and alias.x, the alias of the group
+// _id, renamed to _id and _id.x: the server only applies idAlias to the results, so the
+// having stage still sees _id. Structs are converted to documents first. A document with both
+// a field of the alias and the same field of _id is an error.
+func groupIDCondition(condition interface{}, alias string) (interface{}, error) {
+	val := reflect.ValueOf(condition)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return condition, nil
+		}
+		val = val.Elem()
+	}
+
+	switch val.Kind() {
+	case reflect.Struct:
+		body, err := bson.Marshal(condition)
+		if err != nil {
+			return condition, nil
+		}
+		var m bson.M
+		if err := bson.Unmarshal(body, &m); err != nil {
+			return condition, nil
+		}
+		return groupIDCondition(m, alias)
+	case reflect.Map:
+		if val.Type().Key().Kind() != reflect.String {
+			return condition, nil
+		}
+		m := make(cond.M, val.Len())
+		for _, k := range val.MapKeys() {
+			key, v := k.String(), val.MapIndex(k).Interface()
+			switch {
+			case key == op.And || key == op.Or || key == op.Nor:
+				var err error
+				if v, err = groupIDCondition(v, alias); err != nil {
+					return nil, err
+				}
+			case key == alias:
+				key = "_id"
+			case strings.HasPrefix(key, alias+"."):
+				key = "_id" + strings.TrimPrefix(key, alias)
+			}
+			if _, ok := m[key]; ok {
+				return nil, cExceptions.InvalidParamError("Having cannot use both %s and the group _id alias %s for the same field", key, alias)
+			}
+			m[key] = v
+		}
+		return m, nil
+	case reflect.Slice, reflect.Array:
+		list := make(cond.A, val.Len())
+		for i := range list {
+			var err error
+			if list[i], err = groupIDCondition(val.Index(i).Interface(), alias); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	default:
+		return condition, nil
+	}
 }
 
 func (p *AggQuery) groupAddPipeLine() {
@@ -262,8 +353,8 @@ func (p *AggQuery) groupAddPipeLine() {
 	p.Args.Pipeline = append(p.Args.Pipeline, g)
 }
 
-func (p *AggQuery) conditionAddPipeline() {
-	if p.group == nil || len(p.conditions) == 0 {
+func (p *AggQuery) conditionAddPipeline(conditions []interface{}) {
+	if p.group == nil || len(conditions) == 0 {
 		return
 	}
 
@@ -271,10 +362,10 @@ func (p *AggQuery) conditionAddPipeline() {
 		"type": "matchGeneral",
 	}
 
-	if len(p.conditions) == 1 {
-		g["match"] = p.conditions[0]
-	} else if len(p.conditions) > 1 {
-		g["match"] = cond.M{op.And: p.conditions}
+	if len(conditions) == 1 {
+		g["match"] = conditions[0]
+	} else if len(conditions) > 1 {
+		g["match"] = cond.M{op.And: conditions}
 	}
 	p.Args.Pipeline = append(p.Args.Pipeline, g)
 }
@@ -10,6 +10,8 @@ import (
 
 	"github.com/byted-apaas/baas-sdk-go/common/utils"
 	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/expr"
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
 )
 
 // GroupBy
@@ -157,7 +159,68 @@ func TestQuery_GroupBy_Having(t *testing.T) {
 	T := db.Table("goods")
 
 	var results []bson.M
-	err := T.GroupBy("info.city", "city").Push([]string{"item", "qty"}, "list").Having(cond.M{"qty": cond.Gt(100)}).Find(ctx, &results)
+	err := T.GroupBy("info.city", "city").Push([]string{"item", "qty"}, "list").Sum("qty", "total").Where(cond.M{"qty": cond.Gt(10)}).Having(cond.M{"total": cond.Gt(100)}).Find(ctx, &results)
+	if err != nil {
+		panic(err)
+	}
+	utils.PrintLog(results)
+}
+
+// Having on the alias of the group _id, which the server only applies to the results
+func TestQuery_GroupBy_Having_IDAlias(t *testing.T) {
+	db := NewMongodb()
+	T := db.Table("goods")
+
+	var results []bson.M
+	err := T.GroupBy("info.city", "city").Sum("qty", "total").Having(cond.M{"city": "beijing"}).Find(ctx, &results)
+	if err != nil {
+		panic(err)
+	}
+	utils.PrintLog(results)
+}
+
+// Pipeline
+func TestPipeline_Group_Sort_Limit(t *testing.T) {
+	db := NewMongodb()
+	T := db.Table("goods")
+
+	var results []bson.M
+	err := T.Aggregate().
+		Match(cond.M{"qty": cond.Gt(10)}).
+		Group("info.city", cond.M{"total": cond.M{op.Sum: "$qty"}}).
+		SortDesc("total").
+		Limit(10).
+		Find(ctx, &results)
+	if err != nil {
+		panic(err)
+	}
+	utils.PrintLog(results)
+}
+
+func TestPipeline_Lookup(t *testing.T) {
+	db := NewMongodb()
+	T := db.Table("goods")
+
+	var results []bson.M
+	err := T.Aggregate().
+		Lookup("orders", "item", "sku", "orders").
+		LookupPipeline(cond.M{"item": "item"}, db.Table("orders").Aggregate().
+			Match(cond.M{op.Expr: cond.M{op.Eq: cond.A{"$sku", "$$item"}}}).
+			SortDesc("amount").
+			Limit(1), "topOrder").
+		Find(ctx, &results)
+	if err != nil {
+		panic(err)
+	}
+	utils.PrintLog(results)
+}
+
+func TestQuery_GroupBy_Expr(t *testing.T) {
+	db := NewMongodb()
+	T := db.Table("goods")
+
+	var results []bson.M
+	err := T.GroupBy(expr.DateToString("%Y-%m-%d", "$createdAt", "+08:00"), "day").Sum(expr.Multiply("$price", "$qty"), "revenue").Find(ctx, &results)
 	if err != nil {
 		panic(err)
 	}
@@ -5,15 +5,32 @@ package impl
 
 import (
 	"github.com/byted-apaas/baas-sdk-go/mongodb"
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
 )
 
 type Mongodb struct {
+	transport faasinfra.MongodbTransport
 }
 
-func NewMongodb() *Mongodb {
-	return &Mongodb{}
+type Option func(m *Mongodb)
+
+// WithTransport replaces the transport used to send mongodb requests, e.g. with an in-memory one in tests.
+func WithTransport(transport faasinfra.MongodbTransport) Option {
+	return func(m *Mongodb) {
+		m.transport = transport
+	}
+}
+
+func NewMongodb(opts ...Option) *Mongodb {
+	m := &Mongodb{}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *Mongodb) Table(tableName string) mongodb.ITable {
-	return NewTable(tableName)
+	t := NewTable(tableName)
+	t.transport = m.transport
+	return t
 }
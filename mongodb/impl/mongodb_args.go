@@ -134,3 +134,7 @@ func (p *MongodbParam) AddSort(field string, direct int64) {
 func (p *MongodbParam) SetUpdate(field2value interface{}) {
 	p.Args.Update = field2value
 }
+
+func (p *MongodbParam) SetArrayFilters(arrayFilters []interface{}) {
+	p.Args.ArrayFilters = arrayFilters
+}
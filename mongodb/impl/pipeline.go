@@ -0,0 +1,272 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package impl
+
+import (
+	"context"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/byted-apaas/baas-sdk-go/mongodb"
+	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// Pipeline is an aggregation pipeline. Each of its methods but Find and FindOne appends a
+// stage, which the server runs in order.
+type Pipeline struct {
+	*MongodbParam
+	transport faasinfra.MongodbTransport
+}
+
+func NewPipeline(tableName string) *Pipeline {
+	return &Pipeline{
+		MongodbParam: &MongodbParam{
+			TableName: tableName,
+			Args: &MongodbArgs{
+				Aggregate: true,
+			},
+		},
+	}
+}
+
+func (p *Pipeline) Find(ctx context.Context, records interface{}) error {
+	if p.Err != nil {
+		return p.Err
+	}
+	p.SetOp(OpType_Aggregate)
+	return faasinfra.Find(ctx, p.transport, p.MongodbParam, records)
+}
+
+func (p *Pipeline) FindOne(ctx context.Context, record interface{}) error {
+	if p.Err != nil {
+		return p.Err
+	}
+	p.SetOp(OpType_Aggregate)
+	p.SetOne(true)
+	return faasinfra.FindOne(ctx, p.transport, p.MongodbParam, record)
+}
+
+func (p *Pipeline) Match(condition interface{}) mongodb.IPipeline {
+	if p.Err != nil || condition == nil {
+		return p
+	}
+
+	conditions, err := appendCondition(nil, condition, "Pipeline.Match")
+	if err != nil {
+		p.Err = err
+		return p
+	}
+	p.Args.Pipeline = append(p.Args.Pipeline, cond.M{
+		"type":  "matchGeneral",
+		"match": conditions[0],
+	})
+	return p
+}
+
+func (p *Pipeline) Unwind(field string) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if field == "" {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Unwind received an empty field")
+		return p
+	}
+	return p.addStage("unwind", "$"+field)
+}
+
+func (p *Pipeline) Lookup(from, localField, foreignField, as string) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if from == "" || localField == "" || foreignField == "" || as == "" {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Lookup received an empty argument, from: %q, localField: %q, foreignField: %q, as: %q", from, localField, foreignField, as)
+		return p
+	}
+	return p.addStage("lookup", cond.M{
+		"from":         from,
+		"localField":   localField,
+		"foreignField": foreignField,
+		"as":           as,
+	})
+}
+
+func (p *Pipeline) LookupPipeline(let map[string]interface{}, pipeline mongodb.IPipeline, as string) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	sub, ok := pipeline.(*Pipeline)
+	if !ok || sub == nil {
+		p.Err = cExceptions.InvalidParamError("Pipeline.LookupPipeline received invalid pipeline %T, should be built by ITable.Aggregate", pipeline)
+		return p
+	}
+	if sub.Err != nil {
+		p.Err = sub.Err
+		return p
+	}
+	if as == "" {
+		p.Err = cExceptions.InvalidParamError("Pipeline.LookupPipeline received an empty as")
+		return p
+	}
+
+	lookup := cond.M{
+		"from":     sub.TableName,
+		"pipeline": sub.Args.Pipeline,
+		"as":       as,
+	}
+	if len(let) > 0 {
+		vars := cond.M{}
+		for name, field := range let {
+			if s, ok := field.(string); ok {
+				field = "$" + s
+			}
+			vars[name] = field
+		}
+		lookup["let"] = vars
+	}
+	return p.addStage("lookup", lookup)
+}
+
+func (p *Pipeline) Group(field interface{}, accumulators map[string]interface{}) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if field == "" {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Group received an empty field")
+		return p
+	}
+
+	group := cond.M{"_id": groupID(field)}
+	for alias, acc := range accumulators {
+		if alias == "" || alias == "_id" {
+			p.Err = cExceptions.InvalidParamError("Pipeline.Group received an invalid alias %q", alias)
+			return p
+		}
+		group[alias] = acc
+	}
+	return p.addStage("group", group)
+}
+
+func (p *Pipeline) Sort(fields ...string) mongodb.IPipeline {
+	return p.addSort(Asc, fields)
+}
+
+func (p *Pipeline) SortDesc(fields ...string) mongodb.IPipeline {
+	return p.addSort(Desc, fields)
+}
+
+func (p *Pipeline) Skip(skip int64) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if skip < 0 {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Skip received invalid value (%d), should be >= 0", skip)
+		return p
+	}
+	return p.addStage("skip", skip)
+}
+
+func (p *Pipeline) Limit(limit int64) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if limit < 1 {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Limit received invalid value (%d), should be >= 1", limit)
+		return p
+	}
+	return p.addStage("limit", limit)
+}
+
+func (p *Pipeline) Project(projection interface{}) mongodb.IPipeline {
+	return p.addDocStage("project", projection)
+}
+
+func (p *Pipeline) AddFields(fields interface{}) mongodb.IPipeline {
+	return p.addDocStage("addFields", fields)
+}
+
+func (p *Pipeline) Count(field string) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+	if field == "" {
+		p.Err = cExceptions.InvalidParamError("Pipeline.Count received an empty field")
+		return p
+	}
+	return p.addStage("count", field)
+}
+
+// addStage appends the stage typ, whose parameters payload is sent under the key typ.
+func (p *Pipeline) addStage(typ string, payload interface{}) mongodb.IPipeline {
+	p.Args.Pipeline = append(p.Args.Pipeline, cond.M{
+		"type": typ,
+		typ:    payload,
+	})
+	return p
+}
+
+// addDocStage is addStage for the stages whose parameters are a struct or a map.
+func (p *Pipeline) addDocStage(typ string, payload interface{}) mongodb.IPipeline {
+	if p.Err != nil {
+		return p
+	}
+
+	typOf := reflect.TypeOf(payload)
+	if typOf != nil && typOf.Kind() == reflect.Ptr {
+		typOf = typOf.Elem()
+	}
+	if typOf == nil || (typOf.Kind() != reflect.Struct && typOf.Kind() != reflect.Map) {
+		p.Err = cExceptions.InvalidParamError("Pipeline %s received invalid type, should be struct or map, but received %v", typ, typOf)
+		return p
+	}
+	return p.addStage(typ, payload)
+}
+
+// addSort adds fields to the sort stage ending the pipeline, so that consecutive Sort and
+// SortDesc sort by all their fields in order, or appends a sort stage.
+func (p *Pipeline) addSort(direct int64, fields []string) mongodb.IPipeline {
+	if p.Err != nil || len(fields) == 0 {
+		return p
+	}
+
+	var sort bson.D
+	if n := len(p.Args.Pipeline); n > 0 && p.Args.Pipeline[n-1]["type"] == "sort" {
+		sort = p.Args.Pipeline[n-1]["sort"].(bson.D)
+		p.Args.Pipeline = p.Args.Pipeline[:n-1]
+	}
+	for _, field := range fields {
+		if field == "" {
+			p.Err = cExceptions.InvalidParamError("Pipeline.Sort received an empty field")
+			return p
+		}
+		sort = append(sort, bson.E{Key: field, Value: direct})
+	}
+	return p.addStage("sort", sort)
+}
+
+// groupID returns the _id of a group stage grouping by field, as GroupBy does: a field name,
+// the names of the fields, or the field of each key for slices and maps of strings. Other
+// values are used as is, nil grouping all the records together.
+func groupID(field interface{}) interface{} {
+	switch f := field.(type) {
+	case string:
+		return "$" + f
+	case []string:
+		m := cond.M{}
+		for _, v := range f {
+			m[v] = "$" + v
+		}
+		return m
+	case map[string]string:
+		m := cond.M{}
+		for k, v := range f {
+			m[k] = "$" + v
+		}
+		return m
+	default:
+		return field
+	}
+}
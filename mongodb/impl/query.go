@@ -4,6 +4,7 @@
 package impl
 
 import (
+	"github.com/byted-apaas/baas-sdk-go/common/structs"
 	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
 	"context"
 	"reflect"
@@ -11,6 +12,7 @@ import (
 	"github.com/byted-apaas/baas-sdk-go/mongodb"
 	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
 	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/update"
 	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
 )
 
@@ -22,6 +24,7 @@ const (
 type Query struct {
 	*MongodbParam
 	conditions []interface{}
+	transport  faasinfra.MongodbTransport
 }
 
 func NewQuery(tableName string) *Query {
@@ -33,86 +36,120 @@ func NewQuery(tableName string) *Query {
 }
 
 func (q *Query) Update(ctx context.Context, record interface{}) error {
-	if q.Err != nil {
-		return q.Err
+	if err := q.buildUpdate(record, true, false); err != nil {
+		return err
 	}
+	return faasinfra.Update(ctx, q.transport, q.MongodbParam)
+}
 
-	typ := reflect.TypeOf(record)
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
-	}
-	if typ.Kind() != reflect.Struct && typ.Kind() != reflect.Map {
-		return cExceptions.InvalidParamError("Update failed: record should be map or struct, but %s", typ)
+func (q *Query) Upsert(ctx context.Context, record interface{}) error {
+	if err := q.buildUpdate(record, true, true); err != nil {
+		return err
 	}
-
-	q.SetOp(OpType_Update)
-	q.SetUpdate(cond.M{op.Set: record})
-	q.SetOne(true)
-	q.SetUpsert(false)
-	q.buildQuery()
-	return faasinfra.Update(ctx, q.MongodbParam)
+	return faasinfra.Update(ctx, q.transport, q.MongodbParam)
 }
 
-func (q *Query) Upsert(ctx context.Context, record interface{}) error {
-	if q.Err != nil {
-		return q.Err
+func (q *Query) BatchUpdate(ctx context.Context, record interface{}) error {
+	if err := q.buildUpdate(record, false, false); err != nil {
+		return err
 	}
+	return faasinfra.Update(ctx, q.transport, q.MongodbParam)
+}
 
-	typ := reflect.TypeOf(record)
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
+func (q *Query) UpdateWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error) {
+	if err := q.buildUpdate(record, true, false); err != nil {
+		return nil, err
 	}
-	if typ.Kind() != reflect.Struct && typ.Kind() != reflect.Map {
-		return cExceptions.InvalidParamError("Update failed: record should be map or struct, but %s", typ)
+	return faasinfra.UpdateWithResult(ctx, q.transport, q.MongodbParam)
+}
+
+func (q *Query) UpsertWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error) {
+	if err := q.buildUpdate(record, true, true); err != nil {
+		return nil, err
 	}
+	return faasinfra.UpdateWithResult(ctx, q.transport, q.MongodbParam)
+}
 
-	q.SetOp(OpType_Update)
-	q.SetUpdate(cond.M{op.Set: record})
-	q.SetOne(true)
-	q.SetUpsert(true)
-	q.buildQuery()
-	return faasinfra.Update(ctx, q.MongodbParam)
+func (q *Query) BatchUpdateWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error) {
+	if err := q.buildUpdate(record, false, false); err != nil {
+		return nil, err
+	}
+	return faasinfra.UpdateWithResult(ctx, q.transport, q.MongodbParam)
 }
 
-func (q *Query) BatchUpdate(ctx context.Context, record interface{}) error {
+// buildUpdate sets the update of record, a map or a struct whose fields are set, or an
+// *update.Update.
+func (q *Query) buildUpdate(record interface{}, one, upsert bool) error {
 	if q.Err != nil {
 		return q.Err
 	}
 
-	typ := reflect.TypeOf(record)
-	if typ.Kind() == reflect.Ptr {
-		typ = typ.Elem()
-	}
-	if typ.Kind() != reflect.Struct && typ.Kind() != reflect.Map {
-		return cExceptions.InvalidParamError("Update failed: record should be map or struct, but %s", typ)
+	if u, ok := record.(*update.Update); ok {
+		doc, arrayFilters, err := u.Build()
+		if err != nil {
+			return err
+		}
+		q.SetUpdate(doc)
+		if len(arrayFilters) > 0 {
+			q.SetArrayFilters(arrayFilters)
+		}
+	} else {
+		typ := reflect.TypeOf(record)
+		if typ == nil {
+			return cExceptions.InvalidParamError("Update failed: record should be map, struct or *update.Update, but nil")
+		}
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct && typ.Kind() != reflect.Map {
+			return cExceptions.InvalidParamError("Update failed: record should be map, struct or *update.Update, but %s", typ)
+		}
+		q.SetUpdate(cond.M{op.Set: record})
 	}
 
 	q.SetOp(OpType_Update)
-	q.SetUpdate(cond.M{op.Set: record})
-	q.SetOne(false)
-	q.SetUpsert(false)
+	q.SetOne(one)
+	q.SetUpsert(upsert)
 	q.buildQuery()
-	return faasinfra.Update(ctx, q.MongodbParam)
+	return nil
 }
 
 func (q *Query) Delete(ctx context.Context) error {
-	if q.Err != nil {
-		return q.Err
+	if err := q.buildDelete(true); err != nil {
+		return err
 	}
-	q.SetOp(OpType_Delete)
-	q.SetOne(true)
-	q.buildQuery()
-	return faasinfra.Delete(ctx, q.MongodbParam)
+	return faasinfra.Delete(ctx, q.transport, q.MongodbParam)
 }
 
 func (q *Query) BatchDelete(ctx context.Context) error {
+	if err := q.buildDelete(false); err != nil {
+		return err
+	}
+	return faasinfra.Delete(ctx, q.transport, q.MongodbParam)
+}
+
+func (q *Query) DeleteWithResult(ctx context.Context) (*structs.DeleteResult, error) {
+	if err := q.buildDelete(true); err != nil {
+		return nil, err
+	}
+	return faasinfra.DeleteWithResult(ctx, q.transport, q.MongodbParam)
+}
+
+func (q *Query) BatchDeleteWithResult(ctx context.Context) (*structs.DeleteResult, error) {
+	if err := q.buildDelete(false); err != nil {
+		return nil, err
+	}
+	return faasinfra.DeleteWithResult(ctx, q.transport, q.MongodbParam)
+}
+
+func (q *Query) buildDelete(one bool) error {
 	if q.Err != nil {
 		return q.Err
 	}
 	q.SetOp(OpType_Delete)
-	q.SetOne(false)
+	q.SetOne(one)
 	q.buildQuery()
-	return faasinfra.Delete(ctx, q.MongodbParam)
+	return nil
 }
 
 func (q *Query) Find(ctx context.Context, records interface{}) error {
@@ -121,7 +158,7 @@ func (q *Query) Find(ctx context.Context, records interface{}) error {
 	}
 	q.SetOp(OpType_Find)
 	q.buildQuery()
-	return faasinfra.Find(ctx, q.MongodbParam, records)
+	return faasinfra.Find(ctx, q.transport, q.MongodbParam, records)
 }
 
 func (q *Query) FindOne(ctx context.Context, record interface{}) error {
@@ -131,7 +168,7 @@ func (q *Query) FindOne(ctx context.Context, record interface{}) error {
 	q.SetOp(OpType_FindOne)
 	q.SetLimit(1)
 	q.buildQuery()
-	return faasinfra.FindOne(ctx, q.MongodbParam, record)
+	return faasinfra.FindOne(ctx, q.transport, q.MongodbParam, record)
 }
 
 func (q *Query) Where(condition interface{}, args ...interface{}) mongodb.IQuery {
@@ -204,18 +241,22 @@ func (q *Query) Count(ctx context.Context) (int64, error) {
 
 	q.SetOp(OpType_Count)
 	q.buildQuery()
-	return faasinfra.Count(ctx, q.MongodbParam)
-}
-
-//func (q *Query) Distinct(ctx context.Context, field string, v interface{}) error {
-//	if q.Err != nil {
-//		return q.Err
-//	}
-//
-//	q.SetOp(OpType_Distinct)
-//	q.SetKey(field)
-//	return faasinfra.Distinct(ctx, q.MongodbParam, v)
-//}
+	return faasinfra.Count(ctx, q.transport, q.MongodbParam)
+}
+
+func (q *Query) Distinct(ctx context.Context, field string, v interface{}) error {
+	if q.Err != nil {
+		return q.Err
+	}
+	if field == "" {
+		return cExceptions.InvalidParamError("Distinct failed: field is empty")
+	}
+
+	q.SetOp(OpType_Distinct)
+	q.SetKey(field)
+	q.buildQuery()
+	return faasinfra.Distinct(ctx, q.transport, q.MongodbParam, v)
+}
 
 func (q *Query) Project(projection interface{}) mongodb.IQuery {
 	if q.Err != nil {
@@ -255,17 +255,17 @@ func TestQuery_Where_Gte_Count(t *testing.T) {
 }
 
 // Distinct
-//func TestQuery_Distinct(t *testing.T) {
-//	db := NewMongodb()
-//	T := db.Table("goods")
-//
-//	var cities []string
-//	err := T.Where(nil).Distinct(ctx, "info.city", &cities)
-//	if err != nil {
-//		panic(err)
-//	}
-//	utils.PrintLog(cities)
-//}
+func TestQuery_Distinct(t *testing.T) {
+	db := NewMongodb()
+	T := db.Table("goods")
+
+	var cities []string
+	err := T.Where(nil).Distinct(ctx, "info.city", &cities)
+	if err != nil {
+		panic(err)
+	}
+	utils.PrintLog(cities)
+}
 
 func TestQuery_Project(t *testing.T) {
 	db := NewMongodb()
@@ -16,6 +16,7 @@ import (
 
 type Table struct {
 	*MongodbParam
+	transport faasinfra.MongodbTransport
 }
 
 func NewTable(tableName string) *Table {
@@ -33,7 +34,7 @@ func (t *Table) Create(ctx context.Context, record interface{}) (*structs.Record
 
 	t.SetOp(OpType_Insert)
 	t.SetDocs([]interface{}{record})
-	return faasinfra.Create(ctx, t.MongodbParam)
+	return faasinfra.Create(ctx, t.transport, t.MongodbParam)
 }
 
 func (t *Table) BatchCreate(ctx context.Context, records interface{}) ([]primitive.ObjectID, error) {
@@ -43,13 +44,23 @@ func (t *Table) BatchCreate(ctx context.Context, records interface{}) ([]primiti
 
 	t.SetOp(OpType_Insert)
 	t.SetDocs(records)
-	return faasinfra.BatchCreate(ctx, t.MongodbParam)
+	return faasinfra.BatchCreate(ctx, t.transport, t.MongodbParam)
 }
 
 func (t *Table) Where(condition interface{}, args ...interface{}) mongodb.IQuery {
-	return NewQuery(t.MongodbParam.TableName).Where(condition, args)
+	q := NewQuery(t.MongodbParam.TableName)
+	q.transport = t.transport
+	return q.Where(condition, args)
 }
 
 func (q *Table) GroupBy(field interface{}, alias ...interface{}) mongodb.IAggQuery {
-	return NewAggQuery(q.TableName).GroupBy(field, alias...)
+	a := NewAggQuery(q.TableName)
+	a.transport = q.transport
+	return a.GroupBy(field, alias...)
+}
+
+func (q *Table) Aggregate() mongodb.IPipeline {
+	p := NewPipeline(q.TableName)
+	p.transport = q.transport
+	return p
 }
@@ -25,30 +25,43 @@ type ITable interface {
 
 	// 聚合查询
 	GroupBy(field interface{}, alias ...interface{}) IAggQuery
+	// 聚合管道：按调用顺序组合各阶段
+	Aggregate() IPipeline
 }
 
 // 查询
 type IQuery interface {
 	// 更新
+	// record 为 map 或 struct 时更新其中的字段，为 *update.Update 时执行其中的更新操作
 	Update(ctx context.Context, record interface{}) error
 	Upsert(ctx context.Context, record interface{}) error
 	BatchUpdate(ctx context.Context, record interface{}) error
+	// 更新并返回匹配、修改的记录数及 Upsert 插入记录的 _id
+	UpdateWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error)
+	UpsertWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error)
+	BatchUpdateWithResult(ctx context.Context, record interface{}) (*structs.UpdateResult, error)
 
 	// 删除
 	Delete(ctx context.Context) error
 	BatchDelete(ctx context.Context) error
+	// 删除并返回删除的记录数
+	DeleteWithResult(ctx context.Context) (*structs.DeleteResult, error)
+	BatchDeleteWithResult(ctx context.Context) (*structs.DeleteResult, error)
 
 	// 查询
 	Find(ctx context.Context, v interface{}) error
 	FindOne(ctx context.Context, v interface{}) error
 
 	Count(ctx context.Context) (int64, error)
+	// 去重查询：field 的不同取值写入 v，v 为指向切片的指针，数组字段按其元素去重
+	Distinct(ctx context.Context, field string, v interface{}) error
 
 	Where(condition interface{}, args ...interface{}) IQuery
 	Limit(limit int64) IQuery
 	Offset(offset int64) IQuery
 	OrderBy(fields ...string) IQuery
 	OrderByDesc(fields ...string) IQuery
+	// 显示的字段，可包含 expr 包构造的计算字段
 	Project(v interface{}) IQuery
 }
 
@@ -57,8 +70,11 @@ type IAggQuery interface {
 	Find(ctx context.Context, records interface{}) error
 	FindOne(ctx context.Context, record interface{}) error
 
-	// 分组
+	// 分组，field 可为 expr 包构造的表达式，如按天分组 GroupBy(expr.DateToString("%Y-%m-%d", "$createdAt"), "day")
 	GroupBy(field interface{}, alias ...interface{}) IAggQuery
+	// 分组前过滤记录，条件同 IQuery.Where
+	Where(condition interface{}) IAggQuery
+	// 分组后过滤分组，条件引用分组结果的字段：Sum 等的别名，及 GroupBy 的别名（未指定时为 _id）
 	Having(condition interface{}) IAggQuery
 
 	// 显示的字段
@@ -68,17 +84,49 @@ type IAggQuery interface {
 	// 分组中的最后 1 个
 	Last(field interface{}, alias ...interface{}) IAggQuery
 	// 分组中去重
-	AddToSet(field string, alias ...interface{}) IAggQuery
+	AddToSet(field interface{}, alias ...interface{}) IAggQuery
 
 	// 计算
+	// field 为字段名，或 expr 包构造的表达式（须指定别名），如 Sum(expr.Multiply("$price", "$qty"), "revenue")
 	// 分组求和
-	Sum(field string, args ...interface{}) IAggQuery
+	Sum(field interface{}, args ...interface{}) IAggQuery
 	// 分组求计数
 	Num(field string) IAggQuery
 	// 分组求平均
-	Avg(field string, args ...interface{}) IAggQuery
+	Avg(field interface{}, args ...interface{}) IAggQuery
 	// 分组求总体标准差
-	StdDevPop(field string, args ...interface{}) IAggQuery
+	StdDevPop(field interface{}, args ...interface{}) IAggQuery
 	// 分组求样本标准差
-	StdDevSamp(field string, args ...interface{}) IAggQuery
+	StdDevSamp(field interface{}, args ...interface{}) IAggQuery
+}
+
+// 聚合管道，除 Find、FindOne 外的每个方法追加一个阶段，服务端按顺序执行
+type IPipeline interface {
+	Find(ctx context.Context, records interface{}) error
+	FindOne(ctx context.Context, record interface{}) error
+
+	// 过滤，条件同 Where
+	Match(condition interface{}) IPipeline
+	// 展开数组字段，每个元素输出一条记录
+	Unwind(field string) IPipeline
+	// 关联查询：from 表中 foreignField 等于本表 localField 的记录写入数组字段 as
+	Lookup(from, localField, foreignField, as string) IPipeline
+	// 关联查询：pipeline 所属表中经 pipeline 处理的记录写入数组字段 as
+	// let 为 变量名 -> 本表字段（字符串）或表达式，pipeline 中以 "$$变量名" 引用，如
+	// LookupPipeline(cond.M{"item": "item"}, db.Table("orders").Aggregate().Match(cond.M{op.Expr: cond.M{op.Eq: cond.A{"$sku", "$$item"}}}), "orders")
+	LookupPipeline(let map[string]interface{}, pipeline IPipeline, as string) IPipeline
+	// 分组：field 同 GroupBy，为 nil 时全部记录为一组，结果的 _id 为分组的值
+	// accumulators 为 别名 -> 累加器，如 cond.M{"total": cond.M{op.Sum: "$qty"}}
+	Group(field interface{}, accumulators map[string]interface{}) IPipeline
+	// 排序，连续的 Sort、SortDesc 合并为一个阶段
+	Sort(fields ...string) IPipeline
+	SortDesc(fields ...string) IPipeline
+	Skip(skip int64) IPipeline
+	Limit(limit int64) IPipeline
+	// 显示的字段，可包含计算字段，如 cond.M{"item": 1, "city": "$info.city", "revenue": expr.Multiply("$price", "$qty")}
+	Project(v interface{}) IPipeline
+	// 添加或替换字段
+	AddFields(fields interface{}) IPipeline
+	// 计数，输出一条记录，field 为计数的字段名
+	Count(field string) IPipeline
 }
@@ -0,0 +1,477 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// aggregate runs the pipeline stages built by impl.AggQuery and impl.Pipeline over docs,
+// joining the tables of lookup stages. As the server does, the idAlias of a group stage only
+// renames the _id of the results, the stages after it still seeing _id.
+func aggregate(tables map[string][]primitive.D, docs []primitive.D, pipeline []primitive.D) ([]primitive.D, error) {
+	var err error
+	var idAlias string
+	for _, stage := range pipeline {
+		typ, _ := docGet(stage, "type")
+		switch typ {
+		case "matchGeneral":
+			filter, _ := docGet(stage, "match")
+			docs, err = filterDocs(docs, filter)
+		case "group":
+			group, _ := docGet(stage, "group")
+			alias, _ := docGet(stage, "idAlias")
+			idAlias = toString(alias)
+			docs, err = groupDocs(docs, group)
+		case "lookup":
+			spec, _ := docGet(stage, "lookup")
+			docs, err = lookupDocs(tables, docs, spec)
+		case "unwind":
+			path, _ := docGet(stage, "unwind")
+			docs, err = unwindDocs(docs, path)
+		case "sort":
+			spec, _ := docGet(stage, "sort")
+			d, ok := toDoc(spec)
+			if !ok {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] sort should be a document, but %T", spec)
+			}
+			sortDocs(docs, d)
+		case "skip":
+			v, _ := docGet(stage, "skip")
+			if n, _ := toInt(v); n >= int64(len(docs)) {
+				docs = nil
+			} else if n > 0 {
+				docs = docs[n:]
+			}
+		case "limit":
+			v, _ := docGet(stage, "limit")
+			if n, _ := toInt(v); n < int64(len(docs)) {
+				docs = docs[:n]
+			}
+		case "project":
+			spec, _ := docGet(stage, "project")
+			docs, err = mapDocs(docs, spec, projectExpr)
+		case "addFields":
+			spec, _ := docGet(stage, "addFields")
+			docs, err = mapDocs(docs, spec, addFields)
+		case "count":
+			field, _ := docGet(stage, "count")
+			if len(docs) > 0 {
+				docs = []primitive.D{{{Key: toString(field), Value: int32(len(docs))}}}
+			}
+		default:
+			err = cExceptions.InvalidParamError("[mongodbtest] unsupported pipeline stage %v", typ)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if idAlias != "" {
+		for _, doc := range docs {
+			for i := range doc {
+				if doc[i].Key == "_id" {
+					doc[i].Key = idAlias
+				}
+			}
+		}
+	}
+	return docs, nil
+}
+
+func filterDocs(docs []primitive.D, filter interface{}) ([]primitive.D, error) {
+	var res []primitive.D
+	for _, doc := range docs {
+		ok, err := match(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			res = append(res, doc)
+		}
+	}
+	return res, nil
+}
+
+// lookupDocs sets the field as of docs to the documents of the table from matching them,
+// either those whose foreignField equals localField, or those output by pipeline run with
+// the variables of let.
+func lookupDocs(tables map[string][]primitive.D, docs []primitive.D, stage interface{}) ([]primitive.D, error) {
+	spec, ok := toDoc(stage)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] lookup should be a document, but %T", stage)
+	}
+	from, _ := docGet(spec, "from")
+	as, _ := docGet(spec, "as")
+	localField, simple := docGet(spec, "localField")
+	foreignField, _ := docGet(spec, "foreignField")
+	let, _ := docGet(spec, "let")
+	var pipeline []primitive.D
+	if stages, ok := docGet(spec, "pipeline"); ok {
+		if pipeline, ok = toStages(stages); !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] lookup pipeline should be an array of documents, but %T", stages)
+		}
+	}
+	if toString(from) == "" || toString(as) == "" {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] lookup requires from and as")
+	}
+
+	res := make([]primitive.D, 0, len(docs))
+	for _, doc := range docs {
+		foreign := make([]primitive.D, 0, len(tables[toString(from)]))
+		for _, f := range tables[toString(from)] {
+			foreign = append(foreign, copyDoc(f))
+		}
+
+		var joined []primitive.D
+		var err error
+		if simple {
+			var locals primitive.A
+			for _, v := range lookup(doc, toString(localField)) {
+				if a, ok := v.(primitive.A); ok {
+					locals = append(locals, a...)
+				} else {
+					locals = append(locals, v)
+				}
+			}
+			if len(locals) == 0 {
+				locals = primitive.A{nil}
+			}
+			joined, err = filterDocs(foreign, primitive.D{{Key: toString(foreignField), Value: primitive.D{{Key: op.In, Value: locals}}}})
+		} else {
+			vars := map[string]interface{}{}
+			if d, ok := toDoc(let); ok {
+				for _, e := range d {
+					if vars[e.Key], err = evalExpr(doc, e.Value); err != nil {
+						return nil, err
+					}
+				}
+			}
+			stages := make([]primitive.D, 0, len(pipeline))
+			for _, stage := range pipeline {
+				stages = append(stages, bindVars(stage, vars).(primitive.D))
+			}
+			joined, err = aggregate(tables, foreign, stages)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		arr := make(primitive.A, 0, len(joined))
+		for _, j := range joined {
+			arr = append(arr, j)
+		}
+		if doc, err = setPath(doc, toString(as), arr); err != nil {
+			return nil, err
+		}
+		res = append(res, doc)
+	}
+	return res, nil
+}
+
+// toStages returns the stages of a pipeline.
+func toStages(v interface{}) ([]primitive.D, bool) {
+	stages, ok := v.(primitive.A)
+	if !ok {
+		return nil, false
+	}
+	res := make([]primitive.D, 0, len(stages))
+	for _, stage := range stages {
+		d, ok := toDoc(stage)
+		if !ok {
+			return nil, false
+		}
+		res = append(res, d)
+	}
+	return res, true
+}
+
+// unwindDocs outputs a document per element of the array at path, a "$field" reference,
+// holding the element in place of the array. The documents missing it are dropped.
+func unwindDocs(docs []primitive.D, path interface{}) ([]primitive.D, error) {
+	field := toString(path)
+	if !strings.HasPrefix(field, "$") {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] unwind path should start with $, but %v", path)
+	}
+	field = field[1:]
+
+	var res []primitive.D
+	for _, doc := range docs {
+		switch v := lookupOne(doc, field).(type) {
+		case missing, nil:
+		case primitive.A:
+			for _, e := range v {
+				out, err := setPath(copyDoc(doc), field, e)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, out)
+			}
+		default:
+			res = append(res, doc)
+		}
+	}
+	return res, nil
+}
+
+// mapDocs replaces each of docs by fn(doc, spec).
+func mapDocs(docs []primitive.D, spec interface{}, fn func(doc, spec primitive.D) (primitive.D, error)) ([]primitive.D, error) {
+	d, ok := toDoc(spec)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] stage should be a document, but %T", spec)
+	}
+	res := make([]primitive.D, 0, len(docs))
+	for _, doc := range docs {
+		out, err := fn(doc, d)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, out)
+	}
+	return res, nil
+}
+
+// projectExpr is project for the project stage and the projection of find, whose fields may
+// also be expressions computing new fields, which make it an inclusion projection.
+func projectExpr(doc, spec primitive.D) (primitive.D, error) {
+	var plain, computed primitive.D
+	for _, e := range spec {
+		switch e.Value.(type) {
+		case bool, int32, int64, float64:
+			plain = append(plain, e)
+		default:
+			computed = append(computed, e)
+		}
+	}
+	if len(computed) == 0 {
+		return project(doc, plain)
+	}
+
+	res, err := include(doc, plain)
+	if err != nil {
+		return nil, err
+	}
+	return setFields(doc, computed, res)
+}
+
+func addFields(doc, spec primitive.D) (primitive.D, error) {
+	return setFields(doc, spec, doc)
+}
+
+// setFields sets the fields of spec, expressions evaluated against doc, on out.
+func setFields(doc, spec, out primitive.D) (primitive.D, error) {
+	for _, e := range spec {
+		v, err := evalExpr(doc, e.Value)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(missing); ok {
+			continue
+		}
+		if out, err = setPath(out, e.Key, v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// evalExpr evaluates an aggregation expression against doc: "$field" references, literals,
+// and documents or arrays made of them.
+func evalExpr(doc primitive.D, expr interface{}) (interface{}, error) {
+	switch e := expr.(type) {
+	case string:
+		if strings.HasPrefix(e, "$") {
+			return lookupOne(doc, e[1:]), nil
+		}
+		return e, nil
+	case primitive.D:
+		if isOperatorDoc(e) {
+			if len(e) != 1 {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] expression should hold a single operator, but %v", e)
+			}
+			return evalOperator(doc, e[0].Key, e[0].Value)
+		}
+		res := make(primitive.D, 0, len(e))
+		for _, f := range e {
+			v, err := evalExpr(doc, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := v.(missing); !ok {
+				res = append(res, primitive.E{Key: f.Key, Value: v})
+			}
+		}
+		return res, nil
+	case primitive.A:
+		res := make(primitive.A, 0, len(e))
+		for _, item := range e {
+			v, err := evalExpr(doc, item)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := v.(missing); ok {
+				v = nil
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	default:
+		return expr, nil
+	}
+}
+
+type groupBucket struct {
+	id   interface{}
+	docs []primitive.D
+}
+
+func groupDocs(docs []primitive.D, group interface{}) ([]primitive.D, error) {
+	spec, ok := toDoc(group)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] group should be a document, but %T", group)
+	}
+	idExpr, _ := docGet(spec, "_id")
+
+	var buckets []*groupBucket
+	for _, doc := range docs {
+		id, err := evalExpr(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := id.(missing); ok {
+			id = nil
+		}
+		var bucket *groupBucket
+		for _, b := range buckets {
+			if compareValues(b.id, id) == 0 {
+				bucket = b
+				break
+			}
+		}
+		if bucket == nil {
+			bucket = &groupBucket{id: id}
+			buckets = append(buckets, bucket)
+		}
+		bucket.docs = append(bucket.docs, doc)
+	}
+
+	res := make([]primitive.D, 0, len(buckets))
+	for _, b := range buckets {
+		out := primitive.D{{Key: "_id", Value: b.id}}
+		for _, f := range spec {
+			if f.Key == "_id" {
+				continue
+			}
+			v, err := accumulate(b.docs, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, primitive.E{Key: f.Key, Value: v})
+		}
+		res = append(res, out)
+	}
+	return res, nil
+}
+
+func accumulate(docs []primitive.D, acc interface{}) (interface{}, error) {
+	spec, ok := toDoc(acc)
+	if !ok || len(spec) != 1 {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] accumulator should be a single operator document, but %v", acc)
+	}
+	operator, expr := spec[0].Key, spec[0].Value
+
+	values := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		v, err := evalExpr(doc, expr)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	switch operator {
+	case op.Sum:
+		var sum interface{} = int32(0)
+		for _, v := range values {
+			if isNumber(v) {
+				sum = addNumbers(sum, v)
+			}
+		}
+		return sum, nil
+	case op.Avg:
+		nums := numbers(values)
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		var sum float64
+		for _, n := range nums {
+			sum += n
+		}
+		return sum / float64(len(nums)), nil
+	case op.StdDevPop, op.StdDevSamp:
+		nums := numbers(values)
+		n := len(nums)
+		if n == 0 || (operator == op.StdDevSamp && n < 2) {
+			return nil, nil
+		}
+		var mean, sq float64
+		for _, x := range nums {
+			mean += x
+		}
+		mean /= float64(n)
+		for _, x := range nums {
+			sq += (x - mean) * (x - mean)
+		}
+		if operator == op.StdDevSamp {
+			return math.Sqrt(sq / float64(n-1)), nil
+		}
+		return math.Sqrt(sq / float64(n)), nil
+	case op.First, op.Last:
+		if len(values) == 0 {
+			return nil, nil
+		}
+		v := values[0]
+		if operator == op.Last {
+			v = values[len(values)-1]
+		}
+		if _, ok := v.(missing); ok {
+			return nil, nil
+		}
+		return v, nil
+	case op.Push, op.AddToSet:
+		res := primitive.A{}
+	next:
+		for _, v := range values {
+			if _, ok := v.(missing); ok {
+				continue
+			}
+			if operator == op.AddToSet {
+				for _, existing := range res {
+					if equalValues(existing, v) {
+						continue next
+					}
+				}
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	default:
+		return nil, cExceptions.InvalidParamError("[mongodbtest] unsupported accumulator %s", operator)
+	}
+}
+
+func numbers(values []interface{}) []float64 {
+	var res []float64
+	for _, v := range values {
+		if f, ok := toFloat(v); ok {
+			res = append(res, f)
+		}
+	}
+	return res
+}
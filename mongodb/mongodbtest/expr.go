@@ -0,0 +1,614 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// evalOperator evaluates the expression operator name against doc.
+func evalOperator(doc primitive.D, name string, arg interface{}) (interface{}, error) {
+	switch name {
+	case op.Literal:
+		return arg, nil
+	case op.DateToString, op.Year, op.Month, op.DayOfMonth, op.DayOfWeek, op.Hour, op.Minute, op.Second:
+		return evalDate(doc, name, arg)
+	case op.Filter, op.Map:
+		return evalIteration(doc, name, arg)
+	}
+	args, err := evalArgs(doc, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case op.Add, op.Multiply:
+		return evalNumbers(name, args, func(res, v interface{}) interface{} {
+			if name == op.Add {
+				return addNumbers(res, v)
+			}
+			return mulNumbers(res, v)
+		})
+	case op.Subtract, op.Divide, op.Mod:
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		return evalBinary(name, args[0], args[1])
+	case op.Abs, op.Ceil, op.Floor:
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		return evalUnary(name, args[0])
+	case op.Round:
+		if len(args) == 1 {
+			args = append(args, int32(0))
+		}
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		return evalRound(args[0], args[1])
+	case op.Cond:
+		if err := arity(name, args, 3); err != nil {
+			return nil, err
+		}
+		if truthy(args[0]) {
+			return args[1], nil
+		}
+		return args[2], nil
+	case op.IfNull:
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		if typeClass(args[0]) == classNull || typeClass(args[0]) == classMissing {
+			return args[1], nil
+		}
+		return args[0], nil
+	case op.Concat, op.SubstrCP, op.StrLenCP, op.ToLower, op.ToUpper, op.Split:
+		return evalString(name, args)
+	case op.Size, op.ArrayElemAt, op.ConcatArrays, op.Slice:
+		return evalArray(name, args)
+	case op.Eq, op.Ne, op.Gt, op.Gte, op.Lt, op.Lte:
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		c := compareValues(args[0], args[1])
+		switch name {
+		case op.Eq:
+			return c == 0, nil
+		case op.Ne:
+			return c != 0, nil
+		case op.Gt:
+			return c > 0, nil
+		case op.Gte:
+			return c >= 0, nil
+		case op.Lt:
+			return c < 0, nil
+		default:
+			return c <= 0, nil
+		}
+	case op.And:
+		for _, a := range args {
+			if !truthy(a) {
+				return false, nil
+			}
+		}
+		return true, nil
+	case op.Or:
+		for _, a := range args {
+			if truthy(a) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case op.Not:
+		return len(args) == 0 || !truthy(args[0]), nil
+	case op.In:
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		arr, ok := args[1].(primitive.A)
+		if !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] the second argument of %s should be an array, but %T", name, args[1])
+		}
+		return containsValue(arr, args[0]), nil
+	default:
+		return nil, cExceptions.InvalidParamError("[mongodbtest] unsupported expression operator %s", name)
+	}
+}
+
+func arity(name string, args []interface{}, n int) error {
+	if len(args) != n {
+		return cExceptions.InvalidParamError("[mongodbtest] %s takes %d arguments, but %d", name, n, len(args))
+	}
+	return nil
+}
+
+// isNull reports whether v is null or missing, which make most operators return null.
+func isNull(v interface{}) bool {
+	c := typeClass(v)
+	return c == classNull || c == classMissing
+}
+
+// evalNumbers folds the numbers args with fn, returning null when any of them is null.
+func evalNumbers(name string, args []interface{}, fn func(res, v interface{}) interface{}) (interface{}, error) {
+	var res interface{}
+	for _, v := range args {
+		if isNull(v) {
+			return nil, nil
+		}
+		if !isNumber(v) {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s only supports numbers, but %T", name, v)
+		}
+		if res == nil {
+			res = v
+		} else {
+			res = fn(res, v)
+		}
+	}
+	if res == nil && name == op.Multiply {
+		return int32(1), nil
+	}
+	if res == nil {
+		return int32(0), nil
+	}
+	return res, nil
+}
+
+func evalBinary(name string, a, b interface{}) (interface{}, error) {
+	if isNull(a) || isNull(b) {
+		return nil, nil
+	}
+	if !isNumber(a) || !isNumber(b) {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s only supports numbers, but %T and %T", name, a, b)
+	}
+	x, xInt := toInt(a)
+	y, yInt := toInt(b)
+	fx, _ := toFloat(a)
+	fy, _ := toFloat(b)
+	switch name {
+	case op.Subtract:
+		if xInt && yInt {
+			return narrowInt(a, b, x-y), nil
+		}
+		return fx - fy, nil
+	case op.Divide:
+		if fy == 0 {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s by 0", name)
+		}
+		return fx / fy, nil
+	default:
+		if fy == 0 {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s by 0", name)
+		}
+		if xInt && yInt {
+			return narrowInt(a, b, x%y), nil
+		}
+		return math.Mod(fx, fy), nil
+	}
+}
+
+func evalUnary(name string, v interface{}) (interface{}, error) {
+	if isNull(v) {
+		return nil, nil
+	}
+	if !isNumber(v) {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s only supports numbers, but %T", name, v)
+	}
+	if n, ok := toInt(v); ok {
+		if name == op.Abs && n < 0 {
+			return narrowInt(v, v, -n), nil
+		}
+		return v, nil
+	}
+	f, _ := toFloat(v)
+	switch name {
+	case op.Abs:
+		return math.Abs(f), nil
+	case op.Ceil:
+		return math.Ceil(f), nil
+	default:
+		return math.Floor(f), nil
+	}
+}
+
+// evalRound rounds half to even, as mongodb does.
+func evalRound(v, place interface{}) (interface{}, error) {
+	if isNull(v) {
+		return nil, nil
+	}
+	p, ok := toInt(place)
+	if !isNumber(v) || !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s only supports numbers, but %T and %T", op.Round, v, place)
+	}
+	scale := math.Pow(10, float64(p))
+	if n, ok := toInt(v); ok {
+		if p >= 0 {
+			return v, nil
+		}
+		return narrowInt(v, v, int64(math.RoundToEven(float64(n)*scale)/scale)), nil
+	}
+	f, _ := toFloat(v)
+	return math.RoundToEven(f*scale) / scale, nil
+}
+
+func evalString(name string, args []interface{}) (interface{}, error) {
+	for _, v := range args {
+		if isNull(v) {
+			switch name {
+			case op.Concat, op.Split:
+				return nil, nil
+			case op.ToLower, op.ToUpper, op.SubstrCP:
+				return "", nil
+			}
+		}
+	}
+	strs := make([]string, 0, len(args))
+	for i, v := range args {
+		if name == op.SubstrCP && i > 0 {
+			break
+		}
+		if typeClass(v) != classString {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s only supports strings, but %T", name, v)
+		}
+		strs = append(strs, toString(v))
+	}
+
+	switch name {
+	case op.Concat:
+		return strings.Join(strs, ""), nil
+	case op.ToLower:
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		return strings.ToLower(strs[0]), nil
+	case op.ToUpper:
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		return strings.ToUpper(strs[0]), nil
+	case op.StrLenCP:
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		return int32(utf8.RuneCountInString(strs[0])), nil
+	case op.Split:
+		if err := arity(name, args, 2); err != nil {
+			return nil, err
+		}
+		res := primitive.A{}
+		for _, part := range strings.Split(strs[0], strs[1]) {
+			res = append(res, part)
+		}
+		return res, nil
+	default:
+		if err := arity(name, args, 3); err != nil {
+			return nil, err
+		}
+		start, ok1 := toInt(args[1])
+		length, ok2 := toInt(args[2])
+		if !ok1 || !ok2 || start < 0 {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s takes a string and 2 non negative integers", name)
+		}
+		runes := []rune(strs[0])
+		if start >= int64(len(runes)) {
+			return "", nil
+		}
+		end := int64(len(runes))
+		if length >= 0 && start+length < end {
+			end = start + length
+		}
+		return string(runes[start:end]), nil
+	}
+}
+
+func evalArray(name string, args []interface{}) (interface{}, error) {
+	for _, v := range args {
+		if isNull(v) {
+			return nil, nil
+		}
+	}
+	arrayArg := func(v interface{}) (primitive.A, error) {
+		a, ok := v.(primitive.A)
+		if !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s expects an array, but %T", name, v)
+		}
+		return a, nil
+	}
+
+	switch name {
+	case op.ConcatArrays:
+		res := primitive.A{}
+		for _, v := range args {
+			a, err := arrayArg(v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, a...)
+		}
+		return res, nil
+	case op.Size:
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		a, err := arrayArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return int32(len(a)), nil
+	}
+
+	if err := arity(name, args, 2); err != nil {
+		return nil, err
+	}
+	a, err := arrayArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n, ok := toInt(args[1])
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s expects an integer, but %T", name, args[1])
+	}
+	if name == op.ArrayElemAt {
+		if n < 0 {
+			n += int64(len(a))
+		}
+		if n < 0 || n >= int64(len(a)) {
+			return missing{}, nil
+		}
+		return a[n], nil
+	}
+	if n < 0 {
+		if -n < int64(len(a)) {
+			return a[int64(len(a))+n:], nil
+		}
+		return a, nil
+	}
+	if n < int64(len(a)) {
+		return a[:n], nil
+	}
+	return a, nil
+}
+
+// evalDate evaluates the date operators, which take either a date or a document holding the
+// date, a timezone and, for $dateToString, a format.
+func evalDate(doc primitive.D, name string, arg interface{}) (interface{}, error) {
+	var dateExpr, tzExpr, formatExpr interface{}
+	if spec, ok := toDoc(arg); ok && !isOperatorDoc(spec) {
+		dateExpr, _ = docGet(spec, "date")
+		tzExpr, _ = docGet(spec, "timezone")
+		formatExpr, _ = docGet(spec, "format")
+	} else {
+		args, err := evalArgs(doc, arg)
+		if err != nil {
+			return nil, err
+		}
+		if err := arity(name, args, 1); err != nil {
+			return nil, err
+		}
+		dateExpr = primitive.D{{Key: op.Literal, Value: args[0]}}
+	}
+
+	date, err := evalExpr(doc, dateExpr)
+	if err != nil {
+		return nil, err
+	}
+	if isNull(date) {
+		return nil, nil
+	}
+	dt, ok := date.(primitive.DateTime)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s expects a date, but %T", name, date)
+	}
+	loc := time.UTC
+	if tzExpr != nil {
+		tz, err := evalExpr(doc, tzExpr)
+		if err != nil {
+			return nil, err
+		}
+		if loc, err = location(toString(tz)); err != nil {
+			return nil, err
+		}
+	}
+	t := dt.Time().In(loc)
+
+	switch name {
+	case op.DateToString:
+		format := "%Y-%m-%dT%H:%M:%S.%LZ"
+		if formatExpr != nil {
+			f, err := evalExpr(doc, formatExpr)
+			if err != nil {
+				return nil, err
+			}
+			format = toString(f)
+		}
+		return formatDate(t, format)
+	case op.Year:
+		return int32(t.Year()), nil
+	case op.Month:
+		return int32(t.Month()), nil
+	case op.DayOfMonth:
+		return int32(t.Day()), nil
+	case op.DayOfWeek:
+		return int32(t.Weekday()) + 1, nil
+	case op.Hour:
+		return int32(t.Hour()), nil
+	case op.Minute:
+		return int32(t.Minute()), nil
+	default:
+		return int32(t.Second()), nil
+	}
+}
+
+// location returns the timezone tz, an Olson name or an offset such as "+08:00".
+func location(tz string) (*time.Location, error) {
+	if len(tz) > 0 && (tz[0] == '+' || tz[0] == '-') {
+		digits := strings.Replace(tz[1:], ":", "", 1)
+		if len(digits) == 2 {
+			digits += "00"
+		}
+		hours, err1 := strconv.Atoi(digits[:2])
+		minutes, err2 := strconv.Atoi(digits[2:])
+		if len(digits) != 4 || err1 != nil || err2 != nil {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] invalid timezone %s", tz)
+		}
+		offset := hours*3600 + minutes*60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+		return time.FixedZone(tz, offset), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] invalid timezone %s, err: %v", tz, err)
+	}
+	return loc, nil
+}
+
+func formatDate(t time.Time, format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i++; i == len(format) {
+			return "", cExceptions.InvalidParamError("[mongodbtest] date format %q ends with %%", format)
+		}
+		switch format[i] {
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", t.Year())
+		case 'm':
+			fmt.Fprintf(&b, "%02d", t.Month())
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 'L':
+			fmt.Fprintf(&b, "%03d", t.Nanosecond()/int(time.Millisecond))
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case '%':
+			b.WriteByte('%')
+		default:
+			return "", cExceptions.InvalidParamError("[mongodbtest] unsupported date format %%%c", format[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// evalIteration evaluates $filter and $map, binding the element of the input to the variable
+// named by as.
+func evalIteration(doc primitive.D, name string, arg interface{}) (interface{}, error) {
+	spec, ok := toDoc(arg)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s should be a document, but %T", name, arg)
+	}
+	inputExpr, _ := docGet(spec, "input")
+	as, _ := docGet(spec, "as")
+	body, _ := docGet(spec, "cond")
+	if name == op.Map {
+		body, _ = docGet(spec, "in")
+	}
+	if toString(as) == "" {
+		as = "this"
+	}
+
+	input, err := evalExpr(doc, inputExpr)
+	if err != nil {
+		return nil, err
+	}
+	if isNull(input) {
+		return nil, nil
+	}
+	arr, ok := input.(primitive.A)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s input should be an array, but %T", name, input)
+	}
+
+	res := primitive.A{}
+	for _, e := range arr {
+		v, err := evalExpr(doc, bindVars(body, map[string]interface{}{toString(as): e}))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case name == op.Map:
+			if _, ok := v.(missing); ok {
+				v = nil
+			}
+			res = append(res, v)
+		case truthy(v):
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// evalArgs evaluates the arguments of an operator, an array of expressions or a single one.
+func evalArgs(doc primitive.D, arg interface{}) ([]interface{}, error) {
+	v, err := evalExpr(doc, arg)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := arg.(primitive.A); ok {
+		return v.(primitive.A), nil
+	}
+	if _, ok := v.(missing); ok {
+		v = nil
+	}
+	return []interface{}{v}, nil
+}
+
+// bindVars replaces the "$$name" references of expr to the variables of vars by literals.
+func bindVars(expr interface{}, vars map[string]interface{}) interface{} {
+	switch e := expr.(type) {
+	case string:
+		if !strings.HasPrefix(e, "$$") {
+			return e
+		}
+		name, path := e[2:], ""
+		if i := strings.Index(name, "."); i >= 0 {
+			name, path = name[:i], name[i+1:]
+		}
+		v, ok := vars[name]
+		if !ok {
+			return e
+		}
+		if path != "" {
+			v = lookupOne(v, path)
+		}
+		if _, ok := v.(missing); ok {
+			v = nil
+		}
+		return primitive.D{{Key: "$literal", Value: v}}
+	case primitive.D:
+		res := make(primitive.D, 0, len(e))
+		for _, f := range e {
+			res = append(res, primitive.E{Key: f.Key, Value: bindVars(f.Value, vars)})
+		}
+		return res
+	case primitive.A:
+		res := make(primitive.A, 0, len(e))
+		for _, item := range e {
+			res = append(res, bindVars(item, vars))
+		}
+		return res
+	default:
+		return expr
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// match reports whether doc satisfies the query filter.
+func match(doc primitive.D, filter interface{}) (bool, error) {
+	switch f := filter.(type) {
+	case nil, missing:
+		return true, nil
+	case primitive.D:
+		for _, e := range f {
+			ok, err := matchElem(doc, e.Key, e.Value)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	default:
+		return false, cExceptions.InvalidParamError("[mongodbtest] query should be a document, but %T", filter)
+	}
+}
+
+func matchElem(doc primitive.D, key string, value interface{}) (bool, error) {
+	switch key {
+	case op.And, op.Or, op.Nor:
+		exps, ok := value.(primitive.A)
+		if !ok {
+			return false, cExceptions.InvalidParamError("[mongodbtest] %s should be an array, but %T", key, value)
+		}
+		for _, exp := range exps {
+			ok, err := match(doc, exp)
+			if err != nil {
+				return false, err
+			}
+			switch {
+			case key == op.And && !ok:
+				return false, nil
+			case key == op.Or && ok:
+				return true, nil
+			case key == op.Nor && ok:
+				return false, nil
+			}
+		}
+		return key != op.Or || len(exps) == 0, nil
+	case op.Expr:
+		v, err := evalExpr(doc, value)
+		if err != nil {
+			return false, err
+		}
+		return truthy(v), nil
+	}
+	if strings.HasPrefix(key, "$") {
+		return false, cExceptions.InvalidParamError("[mongodbtest] unsupported query operator %s", key)
+	}
+	return matchField(lookup(doc, key), value)
+}
+
+// matchField tests the values found at a path against either an operator document or a literal.
+func matchField(values []interface{}, cond interface{}) (bool, error) {
+	if !isOperatorDoc(cond) {
+		if re, ok := cond.(primitive.Regex); ok {
+			return matchRegex(values, re.Pattern, re.Options)
+		}
+		return matchEq(values, cond), nil
+	}
+
+	ops := cond.(primitive.D)
+	for _, e := range ops {
+		var ok bool
+		var err error
+		switch e.Key {
+		case op.Eq:
+			ok = matchEq(values, e.Value)
+		case op.Ne:
+			ok = !matchEq(values, e.Value)
+		case op.Gt, op.Gte, op.Lt, op.Lte:
+			ok = matchCompare(values, e.Key, e.Value)
+		case op.In:
+			ok, err = matchIn(values, e.Value)
+		case op.NotIn:
+			ok, err = matchIn(values, e.Value)
+			ok = !ok
+		case op.Not:
+			ok, err = matchField(values, e.Value)
+			ok = !ok
+		case op.Regex:
+			options, _ := docGet(ops, "$options")
+			switch re := e.Value.(type) {
+			case primitive.Regex:
+				ok, err = matchRegex(values, re.Pattern, re.Options+toString(options))
+			default:
+				ok, err = matchRegex(values, toString(re), toString(options))
+			}
+		case "$options":
+			ok = true
+		case "$exists":
+			ok = (len(values) > 0) == truthy(e.Value)
+		default:
+			err = cExceptions.InvalidParamError("[mongodbtest] unsupported query operator %s", e.Key)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchEq(values []interface{}, cond interface{}) bool {
+	if len(values) == 0 {
+		return typeClass(cond) == classNull
+	}
+	for _, v := range expand(values) {
+		if equalValues(v, cond) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(values []interface{}, operator string, cond interface{}) bool {
+	for _, v := range expand(values) {
+		a, b := coerceObjectID(v, cond)
+		if typeClass(a) != typeClass(b) {
+			continue
+		}
+		c := compareValues(a, b)
+		switch operator {
+		case op.Gt:
+			if c > 0 {
+				return true
+			}
+		case op.Gte:
+			if c >= 0 {
+				return true
+			}
+		case op.Lt:
+			if c < 0 {
+				return true
+			}
+		case op.Lte:
+			if c <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchIn(values []interface{}, cond interface{}) (bool, error) {
+	list, ok := cond.(primitive.A)
+	if !ok {
+		return false, cExceptions.InvalidParamError("[mongodbtest] $in/$nin should be an array, but %T", cond)
+	}
+	for _, c := range list {
+		if re, ok := c.(primitive.Regex); ok {
+			matched, err := matchRegex(values, re.Pattern, re.Options)
+			if err != nil || matched {
+				return matched, err
+			}
+			continue
+		}
+		if matchEq(values, c) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchRegex(values []interface{}, pattern, options string) (bool, error) {
+	flags := ""
+	for _, o := range options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, cExceptions.InvalidParamError("[mongodbtest] invalid regex %s, err: %v", pattern, err)
+	}
+	for _, v := range expand(values) {
+		if s, ok := v.(string); ok && re.MatchString(s) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
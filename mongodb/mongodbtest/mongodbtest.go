@@ -0,0 +1,403 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package mongodbtest provides an in-memory stand-in for the FaaS infra mongodb endpoint,
+// so code written against mongodb.IMongodb can be tested without network access.
+//
+//	db := mongodbtest.NewMongodb()
+//	_, _ = db.Table("goods").Create(ctx, cond.M{"item": "iphone", "qty": 10})
+package mongodbtest
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/byted-apaas/baas-sdk-go/mongodb/impl"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// Store keeps the tables of an in-memory database. It implements faasinfra.MongodbTransport
+// by decoding the same MongodbParam payloads the FaaS infra receives and answering
+// with the same BSON responses.
+type Store struct {
+	mu     sync.Mutex
+	tables map[string][]primitive.D
+}
+
+func NewStore() *Store {
+	return &Store{tables: make(map[string][]primitive.D)}
+}
+
+// NewMongodb returns a client backed by a new, empty Store.
+func NewMongodb() *impl.Mongodb {
+	return impl.NewMongodb(impl.WithTransport(NewStore()))
+}
+
+// Reset drops all tables.
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables = make(map[string][]primitive.D)
+}
+
+// request is the decoded form of impl.MongodbParam.
+type request struct {
+	table string
+	args  primitive.D
+}
+
+func (r *request) arg(key string) interface{} {
+	v, ok := docGet(r.args, key)
+	if !ok {
+		return missing{}
+	}
+	return v
+}
+
+func (r *request) intArg(key string) int64 {
+	n, _ := toInt(r.arg(key))
+	return n
+}
+
+func (r *request) boolArg(key string) bool {
+	b, _ := r.arg(key).(bool)
+	return b
+}
+
+func (s *Store) DoRequestMongodb(ctx context.Context, param interface{}) ([]byte, error) {
+	v, err := toValue(param)
+	if err != nil {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] marshal param failed, err: %v", err)
+	}
+	p, ok := toDoc(v)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] param should be a document, but %T", v)
+	}
+	table, _ := docGet(p, "tableName")
+	args, _ := docGet(p, "args")
+	req := &request{table: toString(table)}
+	if req.args, ok = toDoc(args); !ok || req.table == "" {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] tableName and args are required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var data interface{}
+	switch opName := toString(req.arg("op")); opName {
+	case "insert":
+		data, err = s.insert(req)
+	case "find":
+		data, err = s.find(req)
+	case "findOne":
+		var docs []primitive.D
+		if docs, err = s.find(req); err == nil && len(docs) > 0 {
+			data = docs[0]
+		}
+	case "count":
+		var docs []primitive.D
+		if docs, err = s.find(req); err == nil {
+			data = primitive.D{{Key: "count", Value: int64(len(docs))}}
+		}
+	case "distinct":
+		data, err = s.distinct(req)
+	case "update":
+		data, err = s.update(req)
+	case "delete":
+		data, err = s.delete(req)
+	case "aggregate":
+		data, err = s.aggregate(req)
+	default:
+		err = cExceptions.InvalidParamError("[mongodbtest] unsupported op %s", opName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := primitive.D{}
+	if data != nil {
+		res = append(res, primitive.E{Key: "data", Value: data})
+	}
+	return bson.Marshal(res)
+}
+
+func (s *Store) insert(req *request) (interface{}, error) {
+	var docs primitive.A
+	switch v := req.arg("docs").(type) {
+	case primitive.A:
+		docs = v
+	case primitive.D:
+		docs = primitive.A{v}
+	default:
+		return nil, cExceptions.InvalidParamError("[mongodbtest] docs should be an array, but %T", v)
+	}
+
+	ids := primitive.A{}
+	rows := s.tables[req.table]
+	for _, d := range docs {
+		doc, ok := toDoc(d)
+		if !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] doc should be a document, but %T", d)
+		}
+		id, ok := docGet(doc, "_id")
+		if oid, isOID := id.(primitive.ObjectID); !ok || id == nil || (isOID && oid.IsZero()) {
+			id = primitive.NewObjectID()
+			doc = append(primitive.D{{Key: "_id", Value: id}}, docDel(doc, "_id")...)
+		}
+		for _, row := range rows {
+			if rowID, _ := docGet(row, "_id"); equalValues(rowID, id) {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] duplicate key _id: %v", id)
+			}
+		}
+		rows = append(rows, doc)
+		ids = append(ids, id)
+	}
+	s.tables[req.table] = rows
+	return ids, nil
+}
+
+// find applies query, sort, skip, limit and projection.
+func (s *Store) find(req *request) ([]primitive.D, error) {
+	docs, err := filterDocs(s.tables[req.table], req.arg("query"))
+	if err != nil {
+		return nil, err
+	}
+
+	if spec, ok := toDoc(req.arg("sort")); ok {
+		sortDocs(docs, spec)
+	}
+	if skip := req.intArg("skip"); skip > 0 {
+		if skip >= int64(len(docs)) {
+			docs = nil
+		} else {
+			docs = docs[skip:]
+		}
+	}
+	if limit := req.intArg("limit"); limit > 0 && limit < int64(len(docs)) {
+		docs = docs[:limit]
+	}
+
+	res := make([]primitive.D, 0, len(docs))
+	for _, doc := range docs {
+		doc = copyDoc(doc)
+		if proj, ok := toDoc(req.arg("projection")); ok {
+			if doc, err = projectExpr(doc, proj); err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, doc)
+	}
+	return res, nil
+}
+
+func sortDocs(docs []primitive.D, spec primitive.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, e := range spec {
+			c := compareValues(lookupOne(docs[i], e.Key), lookupOne(docs[j], e.Key))
+			if c == 0 {
+				continue
+			}
+			if n, _ := toFloat(e.Value); n < 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// project applies an inclusion or exclusion projection.
+func project(doc primitive.D, proj primitive.D) (primitive.D, error) {
+	inclusive := false
+	for _, e := range proj {
+		if isOperatorDoc(e.Value) {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] unsupported projection of %s", e.Key)
+		}
+		if e.Key != "_id" && truthy(e.Value) {
+			inclusive = true
+		}
+	}
+
+	if !inclusive {
+		for _, e := range proj {
+			if !truthy(e.Value) {
+				doc = unsetPath(doc, e.Key)
+			}
+		}
+		return doc, nil
+	}
+	return include(doc, proj)
+}
+
+// include returns the _id of doc, unless proj excludes it, and the fields proj includes.
+func include(doc primitive.D, proj primitive.D) (primitive.D, error) {
+	keepID := true
+	if v, ok := docGet(proj, "_id"); ok && !truthy(v) {
+		keepID = false
+	}
+
+	res := primitive.D{}
+	if id, ok := docGet(doc, "_id"); ok && keepID {
+		res = append(res, primitive.E{Key: "_id", Value: id})
+	}
+	var err error
+	for _, e := range proj {
+		if e.Key == "_id" || !truthy(e.Value) {
+			continue
+		}
+		if v := lookupOne(doc, e.Key); typeClass(v) != classMissing {
+			if res, err = setPath(res, e.Key, v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// distinct returns the distinct values of the key field of the matching documents, sorted.
+// The elements of array values count as values, as in mongodb.
+func (s *Store) distinct(req *request) (interface{}, error) {
+	key := toString(req.arg("key"))
+	if key == "" {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] distinct requires a key")
+	}
+	docs, err := filterDocs(s.tables[req.table], req.arg("query"))
+	if err != nil {
+		return nil, err
+	}
+
+	res := primitive.A{}
+	for _, doc := range docs {
+		for _, v := range lookup(doc, key) {
+			values := primitive.A{v}
+			if a, ok := v.(primitive.A); ok {
+				values = a
+			}
+			for _, value := range values {
+				if !containsValue(res, value) {
+					res = append(res, value)
+				}
+			}
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return compareValues(res[i], res[j]) < 0
+	})
+	return res, nil
+}
+
+func (s *Store) update(req *request) (interface{}, error) {
+	one, upsert := req.boolArg("one"), req.boolArg("upsert")
+	filter := req.arg("query")
+	rows := s.tables[req.table]
+
+	uc, err := newUpdateContext(filter, req.arg("arrayFilters"), false)
+	if err != nil {
+		return nil, err
+	}
+	var matched, modified int64
+	for i, row := range rows {
+		ok, err := match(row, filter)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		matched++
+		doc, err := applyUpdate(row, req.arg("update"), uc)
+		if err != nil {
+			return nil, err
+		}
+		if compareValues(doc, row) != 0 {
+			rows[i] = doc
+			modified++
+		}
+		if one {
+			break
+		}
+	}
+
+	res := primitive.D{
+		{Key: "matchedCount", Value: matched},
+		{Key: "modifiedCount", Value: modified},
+	}
+	if matched > 0 || !upsert {
+		return res, nil
+	}
+
+	uc.inserting = true
+	doc, err := applyUpdate(upsertSeed(filter), req.arg("update"), uc)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := docGet(doc, "_id")
+	if hex, isStr := id.(string); isStr {
+		if oid, err := primitive.ObjectIDFromHex(hex); err == nil {
+			id = oid
+		}
+	}
+	if !ok || id == nil {
+		id = primitive.NewObjectID()
+	}
+	doc = append(primitive.D{{Key: "_id", Value: id}}, docDel(doc, "_id")...)
+	s.tables[req.table] = append(rows, doc)
+	return append(res, primitive.E{Key: "upsertedId", Value: id}), nil
+}
+
+func (s *Store) delete(req *request) (interface{}, error) {
+	one := req.boolArg("one")
+	rows := s.tables[req.table]
+	kept := make([]primitive.D, 0, len(rows))
+	var deleted int64
+	for _, row := range rows {
+		if one && deleted > 0 {
+			kept = append(kept, row)
+			continue
+		}
+		ok, err := match(row, req.arg("query"))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deleted++
+		} else {
+			kept = append(kept, row)
+		}
+	}
+	s.tables[req.table] = kept
+	return primitive.D{{Key: "deletedCount", Value: deleted}}, nil
+}
+
+func (s *Store) aggregate(req *request) (interface{}, error) {
+	var pipeline []primitive.D
+	if stages, ok := req.arg("pipeline").(primitive.A); ok {
+		if pipeline, ok = toStages(stages); !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] pipeline stages should be documents")
+		}
+	}
+
+	docs := make([]primitive.D, 0, len(s.tables[req.table]))
+	for _, doc := range s.tables[req.table] {
+		docs = append(docs, copyDoc(doc))
+	}
+	docs, err := aggregate(s.tables, docs, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.boolArg("one") {
+		if len(docs) == 0 {
+			return nil, nil
+		}
+		return docs[0], nil
+	}
+	if docs == nil {
+		docs = []primitive.D{}
+	}
+	return docs, nil
+}
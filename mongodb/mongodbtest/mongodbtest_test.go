@@ -0,0 +1,686 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/byted-apaas/baas-sdk-go/common/structs"
+	"github.com/byted-apaas/baas-sdk-go/mongodb"
+	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/expr"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/impl"
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/update"
+)
+
+var ctx = context.Background()
+
+type Goods struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Item string             `bson:"item"`
+	Qty  int64              `bson:"qty"`
+	Info *GoodsInfo         `bson:"info,omitempty"`
+}
+
+type GoodsInfo struct {
+	City string   `bson:"city,omitempty"`
+	Tag  []string `bson:"tag,omitempty"`
+}
+
+func newGoods(t *testing.T) mongodb.ITable {
+	return createGoods(t, NewMongodb())
+}
+
+func createGoods(t *testing.T, db mongodb.IMongodb) mongodb.ITable {
+	T := db.Table("goods")
+	_, err := T.BatchCreate(ctx, []*Goods{
+		{Item: "iphone 7", Qty: 150, Info: &GoodsInfo{City: "shanghai", Tag: []string{"hot"}}},
+		{Item: "iphone X", Qty: 100, Info: &GoodsInfo{City: "beijing", Tag: []string{"new"}}},
+		{Item: "Mac Pro", Qty: 75, Info: &GoodsInfo{City: "shanghai", Tag: []string{"new", "hot"}}},
+		{Item: "Mac Air", Qty: 45, Info: &GoodsInfo{City: "beijing"}},
+		{Item: "iphone 6", Qty: 35, Info: &GoodsInfo{City: "shanghai"}},
+	})
+	assert.NoError(t, err)
+	return T
+}
+
+func items(goods []Goods) []string {
+	res := make([]string, 0, len(goods))
+	for _, g := range goods {
+		res = append(res, g.Item)
+	}
+	return res
+}
+
+func TestTable_Create(t *testing.T) {
+	T := NewMongodb().Table("student")
+	res, err := T.Create(ctx, cond.M{"name": "xiaogang", "age": 19})
+	assert.NoError(t, err)
+	assert.False(t, res.ID.IsZero())
+
+	var student bson.M
+	assert.NoError(t, T.Where(cond.M{"_id": res.ID.Hex()}).FindOne(ctx, &student))
+	assert.Equal(t, "xiaogang", student["name"])
+
+	_, err = T.Create(ctx, cond.M{"_id": res.ID, "name": "again"})
+	assert.Error(t, err)
+}
+
+func TestQuery_Where(t *testing.T) {
+	T := newGoods(t)
+
+	cases := []struct {
+		name      string
+		condition interface{}
+		expected  []string
+	}{
+		{"eq", cond.M{"item": "iphone X"}, []string{"iphone X"}},
+		{"nested", cond.M{"info.city": cond.Eq("beijing")}, []string{"iphone X", "Mac Air"}},
+		{"array element", cond.M{"info.tag": "hot"}, []string{"iphone 7", "Mac Pro"}},
+		{"gte", cond.M{"qty": cond.Gte(100)}, []string{"iphone 7", "iphone X"}},
+		{"in", cond.M{"item": cond.In([]string{"Mac Pro", "Mac Air"})}, []string{"Mac Pro", "Mac Air"}},
+		{"nin", cond.M{"info.city": cond.Nin([]string{"shanghai"})}, []string{"iphone X", "Mac Air"}},
+		{"ne", cond.M{"info.city": cond.Ne("shanghai")}, []string{"iphone X", "Mac Air"}},
+		{"regex", cond.M{"item": cond.Regex("^Mac")}, []string{"Mac Pro", "Mac Air"}},
+		{"not", cond.M{"qty": cond.Not(cond.Lt(100))}, []string{"iphone 7", "iphone X"}},
+		{"or", cond.Or(cond.M{"qty": cond.Lt(40)}, cond.M{"qty": cond.Gt(120)}), []string{"iphone 7", "iphone 6"}},
+		{"and", cond.And(cond.M{"info.city": "shanghai"}, cond.M{"qty": cond.Lte(75)}), []string{"Mac Pro", "iphone 6"}},
+		{"expr", cond.M{op.Expr: cond.M{op.Gt: cond.A{"$qty", 90}}}, []string{"iphone 7", "iphone X"}},
+	}
+	for _, c := range cases {
+		var goods []Goods
+		assert.NoError(t, T.Where(c.condition).Find(ctx, &goods), c.name)
+		assert.Equal(t, c.expected, items(goods), c.name)
+	}
+}
+
+func TestQuery_OrderByLimitOffset(t *testing.T) {
+	T := newGoods(t)
+
+	var goods []Goods
+	assert.NoError(t, T.Where(nil).OrderByDesc("qty").Offset(1).Limit(2).Find(ctx, &goods))
+	assert.Equal(t, []string{"iphone X", "Mac Pro"}, items(goods))
+}
+
+func TestQuery_Project(t *testing.T) {
+	T := newGoods(t)
+
+	var goods []bson.M
+	assert.NoError(t, T.Where(cond.M{"item": "Mac Pro"}).Project(cond.M{"info": 0}).Find(ctx, &goods))
+	assert.Len(t, goods, 1)
+	assert.NotContains(t, goods[0], "info")
+	assert.Equal(t, "Mac Pro", goods[0]["item"])
+}
+
+func TestQuery_Count(t *testing.T) {
+	T := newGoods(t)
+
+	count, err := T.Where(cond.M{"info.city": "shanghai"}).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestQuery_Distinct(t *testing.T) {
+	T := newGoods(t)
+
+	var qty []int64
+	assert.NoError(t, T.Where(cond.M{"info.city": "shanghai"}).Distinct(ctx, "qty", &qty))
+	assert.Equal(t, []int64{35, 75, 150}, qty)
+
+	var cities []string
+	assert.NoError(t, T.Where(nil).Distinct(ctx, "info.city", &cities))
+	assert.Equal(t, []string{"beijing", "shanghai"}, cities)
+
+	var tags []string
+	assert.NoError(t, T.Where(nil).Distinct(ctx, "info.tag", &tags))
+	assert.Equal(t, []string{"hot", "new"}, tags)
+	assert.NoError(t, T.Where(cond.M{"info.city": "beijing"}).Distinct(ctx, "info.tag", &tags))
+	assert.Equal(t, []string{"new"}, tags)
+
+	var none []string
+	assert.NoError(t, T.Where(cond.M{"qty": cond.Gt(1000)}).Distinct(ctx, "item", &none))
+	assert.Empty(t, none)
+	assert.Error(t, T.Where(nil).Distinct(ctx, "", &none))
+}
+
+func TestQuery_Update(t *testing.T) {
+	T := newGoods(t)
+
+	assert.NoError(t, T.Where(cond.M{"info.city": "beijing"}).Update(ctx, cond.M{"qty": 1}))
+	assert.NoError(t, T.Where(cond.M{"info.city": "shanghai"}).BatchUpdate(ctx, cond.M{"info.city": "hangzhou"}))
+
+	count, err := T.Where(cond.M{"qty": 1}).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = T.Where(cond.M{"info.city": "hangzhou"}).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestQuery_Upsert(t *testing.T) {
+	T := NewMongodb().Table("student")
+
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).Upsert(ctx, cond.M{"age": 18}))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).Upsert(ctx, cond.M{"age": 19}))
+
+	var students []bson.M
+	assert.NoError(t, T.Where(nil).Find(ctx, &students))
+	assert.Len(t, students, 1)
+	assert.Equal(t, "xiaoming", students[0]["name"])
+	assert.EqualValues(t, 19, students[0]["age"])
+}
+
+func TestQuery_UpdateOperators(t *testing.T) {
+	T := NewMongodb().Table("student")
+	_, err := T.Create(ctx, cond.M{"name": "xiaoming", "age": 18, "score": 80, "tags": bson.A{"a", "b", "a"}, "nick": "ming"})
+	assert.NoError(t, err)
+
+	q := func() mongodb.IQuery { return T.Where(cond.M{"name": "xiaoming"}) }
+	assert.NoError(t, q().Update(ctx, update.Inc("age", 1).Max("score", 90).Min("best", 3).Unset("nick")))
+	assert.NoError(t, q().Update(ctx, update.Mul("score", 2).Rename("age", "years").CurrentDate("updatedAt")))
+	assert.NoError(t, q().Update(ctx, update.Pull("tags", "a").AddToSetEach("tags", []string{"b", "c"}).Push("log", "x")))
+	assert.NoError(t, q().Update(ctx, update.PushEach("log", []string{"y", "z"}, update.Position(0), update.Slice(-2))))
+	assert.NoError(t, q().Update(ctx, update.PopLast("tags").PullAll("log", []string{"z"})))
+
+	var student bson.M
+	assert.NoError(t, q().FindOne(ctx, &student))
+	assert.EqualValues(t, 19, student["years"])
+	assert.EqualValues(t, 180, student["score"])
+	assert.EqualValues(t, 3, student["best"])
+	assert.NotContains(t, student, "age")
+	assert.NotContains(t, student, "nick")
+	assert.IsType(t, primitive.DateTime(0), student["updatedAt"])
+	assert.Equal(t, bson.A{"b"}, student["tags"])
+	assert.Equal(t, bson.A{"x"}, student["log"])
+
+	assert.NoError(t, T.Where(cond.M{"name": "xiaohong"}).Upsert(ctx, update.Inc("visits", 1).SetOnInsert("age", 20)))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaohong"}).Upsert(ctx, update.Inc("visits", 1).SetOnInsert("age", 30)))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaohong"}).FindOne(ctx, &student))
+	assert.EqualValues(t, 2, student["visits"])
+	assert.EqualValues(t, 20, student["age"])
+}
+
+func TestQuery_UpdatePositional(t *testing.T) {
+	T := NewMongodb().Table("student")
+	_, err := T.Create(ctx, cond.M{"name": "xiaoming", "grades": bson.A{
+		cond.M{"course": "math", "score": 50},
+		cond.M{"course": "art", "score": 70},
+		cond.M{"course": "music", "score": 90},
+	}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming", "grades.course": "art"}).Update(ctx, update.Inc("grades.$.score", 5)))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).Update(ctx, update.Set("grades.$[].checked", true)))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).Update(ctx,
+		update.Set("grades.$[g].passed", true).ArrayFilters(cond.M{"g.score": cond.Gte(60)})))
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).Update(ctx, update.Pull("grades", cond.M{"score": cond.Lt(60)})))
+
+	var student struct {
+		Grades []struct {
+			Course  string `bson:"course"`
+			Score   int64  `bson:"score"`
+			Checked bool   `bson:"checked"`
+			Passed  bool   `bson:"passed"`
+		} `bson:"grades"`
+	}
+	assert.NoError(t, T.Where(cond.M{"name": "xiaoming"}).FindOne(ctx, &student))
+	assert.Len(t, student.Grades, 2)
+	assert.Equal(t, "art", student.Grades[0].Course)
+	assert.EqualValues(t, 75, student.Grades[0].Score)
+	for _, g := range student.Grades {
+		assert.True(t, g.Checked)
+		assert.True(t, g.Passed)
+	}
+
+	assert.Error(t, T.Where(cond.M{"name": "xiaoming"}).Update(ctx, update.Set("grades.$[x].passed", false)))
+}
+
+func TestQuery_UpdateInvalid(t *testing.T) {
+	T := NewMongodb().Table("student")
+	q := T.Where(cond.M{"name": "xiaoming"})
+	assert.Error(t, q.Update(ctx, update.New()))
+	assert.Error(t, q.Update(ctx, update.Set("", 1)))
+	assert.Error(t, q.Update(ctx, update.PushEach("tags", "a")))
+	assert.Error(t, q.Update(ctx, update.Rename("age", "age")))
+	assert.Error(t, q.Update(ctx, nil))
+}
+
+func TestQuery_Results(t *testing.T) {
+	T := newGoods(t)
+
+	res, err := T.Where(cond.M{"info.city": "shanghai"}).BatchUpdateWithResult(ctx, update.Max("qty", 100))
+	assert.NoError(t, err)
+	assert.Equal(t, &structs.UpdateResult{MatchedCount: 3, ModifiedCount: 2}, res)
+
+	res, err = T.Where(cond.M{"item": "iPad"}).UpdateWithResult(ctx, cond.M{"qty": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, &structs.UpdateResult{}, res)
+
+	res, err = T.Where(cond.M{"item": "iPad"}).UpsertWithResult(ctx, cond.M{"qty": 1})
+	assert.NoError(t, err)
+	assert.Zero(t, res.MatchedCount)
+	id, ok := res.UpsertedID.(primitive.ObjectID)
+	assert.True(t, ok)
+	var goods Goods
+	assert.NoError(t, T.Where(cond.M{"_id": id}).FindOne(ctx, &goods))
+	assert.Equal(t, "iPad", goods.Item)
+
+	del, err := T.Where(cond.M{"info.city": "beijing"}).DeleteWithResult(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &structs.DeleteResult{DeletedCount: 1}, del)
+	del, err = T.Where(cond.M{"info.city": "shanghai"}).BatchDeleteWithResult(ctx)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, del.DeletedCount)
+}
+
+// emptyTransport answers every request with an empty body, as servers not returning the
+// results of updates and deletes do.
+type emptyTransport struct{}
+
+func (emptyTransport) DoRequestMongodb(ctx context.Context, param interface{}) ([]byte, error) {
+	return nil, nil
+}
+
+func TestQuery_ResultsNotReturned(t *testing.T) {
+	q := impl.NewMongodb(impl.WithTransport(emptyTransport{})).Table("goods").Where(cond.M{"item": "iPad"})
+
+	assert.NoError(t, q.Update(ctx, cond.M{"qty": 1}))
+	assert.NoError(t, q.Upsert(ctx, cond.M{"qty": 1}))
+	assert.NoError(t, q.BatchUpdate(ctx, cond.M{"qty": 1}))
+	assert.NoError(t, q.Delete(ctx))
+	assert.NoError(t, q.BatchDelete(ctx))
+
+	_, err := q.UpdateWithResult(ctx, cond.M{"qty": 1})
+	assert.Error(t, err)
+	_, err = q.DeleteWithResult(ctx)
+	assert.Error(t, err)
+}
+
+func TestQuery_Delete(t *testing.T) {
+	T := newGoods(t)
+
+	assert.NoError(t, T.Where(cond.M{"info.city": "beijing"}).Delete(ctx))
+	count, err := T.Where(nil).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	assert.NoError(t, T.Where(cond.M{"info.city": "shanghai"}).BatchDelete(ctx))
+	count, err = T.Where(nil).Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestAggQuery_GroupBy(t *testing.T) {
+	T := newGoods(t)
+
+	var results []bson.M
+	err := T.GroupBy("info.city", "city").
+		Sum("qty", "total").
+		Avg("qty", "avg").
+		Num("count").
+		Push("item", "items").
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	shanghai := results[0]
+	assert.Equal(t, "shanghai", shanghai["city"])
+	assert.EqualValues(t, 260, shanghai["total"])
+	assert.InDelta(t, 260.0/3, shanghai["avg"], 1e-9)
+	assert.EqualValues(t, 3, shanghai["count"])
+	assert.Equal(t, bson.A{"iphone 7", "Mac Pro", "iphone 6"}, shanghai["items"])
+}
+
+func TestAggQuery_GroupBy_Where(t *testing.T) {
+	T := newGoods(t)
+
+	var result bson.M
+	err := T.GroupBy("info.city", "city").Sum("qty", "total").Where(cond.M{"qty": cond.Gte(100)}).FindOne(ctx, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, "shanghai", result["city"])
+	assert.EqualValues(t, 150, result["total"])
+}
+
+func TestAggQuery_GroupBy_Having(t *testing.T) {
+	T := newGoods(t)
+
+	var results []bson.M
+	err := T.GroupBy("info.city", "city").Sum("qty", "total").Having(cond.M{"total": cond.Gt(200)}).Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"city": "shanghai", "total": int64(260)}}, results)
+
+	results = nil
+	err = T.GroupBy("info.city", "city").
+		Sum("qty", "total").
+		Num("count").
+		Where(cond.M{"qty": cond.Gte(50)}).
+		Having([]interface{}{cond.M{"count": 1}, cond.M{"city": cond.Ne("nowhere")}}).
+		Having(cond.M{"total": cond.Lt(150)}).
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"city": "beijing", "total": int64(100), "count": int32(1)}}, results)
+
+	results = nil
+	err = T.GroupBy("info.city").Sum("qty", "total").Having(cond.M{"_id": "beijing"}).Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"_id": "beijing", "total": int64(145)}}, results)
+
+	// the alias of the group _id is only applied to the results
+	results = nil
+	err = T.GroupBy("info.city", "city").Sum("qty", "total").Having(cond.M{"city": "beijing"}).Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"city": "beijing", "total": int64(145)}}, results)
+
+	results = nil
+	err = T.GroupBy(map[string]string{"city": "info.city"}, "loc").
+		Sum("qty", "total").
+		Having(struct {
+			Or []interface{} `bson:"$or"`
+		}{[]interface{}{cond.M{"loc.city": "beijing"}, cond.M{"total": cond.Gt(1000)}}}).
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"loc": bson.M{"city": "beijing"}, "total": int64(145)}}, results)
+
+	err = T.GroupBy("info.city", "city").Having(cond.M{"city": "beijing", "_id": "shanghai"}).Find(ctx, &results)
+	assert.Error(t, err)
+	err = T.GroupBy(map[string]string{"city": "info.city"}, "loc").
+		Having(cond.M{op.Or: []interface{}{cond.M{"loc.city": "beijing", "_id.city": "shanghai"}}}).
+		Find(ctx, &results)
+	assert.Error(t, err)
+
+	assert.Error(t, T.GroupBy("info.city").Having(1).Find(ctx, &results))
+	assert.Error(t, T.GroupBy("info.city").Where("qty").Find(ctx, &results))
+}
+
+func TestPipeline(t *testing.T) {
+	T := newGoods(t)
+
+	var top []bson.M
+	err := T.Aggregate().
+		Match(cond.M{"qty": cond.Gte(40)}).
+		Group("info.city", cond.M{"total": cond.M{op.Sum: "$qty"}}).
+		SortDesc("total").
+		Limit(1).
+		Find(ctx, &top)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"_id": "shanghai", "total": int64(225)}}, top)
+
+	var second bson.M
+	err = T.Aggregate().
+		Match(cond.M{"qty": cond.Gte(40)}).
+		Group("info.city", cond.M{"total": cond.M{op.Sum: "$qty"}}).
+		SortDesc("total").
+		Skip(1).
+		FindOne(ctx, &second)
+	assert.NoError(t, err)
+	assert.Equal(t, "beijing", second["_id"])
+
+	var goods []Goods
+	err = T.Aggregate().Sort("info.city").SortDesc("qty").Find(ctx, &goods)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"iphone X", "Mac Air", "iphone 7", "Mac Pro", "iphone 6"}, items(goods))
+}
+
+func TestPipeline_Unwind(t *testing.T) {
+	T := newGoods(t)
+
+	var tags []bson.M
+	err := T.Aggregate().
+		Unwind("info.tag").
+		Group("info.tag", cond.M{"count": cond.M{op.Sum: 1}, "items": cond.M{op.Push: "$item"}}).
+		Sort("_id").
+		Find(ctx, &tags)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"_id": "hot", "count": int32(2), "items": bson.A{"iphone 7", "Mac Pro"}},
+		{"_id": "new", "count": int32(2), "items": bson.A{"iphone X", "Mac Pro"}},
+	}, tags)
+
+	var count bson.M
+	err = T.Aggregate().Unwind("info.tag").Count("n").FindOne(ctx, &count)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, count["n"])
+
+	var none []bson.M
+	err = T.Aggregate().Match(cond.M{"qty": cond.Gt(1000)}).Count("n").Find(ctx, &none)
+	assert.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestPipeline_Project(t *testing.T) {
+	T := newGoods(t)
+
+	var results []bson.M
+	err := T.Aggregate().
+		Match(cond.M{"item": "Mac Pro"}).
+		Project(cond.M{"_id": 0, "item": 1, "city": "$info.city"}).
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"item": "Mac Pro", "city": "shanghai"}}, results)
+
+	var result bson.M
+	err = T.Aggregate().
+		Sort("qty").
+		AddFields(cond.M{"city": "$info.city"}).
+		Project(cond.M{"_id": 0, "info": 0}).
+		FindOne(ctx, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"item": "iphone 6", "qty": int64(35), "city": "shanghai"}, result)
+}
+
+func TestPipeline_Invalid(t *testing.T) {
+	T := newGoods(t)
+
+	var results []bson.M
+	assert.Error(t, T.Aggregate().Limit(0).Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Skip(-1).Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Group("", nil).Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Group("item", cond.M{"_id": 1}).Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Project(1).Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Unwind("").Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Count("").Find(ctx, &results))
+}
+
+type Order struct {
+	Sku    string `bson:"sku"`
+	Amount int64  `bson:"amount"`
+}
+
+type GoodsOrders struct {
+	Goods  `bson:",inline"`
+	Orders []Order `bson:"orders"`
+}
+
+func newShop(t *testing.T) (mongodb.IMongodb, mongodb.ITable) {
+	db := NewMongodb()
+	T := createGoods(t, db)
+	_, err := db.Table("orders").BatchCreate(ctx, []*Order{
+		{Sku: "iphone 7", Amount: 2},
+		{Sku: "Mac Pro", Amount: 1},
+		{Sku: "iphone 7", Amount: 3},
+		{Sku: "Mac Pro", Amount: 5},
+	})
+	assert.NoError(t, err)
+	_, err = db.Table("tags").BatchCreate(ctx, []bson.M{{"name": "hot", "label": "Hot"}, {"name": "new", "label": "New"}})
+	assert.NoError(t, err)
+	return db, T
+}
+
+func TestPipeline_Lookup(t *testing.T) {
+	_, T := newShop(t)
+
+	var goods []GoodsOrders
+	err := T.Aggregate().
+		Match(cond.M{"item": cond.In([]string{"iphone 7", "Mac Air"})}).
+		Lookup("orders", "item", "sku", "orders").
+		Find(ctx, &goods)
+	assert.NoError(t, err)
+	assert.Len(t, goods, 2)
+	assert.Equal(t, "iphone 7", goods[0].Item)
+	assert.Equal(t, []Order{{Sku: "iphone 7", Amount: 2}, {Sku: "iphone 7", Amount: 3}}, goods[0].Orders)
+	assert.Equal(t, "Mac Air", goods[1].Item)
+	assert.Empty(t, goods[1].Orders)
+
+	var tags []bson.M
+	err = T.Aggregate().
+		Match(cond.M{"item": "Mac Pro"}).
+		Lookup("tags", "info.tag", "name", "tags").
+		Project(cond.M{"_id": 0, "labels": "$tags.label"}).
+		Find(ctx, &tags)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"labels": bson.A{"Hot", "New"}}}, tags)
+
+	var sold []bson.M
+	err = T.Aggregate().
+		Lookup("orders", "item", "sku", "orders").
+		Unwind("orders").
+		Group("item", cond.M{"sold": cond.M{op.Sum: "$orders.amount"}}).
+		SortDesc("sold").
+		Find(ctx, &sold)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"_id": "Mac Pro", "sold": int64(6)}, {"_id": "iphone 7", "sold": int64(5)}}, sold)
+}
+
+func TestPipeline_LookupPipeline(t *testing.T) {
+	db, T := newShop(t)
+
+	var goods []GoodsOrders
+	err := T.Aggregate().
+		Match(cond.M{"info.city": "shanghai"}).
+		LookupPipeline(cond.M{"item": "item"}, db.Table("orders").Aggregate().
+			Match(cond.M{op.Expr: cond.M{op.Eq: cond.A{"$sku", "$$item"}}}).
+			Match(cond.M{"amount": cond.Gte(3)}), "orders").
+		Find(ctx, &goods)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"iphone 7", "Mac Pro", "iphone 6"}, items(goodsOf(goods)))
+	assert.Equal(t, []Order{{Sku: "iphone 7", Amount: 3}}, goods[0].Orders)
+	assert.Equal(t, []Order{{Sku: "Mac Pro", Amount: 5}}, goods[1].Orders)
+	assert.Empty(t, goods[2].Orders)
+
+	var results []bson.M
+	assert.Error(t, T.Aggregate().LookupPipeline(nil, db.Table("orders").Aggregate().Limit(0), "orders").Find(ctx, &results))
+	assert.Error(t, T.Aggregate().LookupPipeline(nil, nil, "orders").Find(ctx, &results))
+	assert.Error(t, T.Aggregate().Lookup("orders", "item", "", "orders").Find(ctx, &results))
+}
+
+func goodsOf(goods []GoodsOrders) []Goods {
+	res := make([]Goods, 0, len(goods))
+	for _, g := range goods {
+		res = append(res, g.Goods)
+	}
+	return res
+}
+
+type Sale struct {
+	Item      string    `bson:"item"`
+	Price     float64   `bson:"price"`
+	Qty       int64     `bson:"qty"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+func newSales(t *testing.T) mongodb.ITable {
+	T := NewMongodb().Table("sales")
+	_, err := T.BatchCreate(ctx, []*Sale{
+		{Item: "apple", Price: 2.5, Qty: 4, CreatedAt: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)},
+		{Item: "melon", Price: 10, Qty: 1, CreatedAt: time.Date(2023, 1, 1, 20, 0, 0, 0, time.UTC)},
+		{Item: "kiwi", Price: 1, Qty: 3, CreatedAt: time.Date(2023, 1, 2, 1, 0, 0, 0, time.UTC)},
+	})
+	assert.NoError(t, err)
+	return T
+}
+
+func TestAggQuery_Expr(t *testing.T) {
+	T := newSales(t)
+	revenue := expr.Multiply("$price", "$qty")
+
+	var days []bson.M
+	err := T.GroupBy(expr.DateToString("%Y-%m-%d", "$createdAt"), "day").
+		Sum(revenue, "revenue").
+		Avg(revenue, "avg").
+		Find(ctx, &days)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"day": "2023-01-01", "revenue": 20.0, "avg": 10.0},
+		{"day": "2023-01-02", "revenue": 3.0, "avg": 3.0},
+	}, days)
+
+	days = nil
+	err = T.GroupBy(expr.DateToString("%Y-%m-%d", "$createdAt", "+08:00"), "day").Sum(revenue, "revenue").Find(ctx, &days)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"day": "2023-01-01", "revenue": 10.0}, {"day": "2023-01-02", "revenue": 13.0}}, days)
+
+	var months []bson.M
+	err = T.GroupBy(cond.M{"year": expr.Year("$createdAt"), "month": expr.Month("$createdAt")}, "month").
+		Sum(1, "count").
+		Find(ctx, &months)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"month": bson.M{"year": int32(2023), "month": int32(1)}, "count": int32(3)}}, months)
+
+	var results []bson.M
+	assert.Error(t, T.GroupBy("item").Sum(revenue).Find(ctx, &results))
+}
+
+func TestQuery_ProjectExpr(t *testing.T) {
+	T := newGoods(t)
+
+	var results []bson.M
+	err := T.Where(cond.M{"item": cond.In([]string{"iphone 7", "Mac Air"})}).
+		OrderBy("qty").
+		Project(cond.M{
+			"_id":   0,
+			"item":  1,
+			"upper": expr.ToUpper("$item"),
+			"brand": expr.Substr("$item", 0, 3),
+			"label": expr.Concat("$item", "@", expr.IfNull("$info.city", "unknown")),
+			"tags":  expr.Size(expr.IfNull("$info.tag", cond.A{})),
+			"stock": expr.Cond(expr.Gte("$qty", 100), "high", "low"),
+			"half":  expr.Round(expr.Divide("$qty", 2), 0),
+		}).
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"item": "Mac Air", "upper": "MAC AIR", "brand": "Mac", "label": "Mac Air@beijing", "tags": int32(0), "stock": "low", "half": 22.0},
+		{"item": "iphone 7", "upper": "IPHONE 7", "brand": "iph", "label": "iphone 7@shanghai", "tags": int32(1), "stock": "high", "half": 75.0},
+	}, results)
+}
+
+func TestPipeline_Expr(t *testing.T) {
+	T := newSales(t)
+
+	var results []bson.M
+	err := T.Aggregate().
+		Group(nil, cond.M{"items": cond.M{op.Push: cond.M{"item": "$item", "revenue": expr.Multiply("$price", "$qty")}}}).
+		Project(cond.M{
+			"_id":   0,
+			"big":   expr.Map(expr.Filter("$items", "i", expr.Gt("$$i.revenue", 5)), "i", "$$i.item"),
+			"last":  expr.ArrayElemAt("$items.item", -1),
+			"first": expr.Slice("$items.item", 2),
+			"count": expr.Size(expr.ConcatArrays("$items", "$items")),
+		}).
+		AddFields(cond.M{"weekday": expr.DayOfWeek(expr.Literal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))}).
+		Find(ctx, &results)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{
+		"big":     bson.A{"apple", "melon"},
+		"last":    "kiwi",
+		"first":   bson.A{"apple", "melon"},
+		"count":   int32(6),
+		"weekday": int32(1),
+	}}, results)
+}
+
+func TestStore_Unsupported(t *testing.T) {
+	T := newGoods(t)
+
+	var goods []Goods
+	assert.Error(t, T.Where(cond.M{"$where": "this.qty > 1"}).Find(ctx, &goods))
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	"github.com/byted-apaas/baas-sdk-go/mongodb/impl"
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+)
+
+// do sends the request built by build to s and decodes the data of the response.
+func do(t *testing.T, s *Store, opType impl.OpType, build func(p *impl.MongodbParam)) interface{} {
+	p := impl.NewMongodbParam("goods")
+	p.SetOp(opType)
+	build(p)
+	body, err := s.DoRequestMongodb(context.Background(), p)
+	assert.NoError(t, err)
+
+	var res bson.M
+	assert.NoError(t, bson.Unmarshal(body, &res))
+	return res["data"]
+}
+
+func TestStore_DoRequestMongodb(t *testing.T) {
+	s := NewStore()
+	data := do(t, s, impl.OpType_Insert, func(p *impl.MongodbParam) {
+		p.SetDocs([]interface{}{
+			cond.M{"item": "iphone 7", "qty": 150},
+			cond.M{"item": "Mac Air", "qty": 45},
+		})
+	})
+	assert.Len(t, data, 2)
+
+	data = do(t, s, impl.OpType_Find, func(p *impl.MongodbParam) {
+		p.SetQuery(cond.M{"qty": cond.M{op.Gt: 100}})
+		p.SetProjection(cond.M{"_id": 0})
+	})
+	assert.Equal(t, bson.A{bson.M{"item": "iphone 7", "qty": int32(150)}}, data)
+
+	data = do(t, s, impl.OpType_Count, func(p *impl.MongodbParam) {})
+	assert.EqualValues(t, 2, data.(bson.M)["count"])
+
+	s.Reset()
+	data = do(t, s, impl.OpType_Count, func(p *impl.MongodbParam) {})
+	assert.EqualValues(t, 0, data.(bson.M)["count"])
+
+	_, err := s.DoRequestMongodb(context.Background(), cond.M{"tableName": "goods"})
+	assert.Error(t, err)
+}
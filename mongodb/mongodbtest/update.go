@@ -0,0 +1,524 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// updateContext holds what the update paths of a document are resolved against: the query,
+// for the "$" positional operator, and the array filters, for "$[identifier]".
+type updateContext struct {
+	filter       interface{}
+	arrayFilters []primitive.D
+	// inserting is true when the document is being created by an upsert.
+	inserting bool
+}
+
+func newUpdateContext(filter, arrayFilters interface{}, inserting bool) (*updateContext, error) {
+	uc := &updateContext{filter: filter, inserting: inserting}
+	if filters, ok := arrayFilters.(primitive.A); ok {
+		for _, f := range filters {
+			d, ok := toDoc(f)
+			if !ok || len(d) == 0 {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] array filter should be a non-empty document, but %v", f)
+			}
+			uc.arrayFilters = append(uc.arrayFilters, d)
+		}
+	}
+	return uc, nil
+}
+
+// applyUpdate applies an update document to doc and returns the new document.
+func applyUpdate(doc primitive.D, update interface{}, uc *updateContext) (primitive.D, error) {
+	u, ok := toDoc(update)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] update should be a document, but %T", update)
+	}
+
+	if !isOperatorDoc(u) {
+		// replacement document
+		id, _ := docGet(doc, "_id")
+		res := primitive.D{{Key: "_id", Value: id}}
+		for _, e := range u {
+			if e.Key != "_id" {
+				res = append(res, e)
+			}
+		}
+		return res, nil
+	}
+
+	res := copyDoc(doc)
+	for _, e := range u {
+		fields, ok := toDoc(e.Value)
+		if !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s should be a document, but %T", e.Key, e.Value)
+		}
+		for _, f := range fields {
+			paths, err := uc.resolve(res, res, nil, strings.Split(f.Key, "."))
+			if err != nil {
+				return nil, err
+			}
+			for _, path := range paths {
+				if res, err = applyOperator(res, e.Key, path, f.Value, uc.inserting); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// resolve replaces the positional operators of the path parts following prefix, v being the
+// value at prefix, by the indexes of the elements they select.
+func (uc *updateContext) resolve(doc primitive.D, v interface{}, prefix, parts []string) ([]string, error) {
+	for i, part := range parts {
+		if !strings.HasPrefix(part, "$") {
+			v = child(v, part)
+			prefix = append(prefix, part)
+			continue
+		}
+
+		arr, ok := v.(primitive.A)
+		if !ok && typeClass(v) != classMissing {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] cannot apply array updates to non-array element %s", strings.Join(prefix, "."))
+		}
+		var indexes []int
+		switch {
+		case part == "$":
+			idx, ok, err := uc.positional(doc, strings.Join(prefix, "."), arr)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] the positional operator did not find the match needed from the query")
+			}
+			indexes = append(indexes, idx)
+		case part == "$[]":
+			for j := range arr {
+				indexes = append(indexes, j)
+			}
+		case strings.HasPrefix(part, "$[") && strings.HasSuffix(part, "]"):
+			id := part[2 : len(part)-1]
+			filter, ok := uc.arrayFilter(id)
+			if !ok {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] no array filter found for identifier '%s' in path '%s'", id, strings.Join(parts, "."))
+			}
+			for j, e := range arr {
+				ok, err := match(primitive.D{{Key: id, Value: e}}, filter)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					indexes = append(indexes, j)
+				}
+			}
+		default:
+			return nil, cExceptions.InvalidParamError("[mongodbtest] unsupported path part %s", part)
+		}
+
+		var res []string
+		for _, j := range indexes {
+			next := append(append([]string{}, prefix...), strconv.Itoa(j))
+			paths, err := uc.resolve(doc, arr[j], next, parts[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, paths...)
+		}
+		return res, nil
+	}
+	return []string{strings.Join(prefix, ".")}, nil
+}
+
+// positional returns the index of the first element of arr, the array at path, for which doc
+// matches the query.
+func (uc *updateContext) positional(doc primitive.D, path string, arr primitive.A) (int, bool, error) {
+	for i, e := range arr {
+		candidate, err := setPath(copyDoc(doc), path, primitive.A{e})
+		if err != nil {
+			return 0, false, err
+		}
+		ok, err := match(candidate, uc.filter)
+		if err != nil || ok {
+			return i, ok, err
+		}
+	}
+	return 0, false, nil
+}
+
+func (uc *updateContext) arrayFilter(id string) (primitive.D, bool) {
+	for _, f := range uc.arrayFilters {
+		if strings.Split(f[0].Key, ".")[0] == id {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// child returns the field or element part of v, missing{} when there is none.
+func child(v interface{}, part string) interface{} {
+	switch val := v.(type) {
+	case primitive.D:
+		if c, ok := docGet(val, part); ok {
+			return c
+		}
+	case primitive.A:
+		if idx, err := strconv.Atoi(part); err == nil && idx >= 0 && idx < len(val) {
+			return val[idx]
+		}
+	}
+	return missing{}
+}
+
+// applyOperator applies the update operator to the field at path, which has no positional
+// operators anymore.
+func applyOperator(doc primitive.D, operator, path string, value interface{}, inserting bool) (primitive.D, error) {
+	cur := lookupOne(doc, path)
+	switch operator {
+	case op.Set:
+		return setPath(doc, path, value)
+	case op.SetOnInsert:
+		if inserting {
+			return setPath(doc, path, value)
+		}
+		return doc, nil
+	case op.Unset:
+		return unsetPath(doc, path), nil
+	case op.Inc, op.Mul:
+		if !isNumber(value) {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] cannot %s with non-numeric value %v", operator, value)
+		}
+		switch {
+		case typeClass(cur) == classMissing && operator == op.Inc:
+			return setPath(doc, path, value)
+		case typeClass(cur) == classMissing:
+			return setPath(doc, path, mulNumbers(value, int32(0)))
+		case !isNumber(cur):
+			return nil, cExceptions.InvalidParamError("[mongodbtest] cannot %s non-numeric field %s", operator, path)
+		case operator == op.Inc:
+			return setPath(doc, path, addNumbers(cur, value))
+		default:
+			return setPath(doc, path, mulNumbers(cur, value))
+		}
+	case op.Min, op.Max:
+		c := compareValues(value, cur)
+		if typeClass(cur) == classMissing || (operator == op.Min && c < 0) || (operator == op.Max && c > 0) {
+			return setPath(doc, path, value)
+		}
+		return doc, nil
+	case op.CurrentDate:
+		now := time.Now()
+		var date interface{} = primitive.NewDateTimeFromTime(now)
+		if spec, ok := toDoc(value); ok {
+			if typ, _ := docGet(spec, "$type"); toString(typ) == "timestamp" {
+				date = primitive.Timestamp{T: uint32(now.Unix()), I: 1}
+			}
+		}
+		return setPath(doc, path, date)
+	case op.Rename:
+		target, ok := value.(string)
+		if !ok || target == "" {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s of %s should be a field name, but %v", operator, path, value)
+		}
+		if typeClass(cur) == classMissing {
+			return doc, nil
+		}
+		return setPath(unsetPath(doc, path), target, cur)
+	case op.Push, op.AddToSet, op.Pull, op.PullAll, op.Pop:
+		var arr primitive.A
+		switch val := cur.(type) {
+		case primitive.A:
+			arr = append(primitive.A{}, val...)
+		case missing:
+			if operator != op.Push && operator != op.AddToSet {
+				return doc, nil
+			}
+			arr = primitive.A{}
+		default:
+			return nil, cExceptions.InvalidParamError("[mongodbtest] cannot apply %s to non-array field %s", operator, path)
+		}
+		arr, err := updateArray(arr, operator, value)
+		if err != nil {
+			return nil, err
+		}
+		return setPath(doc, path, arr)
+	default:
+		return nil, cExceptions.InvalidParamError("[mongodbtest] unsupported update operator %s", operator)
+	}
+}
+
+// updateArray applies the array update operator to arr.
+func updateArray(arr primitive.A, operator string, value interface{}) (primitive.A, error) {
+	switch operator {
+	case op.Push:
+		return push(arr, value)
+	case op.AddToSet:
+		values := primitive.A{value}
+		if d, ok := toDoc(value); ok && isOperatorDoc(d) {
+			each, _ := docGet(d, op.Each)
+			if values, ok = each.(primitive.A); !ok {
+				return nil, cExceptions.InvalidParamError("[mongodbtest] %s should be an array, but %T", op.Each, each)
+			}
+		}
+		for _, v := range values {
+			if !containsValue(arr, v) {
+				arr = append(arr, v)
+			}
+		}
+		return arr, nil
+	case op.Pull:
+		res := primitive.A{}
+		for _, e := range arr {
+			ok, err := pullMatch(e, value)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				res = append(res, e)
+			}
+		}
+		return res, nil
+	case op.PullAll:
+		values, ok := value.(primitive.A)
+		if !ok {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] %s should be an array, but %T", operator, value)
+		}
+		res := primitive.A{}
+		for _, e := range arr {
+			if !containsValue(values, e) {
+				res = append(res, e)
+			}
+		}
+		return res, nil
+	default: // op.Pop
+		n, _ := toInt(value)
+		switch {
+		case len(arr) == 0:
+		case n < 0:
+			arr = arr[1:]
+		default:
+			arr = arr[:len(arr)-1]
+		}
+		return arr, nil
+	}
+}
+
+// push applies $push, with the $each, $position, $sort and $slice modifiers.
+func push(arr primitive.A, value interface{}) (primitive.A, error) {
+	mods, ok := toDoc(value)
+	if !ok || !isOperatorDoc(mods) {
+		return append(arr, value), nil
+	}
+	each, _ := docGet(mods, op.Each)
+	values, ok := each.(primitive.A)
+	if !ok {
+		return nil, cExceptions.InvalidParamError("[mongodbtest] %s should be an array, but %T", op.Each, each)
+	}
+
+	pos := len(arr)
+	if v, ok := docGet(mods, op.Position); ok {
+		n, _ := toInt(v)
+		if pos = int(n); pos < 0 {
+			pos += len(arr)
+		}
+		if pos < 0 {
+			pos = 0
+		} else if pos > len(arr) {
+			pos = len(arr)
+		}
+	}
+	res := append(append(append(primitive.A{}, arr[:pos]...), values...), arr[pos:]...)
+
+	if spec, ok := docGet(mods, op.Sort); ok {
+		sort.SliceStable(res, func(i, j int) bool {
+			if fields, ok := toDoc(spec); ok {
+				for _, f := range fields {
+					c := compareValues(lookupOne(res[i], f.Key), lookupOne(res[j], f.Key))
+					if n, _ := toFloat(f.Value); n < 0 {
+						c = -c
+					}
+					if c != 0 {
+						return c < 0
+					}
+				}
+				return false
+			}
+			if n, _ := toFloat(spec); n < 0 {
+				return compareValues(res[i], res[j]) > 0
+			}
+			return compareValues(res[i], res[j]) < 0
+		})
+	}
+	if v, ok := docGet(mods, op.Slice); ok {
+		n, _ := toInt(v)
+		switch {
+		case n >= 0 && int(n) < len(res):
+			res = res[:n]
+		case n < 0 && int(-n) < len(res):
+			res = res[len(res)+int(n):]
+		}
+	}
+	return res, nil
+}
+
+// pullMatch reports whether $pull removes e: cond is a value e equals, a condition such as
+// {$gte: 6}, or a query on the fields of e.
+func pullMatch(e, cond interface{}) (bool, error) {
+	if isOperatorDoc(cond) {
+		return matchField([]interface{}{e}, cond)
+	}
+	if d, ok := toDoc(cond); ok {
+		if elem, ok := toDoc(e); ok {
+			return match(elem, d)
+		}
+	}
+	return equalValues(e, cond), nil
+}
+
+func containsValue(arr primitive.A, v interface{}) bool {
+	for _, e := range arr {
+		if equalValues(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyDoc(doc primitive.D) primitive.D {
+	return copyValue(doc).(primitive.D)
+}
+
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case primitive.D:
+		d := make(primitive.D, len(val))
+		for i, e := range val {
+			d[i] = primitive.E{Key: e.Key, Value: copyValue(e.Value)}
+		}
+		return d
+	case primitive.A:
+		a := make(primitive.A, len(val))
+		for i, e := range val {
+			a[i] = copyValue(e)
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+// setPath sets a dotted path, creating intermediate documents and padding arrays with nulls as needed.
+func setPath(doc primitive.D, path string, value interface{}) (primitive.D, error) {
+	v, err := setParts(doc, strings.Split(path, "."), value)
+	if err != nil {
+		return nil, err
+	}
+	return v.(primitive.D), nil
+}
+
+func setParts(v interface{}, parts []string, value interface{}) (interface{}, error) {
+	if len(parts) == 0 {
+		return value, nil
+	}
+	switch val := v.(type) {
+	case primitive.D:
+		child, ok := docGet(val, parts[0])
+		if !ok {
+			child = primitive.D{}
+		}
+		child, err := setParts(child, parts[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return docSet(val, parts[0], child), nil
+	case primitive.A:
+		idx, err := strconv.Atoi(parts[0])
+		if err != nil || idx < 0 {
+			return nil, cExceptions.InvalidParamError("[mongodbtest] cannot use the part (%s) to traverse an array", parts[0])
+		}
+		for len(val) <= idx {
+			val = append(val, nil)
+		}
+		child := val[idx]
+		if child == nil && len(parts) > 1 {
+			child = primitive.D{}
+		}
+		if val[idx], err = setParts(child, parts[1:], value); err != nil {
+			return nil, err
+		}
+		return val, nil
+	default:
+		return nil, cExceptions.InvalidParamError("[mongodbtest] cannot create field %s in element of type %T", parts[0], v)
+	}
+}
+
+func unsetPath(doc primitive.D, path string) primitive.D {
+	return unsetParts(doc, strings.Split(path, ".")).(primitive.D)
+}
+
+func unsetParts(v interface{}, parts []string) interface{} {
+	switch val := v.(type) {
+	case primitive.D:
+		if len(parts) == 1 {
+			return docDel(val, parts[0])
+		}
+		child, ok := docGet(val, parts[0])
+		if !ok {
+			return val
+		}
+		return docSet(val, parts[0], unsetParts(child, parts[1:]))
+	case primitive.A:
+		idx, err := strconv.Atoi(parts[0])
+		if err != nil || idx < 0 || idx >= len(val) {
+			return val
+		}
+		if len(parts) == 1 {
+			// $unset on an array element leaves a null in place
+			val[idx] = nil
+			return val
+		}
+		val[idx] = unsetParts(val[idx], parts[1:])
+		return val
+	default:
+		return v
+	}
+}
+
+// upsertSeed builds the document an upsert starts from: the equality conditions of the query.
+func upsertSeed(filter interface{}) primitive.D {
+	doc := primitive.D{}
+	f, ok := toDoc(filter)
+	if !ok {
+		return doc
+	}
+	for _, e := range f {
+		switch {
+		case e.Key == op.And:
+			if exps, ok := e.Value.(primitive.A); ok {
+				for _, exp := range exps {
+					for _, s := range upsertSeed(exp) {
+						doc, _ = setPath(doc, s.Key, s.Value)
+					}
+				}
+			}
+		case strings.HasPrefix(e.Key, "$"):
+		case isOperatorDoc(e.Value):
+			if eq, ok := docGet(e.Value.(primitive.D), op.Eq); ok {
+				doc, _ = setPath(doc, e.Key, eq)
+			}
+		default:
+			doc, _ = setPath(doc, e.Key, e.Value)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,425 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package mongodbtest
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// missing marks a path that does not exist in a document, which is different from an explicit null.
+type missing struct{}
+
+// normalize converts a value into the shapes the engine works with: primitive.D for documents
+// and primitive.A for arrays.
+func normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case primitive.D:
+		d := make(primitive.D, 0, len(val))
+		for _, e := range val {
+			d = append(d, primitive.E{Key: e.Key, Value: normalize(e.Value)})
+		}
+		return d
+	case primitive.M:
+		return normalize(mapToD(val))
+	case map[string]interface{}:
+		return normalize(mapToD(val))
+	case primitive.A:
+		a := make(primitive.A, 0, len(val))
+		for _, e := range val {
+			a = append(a, normalize(e))
+		}
+		return a
+	case []interface{}:
+		return normalize(primitive.A(val))
+	default:
+		return v
+	}
+}
+
+func mapToD(m map[string]interface{}) primitive.D {
+	d := make(primitive.D, 0, len(m))
+	for k, v := range m {
+		d = append(d, primitive.E{Key: k, Value: v})
+	}
+	return d
+}
+
+// toValue round-trips v through BSON so that structs, maps and typed slices become plain documents.
+func toValue(v interface{}) (interface{}, error) {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: v}})
+	if err != nil {
+		return nil, err
+	}
+	var d primitive.D
+	if err = bson.Unmarshal(data, &d); err != nil {
+		return nil, err
+	}
+	return normalize(d[0].Value), nil
+}
+
+func toDoc(v interface{}) (primitive.D, bool) {
+	d, ok := v.(primitive.D)
+	return d, ok
+}
+
+func docGet(doc primitive.D, key string) (interface{}, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+func docSet(doc primitive.D, key string, value interface{}) primitive.D {
+	for i, e := range doc {
+		if e.Key == key {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, primitive.E{Key: key, Value: value})
+}
+
+func docDel(doc primitive.D, key string) primitive.D {
+	for i, e := range doc {
+		if e.Key == key {
+			return append(doc[:i:i], doc[i+1:]...)
+		}
+	}
+	return doc
+}
+
+func isOperatorDoc(v interface{}) bool {
+	d, ok := toDoc(v)
+	return ok && len(d) > 0 && strings.HasPrefix(d[0].Key, "$")
+}
+
+// lookup resolves a dotted path. Arrays met along the way are traversed element by element,
+// so the result may contain several values. A missing path yields no values.
+func lookup(v interface{}, path string) []interface{} {
+	return lookupParts(v, strings.Split(path, "."))
+}
+
+func lookupParts(v interface{}, parts []string) []interface{} {
+	if len(parts) == 0 {
+		return []interface{}{v}
+	}
+	switch val := v.(type) {
+	case primitive.D:
+		child, ok := docGet(val, parts[0])
+		if !ok {
+			return nil
+		}
+		return lookupParts(child, parts[1:])
+	case primitive.A:
+		if idx, err := strconv.Atoi(parts[0]); err == nil {
+			if idx < 0 || idx >= len(val) {
+				return nil
+			}
+			return lookupParts(val[idx], parts[1:])
+		}
+		var res []interface{}
+		for _, e := range val {
+			if _, ok := e.(primitive.D); ok {
+				res = append(res, lookupParts(e, parts)...)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+// lookupOne resolves a path the way aggregation expressions do and returns missing{} when nothing is found.
+func lookupOne(v interface{}, path string) interface{} {
+	values := lookup(v, path)
+	switch len(values) {
+	case 0:
+		return missing{}
+	case 1:
+		return values[0]
+	default:
+		return primitive.A(values)
+	}
+}
+
+// expand returns the candidates a query predicate is tested against: every value and,
+// for arrays, every element as well.
+func expand(values []interface{}) []interface{} {
+	var res []interface{}
+	for _, v := range values {
+		res = append(res, v)
+		if a, ok := v.(primitive.A); ok {
+			res = append(res, a...)
+		}
+	}
+	return res
+}
+
+const (
+	classMissing = iota
+	classNull
+	classNumber
+	classString
+	classDocument
+	classArray
+	classBinary
+	classObjectID
+	classBool
+	classDate
+	classTimestamp
+	classRegex
+	classOther
+)
+
+func typeClass(v interface{}) int {
+	switch v.(type) {
+	case missing:
+		return classMissing
+	case nil, primitive.Null, primitive.Undefined:
+		return classNull
+	case int, int32, int64, float32, float64:
+		return classNumber
+	case string, primitive.Symbol:
+		return classString
+	case primitive.D:
+		return classDocument
+	case primitive.A:
+		return classArray
+	case primitive.Binary:
+		return classBinary
+	case primitive.ObjectID:
+		return classObjectID
+	case bool:
+		return classBool
+	case primitive.DateTime:
+		return classDate
+	case primitive.Timestamp:
+		return classTimestamp
+	case primitive.Regex:
+		return classRegex
+	default:
+		return classOther
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+func isNumber(v interface{}) bool {
+	return typeClass(v) == classNumber
+}
+
+// coerceObjectID lets a hex string be compared with an ObjectID, the way the FaaS infra treats _id filters.
+func coerceObjectID(a, b interface{}) (interface{}, interface{}) {
+	if id, ok := a.(primitive.ObjectID); ok {
+		if s, ok := b.(string); ok {
+			if oid, err := primitive.ObjectIDFromHex(s); err == nil {
+				return id, oid
+			}
+		}
+	}
+	if s, ok := a.(string); ok {
+		if id, ok := b.(primitive.ObjectID); ok {
+			if oid, err := primitive.ObjectIDFromHex(s); err == nil {
+				return oid, id
+			}
+		}
+	}
+	return a, b
+}
+
+// compareValues orders two values by BSON type class first and by value second.
+func compareValues(a, b interface{}) int {
+	a, b = coerceObjectID(a, b)
+	ca, cb := typeClass(a), typeClass(b)
+	if ca != cb {
+		if ca < cb {
+			return -1
+		}
+		return 1
+	}
+
+	switch ca {
+	case classNumber:
+		if x, ok := toInt(a); ok {
+			if y, ok := toInt(b); ok {
+				return compareInt(x, y)
+			}
+		}
+		x, _ := toFloat(a)
+		y, _ := toFloat(b)
+		return compareFloat(x, y)
+	case classString:
+		return strings.Compare(toString(a), toString(b))
+	case classDocument:
+		x, y := a.(primitive.D), b.(primitive.D)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := strings.Compare(x[i].Key, y[i].Key); c != 0 {
+				return c
+			}
+			if c := compareValues(x[i].Value, y[i].Value); c != 0 {
+				return c
+			}
+		}
+		return compareInt(int64(len(x)), int64(len(y)))
+	case classArray:
+		x, y := a.(primitive.A), b.(primitive.A)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareValues(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(int64(len(x)), int64(len(y)))
+	case classBinary:
+		return bytes.Compare(a.(primitive.Binary).Data, b.(primitive.Binary).Data)
+	case classObjectID:
+		x, y := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case classBool:
+		x, y := a.(bool), b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case classDate:
+		return compareInt(int64(a.(primitive.DateTime)), int64(b.(primitive.DateTime)))
+	case classTimestamp:
+		x, y := a.(primitive.Timestamp), b.(primitive.Timestamp)
+		if x.T != y.T {
+			return compareInt(int64(x.T), int64(y.T))
+		}
+		return compareInt(int64(x.I), int64(y.I))
+	case classMissing, classNull:
+		return 0
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(reflect.TypeOf(a).String(), reflect.TypeOf(b).String())
+}
+
+func equalValues(a, b interface{}) bool {
+	a, b = coerceObjectID(a, b)
+	if typeClass(a) != typeClass(b) {
+		return false
+	}
+	return compareValues(a, b) == 0
+}
+
+func compareInt(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	case math.IsNaN(x) && !math.IsNaN(y):
+		return -1
+	case !math.IsNaN(x) && math.IsNaN(y):
+		return 1
+	}
+	return 0
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case primitive.Symbol:
+		return string(s)
+	}
+	return ""
+}
+
+// addNumbers keeps integer arithmetic exact and falls back to float64 as soon as one side is a float.
+func addNumbers(a, b interface{}) interface{} {
+	x, xInt := toInt(a)
+	y, yInt := toInt(b)
+	if xInt && yInt {
+		return narrowInt(a, b, x+y)
+	}
+	fx, _ := toFloat(a)
+	fy, _ := toFloat(b)
+	return fx + fy
+}
+
+func mulNumbers(a, b interface{}) interface{} {
+	x, xInt := toInt(a)
+	y, yInt := toInt(b)
+	if xInt && yInt {
+		return narrowInt(a, b, x*y)
+	}
+	fx, _ := toFloat(a)
+	fy, _ := toFloat(b)
+	return fx * fy
+}
+
+// narrowInt keeps an int32 result when both operands were int32 and the result still fits.
+func narrowInt(a, b interface{}, n int64) interface{} {
+	_, x32 := a.(int32)
+	_, y32 := b.(int32)
+	if x32 && y32 && n >= math.MinInt32 && n <= math.MaxInt32 {
+		return int32(n)
+	}
+	return n
+}
+
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case missing, nil, primitive.Null, primitive.Undefined:
+		return false
+	case bool:
+		return val
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	return true
+}
@@ -8,7 +8,7 @@ const (
 	And          = "$and"
 	Nor          = "$nor"
 	Not          = "$not"
-	Expr         = "$exp"
+	Expr         = "$expr"
 	Where        = "$where"
 	In           = "$in"
 	NotIn        = "$nin"
@@ -34,4 +34,46 @@ const (
 	Inc          = "$inc"
 	SetOnInsert  = "$setOnInsert"
 	Multiply     = "$multiply"
+	Mul          = "$mul"
+	Min          = "$min"
+	Max          = "$max"
+	Rename       = "$rename"
+	CurrentDate  = "$currentDate"
+	Pull         = "$pull"
+	PullAll      = "$pullAll"
+	Pop          = "$pop"
+	Each         = "$each"
+	Position     = "$position"
+	Slice        = "$slice"
+	Sort         = "$sort"
+	Literal      = "$literal"
+	Add          = "$add"
+	Subtract     = "$subtract"
+	Divide       = "$divide"
+	Mod          = "$mod"
+	Abs          = "$abs"
+	Ceil         = "$ceil"
+	Floor        = "$floor"
+	Round        = "$round"
+	Cond         = "$cond"
+	IfNull       = "$ifNull"
+	Concat       = "$concat"
+	SubstrCP     = "$substrCP"
+	StrLenCP     = "$strLenCP"
+	ToLower      = "$toLower"
+	ToUpper      = "$toUpper"
+	Split        = "$split"
+	DateToString = "$dateToString"
+	Year         = "$year"
+	Month        = "$month"
+	DayOfMonth   = "$dayOfMonth"
+	DayOfWeek    = "$dayOfWeek"
+	Hour         = "$hour"
+	Minute       = "$minute"
+	Second       = "$second"
+	Size         = "$size"
+	ArrayElemAt  = "$arrayElemAt"
+	ConcatArrays = "$concatArrays"
+	Filter       = "$filter"
+	Map          = "$map"
 )
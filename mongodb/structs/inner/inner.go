@@ -9,12 +9,22 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/byted-apaas/baas-sdk-go/common/structs"
 )
 
 type BatchCreateResult struct {
 	IDs []primitive.ObjectID `bson:"data"`
 }
 
+type UpdateResult struct {
+	Data structs.UpdateResult `bson:"data"`
+}
+
+type DeleteResult struct {
+	Data structs.DeleteResult `bson:"data"`
+}
+
 type CountResult struct {
 	Data struct {
 		Count int64 `bson:"count"`
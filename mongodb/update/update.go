@@ -0,0 +1,279 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package update builds the update documents IQuery.Update, Upsert and BatchUpdate accept
+// in place of a record, whose fields they would otherwise set.
+//
+//	err := baas.MongoDB.Table("goods").Where(cond.M{"item": "iphone"}).Update(ctx,
+//		update.Inc("qty", -1).Push("history", entry).CurrentDate("updatedAt"))
+//
+// Fields are dotted paths, which may use the positional operators: "grades.$" is the element
+// of grades the query matched, "grades.$[]" all the elements, and "grades.$[elem]" those
+// matching the filter on elem given to ArrayFilters:
+//
+//	update.Set("grades.$[g].passed", true).ArrayFilters(cond.M{"g.score": cond.Gte(60)})
+package update
+
+import (
+	"reflect"
+
+	cond "github.com/byted-apaas/baas-sdk-go/mongodb/condition"
+	op "github.com/byted-apaas/baas-sdk-go/mongodb/operator"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// Update is an update document. Its methods add an operation on a field and return it, for
+// chaining.
+type Update struct {
+	doc          cond.M
+	arrayFilters []interface{}
+	err          error
+}
+
+func New() *Update {
+	return &Update{doc: cond.M{}}
+}
+
+func Set(field string, value interface{}) *Update {
+	return New().Set(field, value)
+}
+
+func SetOnInsert(field string, value interface{}) *Update {
+	return New().SetOnInsert(field, value)
+}
+
+func Unset(fields ...string) *Update {
+	return New().Unset(fields...)
+}
+
+func Inc(field string, value interface{}) *Update {
+	return New().Inc(field, value)
+}
+
+func Mul(field string, value interface{}) *Update {
+	return New().Mul(field, value)
+}
+
+func Min(field string, value interface{}) *Update {
+	return New().Min(field, value)
+}
+
+func Max(field string, value interface{}) *Update {
+	return New().Max(field, value)
+}
+
+func CurrentDate(fields ...string) *Update {
+	return New().CurrentDate(fields...)
+}
+
+func Rename(field, newName string) *Update {
+	return New().Rename(field, newName)
+}
+
+func Push(field string, value interface{}) *Update {
+	return New().Push(field, value)
+}
+
+func PushEach(field string, values interface{}, modifiers ...PushModifier) *Update {
+	return New().PushEach(field, values, modifiers...)
+}
+
+func AddToSet(field string, value interface{}) *Update {
+	return New().AddToSet(field, value)
+}
+
+func AddToSetEach(field string, values interface{}) *Update {
+	return New().AddToSetEach(field, values)
+}
+
+func Pull(field string, condition interface{}) *Update {
+	return New().Pull(field, condition)
+}
+
+func PullAll(field string, values interface{}) *Update {
+	return New().PullAll(field, values)
+}
+
+func PopFirst(field string) *Update {
+	return New().PopFirst(field)
+}
+
+func PopLast(field string) *Update {
+	return New().PopLast(field)
+}
+
+func (u *Update) add(operator, field string, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+	if field == "" {
+		u.err = cExceptions.InvalidParamError("update %s received an empty field", operator)
+		return u
+	}
+	fields, ok := u.doc[operator].(cond.M)
+	if !ok {
+		fields = cond.M{}
+		u.doc[operator] = fields
+	}
+	fields[field] = value
+	return u
+}
+
+// addEach is add for the operators taking an array of values, value holding values.
+func (u *Update) addEach(operator, field string, values, value interface{}) *Update {
+	if u.err != nil {
+		return u
+	}
+	if values == nil || (reflect.TypeOf(values).Kind() != reflect.Slice && reflect.TypeOf(values).Kind() != reflect.Array) {
+		u.err = cExceptions.InvalidParamError("update %s of %s should receive a slice, but %T", operator, field, values)
+		return u
+	}
+	return u.add(operator, field, value)
+}
+
+// Set sets field to value.
+func (u *Update) Set(field string, value interface{}) *Update {
+	return u.add(op.Set, field, value)
+}
+
+// SetOnInsert sets field to value when an Upsert inserts the record, and leaves it otherwise.
+func (u *Update) SetOnInsert(field string, value interface{}) *Update {
+	return u.add(op.SetOnInsert, field, value)
+}
+
+// Unset removes fields.
+func (u *Update) Unset(fields ...string) *Update {
+	for _, field := range fields {
+		u.add(op.Unset, field, "")
+	}
+	return u
+}
+
+// Inc adds value, which may be negative, to field, setting it to value when it is missing.
+func (u *Update) Inc(field string, value interface{}) *Update {
+	return u.add(op.Inc, field, value)
+}
+
+// Mul multiplies field by value, setting it to 0 when it is missing.
+func (u *Update) Mul(field string, value interface{}) *Update {
+	return u.add(op.Mul, field, value)
+}
+
+// Min sets field to value when value is lower, or field is missing.
+func (u *Update) Min(field string, value interface{}) *Update {
+	return u.add(op.Min, field, value)
+}
+
+// Max sets field to value when value is greater, or field is missing.
+func (u *Update) Max(field string, value interface{}) *Update {
+	return u.add(op.Max, field, value)
+}
+
+// CurrentDate sets fields to the current date of the database.
+func (u *Update) CurrentDate(fields ...string) *Update {
+	for _, field := range fields {
+		u.add(op.CurrentDate, field, true)
+	}
+	return u
+}
+
+// Rename renames field to newName, replacing the value of newName.
+func (u *Update) Rename(field, newName string) *Update {
+	if u.err == nil && (newName == "" || newName == field) {
+		u.err = cExceptions.InvalidParamError("update %s of %s received an invalid new name %q", op.Rename, field, newName)
+		return u
+	}
+	return u.add(op.Rename, field, newName)
+}
+
+// Push appends value to the array field, creating it when it is missing.
+func (u *Update) Push(field string, value interface{}) *Update {
+	return u.add(op.Push, field, value)
+}
+
+// PushModifier changes where PushEach inserts the values, and which elements it keeps.
+type PushModifier func(m cond.M)
+
+// Position inserts the values at index i rather than at the end, counting from the end
+// when i is negative.
+func Position(i int) PushModifier {
+	return func(m cond.M) {
+		m[op.Position] = i
+	}
+}
+
+// Sort sorts the array after the values are inserted: order is 1 or -1 for arrays of
+// values, and a document such as cond.M{"score": -1} for arrays of documents.
+func Sort(order interface{}) PushModifier {
+	return func(m cond.M) {
+		m[op.Sort] = order
+	}
+}
+
+// Slice keeps the first n elements of the array after the values are inserted, or the last
+// -n ones when n is negative.
+func Slice(n int) PushModifier {
+	return func(m cond.M) {
+		m[op.Slice] = n
+	}
+}
+
+// PushEach appends the slice values to the array field, as modifiers direct.
+func (u *Update) PushEach(field string, values interface{}, modifiers ...PushModifier) *Update {
+	m := cond.M{op.Each: values}
+	for _, modifier := range modifiers {
+		modifier(m)
+	}
+	return u.addEach(op.Push, field, values, m)
+}
+
+// AddToSet appends value to the array field unless it holds it already.
+func (u *Update) AddToSet(field string, value interface{}) *Update {
+	return u.add(op.AddToSet, field, value)
+}
+
+// AddToSetEach is AddToSet for each of the slice values.
+func (u *Update) AddToSetEach(field string, values interface{}) *Update {
+	return u.addEach(op.AddToSet, field, values, cond.M{op.Each: values})
+}
+
+// Pull removes from the array field the elements equal to condition, or matching it when it
+// is a condition, e.g. cond.Gte(6), or a query on the fields of the elements, e.g.
+// cond.M{"score": cond.Lt(60)}.
+func (u *Update) Pull(field string, condition interface{}) *Update {
+	return u.add(op.Pull, field, condition)
+}
+
+// PullAll removes from the array field the elements equal to any of the slice values.
+func (u *Update) PullAll(field string, values interface{}) *Update {
+	return u.addEach(op.PullAll, field, values, values)
+}
+
+// PopFirst removes the first element of the array field.
+func (u *Update) PopFirst(field string) *Update {
+	return u.add(op.Pop, field, -1)
+}
+
+// PopLast removes the last element of the array field.
+func (u *Update) PopLast(field string) *Update {
+	return u.add(op.Pop, field, 1)
+}
+
+// ArrayFilters adds the filters selecting the elements of the "$[identifier]" paths. Each
+// filter is a condition on a single identifier, e.g. cond.M{"elem.score": cond.Gte(60)}, or
+// cond.M{"elem": cond.Gte(60)} for arrays of values.
+func (u *Update) ArrayFilters(filters ...interface{}) *Update {
+	u.arrayFilters = append(u.arrayFilters, filters...)
+	return u
+}
+
+// Build returns the update document and the array filters, or the first invalid operation.
+func (u *Update) Build() (cond.M, []interface{}, error) {
+	if u.err != nil {
+		return nil, nil, u.err
+	}
+	if len(u.doc) == 0 {
+		return nil, nil, cExceptions.InvalidParamError("update is empty")
+	}
+	return u.doc, u.arrayFilters, nil
+}
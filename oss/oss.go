@@ -15,14 +15,29 @@ type IOss interface {
 	UploadWithPath(ctx context.Context, name string, filePath string, option *structs.Option) (*structs.UploadResult, error)
 }
 
-type Oss struct{}
+type Oss struct {
+	transport faasinfra.FileTransport
+}
+
+type Option func(f *Oss)
+
+// WithTransport replaces the transport used to upload files, e.g. with an in-memory one in tests.
+func WithTransport(transport faasinfra.FileTransport) Option {
+	return func(f *Oss) {
+		f.transport = transport
+	}
+}
 
-func NewOss() *Oss {
-	return &Oss{}
+func NewOss(opts ...Option) *Oss {
+	f := &Oss{}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 func (f *Oss) UploadWithContent(ctx context.Context, name string, content []byte, option *structs.Option) (*structs.UploadResult, error) {
-	return faasinfra.UploadWithContent(ctx, name, content, option)
+	return faasinfra.UploadWithContent(ctx, f.transport, name, content, option)
 }
 
 func (f *Oss) UploadWithURL(ctx context.Context, name string, targetUrl string, option *structs.Option) (*structs.UploadResult, error) {
@@ -30,7 +45,7 @@ func (f *Oss) UploadWithURL(ctx context.Context, name string, targetUrl string,
 	if err != nil {
 		return nil, cException.InvalidParamError("fetch data from targetUrl error: %v", err)
 	}
-	return faasinfra.UploadWithContent(ctx, name, data, option)
+	return faasinfra.UploadWithContent(ctx, f.transport, name, data, option)
 }
 
 func (f *Oss) UploadWithPath(ctx context.Context, name string, filePath string, option *structs.Option) (*structs.UploadResult, error) {
@@ -38,5 +53,5 @@ func (f *Oss) UploadWithPath(ctx context.Context, name string, filePath string,
 	if err != nil {
 		return nil, cException.InvalidParamError("read data from filePath error: %v", err)
 	}
-	return faasinfra.UploadWithContent(ctx, name, data, option)
+	return faasinfra.UploadWithContent(ctx, f.transport, name, data, option)
 }
@@ -0,0 +1,51 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+// Overflow modes of the INCRBY and SET operations of a BitField.
+const (
+	OverflowWrap = "WRAP"
+	OverflowSat  = "SAT"
+	OverflowFail = "FAIL"
+)
+
+// BitFieldArgs builds the operations of a BITFIELD command, run in order. Types are
+// "i" or "u" followed by the number of bits, e.g. "u8" or "i5". Offsets are bit offsets,
+// or "#n" to address the n-th field of the type.
+//
+//	ops := redis.NewBitFieldArgs().
+//		Get("u8", 0).
+//		Overflow(redis.OverflowSat).IncrBy("u8", "#1", 300)
+//	vals, err := cli.BitField(ctx, "key", ops).Result()
+type BitFieldArgs struct {
+	args []interface{}
+}
+
+func NewBitFieldArgs() *BitFieldArgs {
+	return &BitFieldArgs{}
+}
+
+// Get reads the field of type t at offset.
+func (b *BitFieldArgs) Get(t string, offset interface{}) *BitFieldArgs {
+	b.args = append(b.args, "get", t, offset)
+	return b
+}
+
+// Set writes value to the field of type t at offset, and returns the previous value.
+func (b *BitFieldArgs) Set(t string, offset interface{}, value int64) *BitFieldArgs {
+	b.args = append(b.args, "set", t, offset, value)
+	return b
+}
+
+// IncrBy adds increment to the field of type t at offset, and returns the new value.
+func (b *BitFieldArgs) IncrBy(t string, offset interface{}, increment int64) *BitFieldArgs {
+	b.args = append(b.args, "incrby", t, offset, increment)
+	return b
+}
+
+// Overflow sets how the following Set and IncrBy operations overflow, OverflowWrap by default.
+func (b *BitFieldArgs) Overflow(mode string) *BitFieldArgs {
+	b.args = append(b.args, "overflow", mode)
+	return b
+}
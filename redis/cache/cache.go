@@ -0,0 +1,185 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package cache implements a read-through cache on top of redis.IRedis, typically in front
+// of MongoDB queries.
+//
+//	users := cache.New(baas.Redis)
+//	var u User
+//	err := users.GetOrLoad(ctx, "user:42", time.Hour, &u, func(ctx context.Context) (interface{}, error) {
+//		var u User
+//		if err := baas.MongoDB.Table("user").Where(cond.M{"id": 42}).FindOne(ctx, &u); err != nil {
+//			return nil, err
+//		}
+//		if u.ID == 0 {
+//			return nil, cache.ErrNotFound
+//		}
+//		return u, nil
+//	})
+//
+//	// after the record changed
+//	err = users.AfterWrite(ctx, baas.MongoDB.Table("user").Where(cond.M{"id": 42}).Update(ctx, rec), "user:42")
+//
+// Concurrent loads of a key within the process run the loader once, the values not found
+// are cached for a while too, and the TTLs are spread so that keys cached together do not
+// expire together.
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// ErrNotFound is returned by a Loader when there is no value for a key, and then by GetOrLoad.
+// A Loader returning redis.Nil is treated the same.
+var ErrNotFound = errors.New("cache: not found")
+
+// notFound is the value cached for the keys a Loader found no value for. Neither JSON nor
+// base64 start with a NUL byte.
+const notFound = "\x00notfound"
+
+// Loader loads the value of a key missing from the cache, e.g. with a MongoDB FindOne.
+type Loader func(ctx context.Context) (interface{}, error)
+
+// Cache reads values from redis, and loads the missing ones.
+type Cache struct {
+	client redis.IRedis
+	opts   options
+	loads  group
+}
+
+type options struct {
+	codec       redis.Codec
+	negativeTTL time.Duration
+	jitter      float64
+}
+
+type Option func(o *options)
+
+// WithCodec sets how values are encoded in redis, redis.JSONCodec by default.
+func WithCodec(codec redis.Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+// WithNegativeTTL sets how long the keys a Loader found no value for are cached, 1 minute by
+// default. 0 disables the caching of missing values.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithJitter sets the fraction of the TTLs randomly added to them, 0.1 by default, so that
+// the keys cached at the same time do not all expire at the same time.
+func WithJitter(jitter float64) Option {
+	return func(o *options) {
+		o.jitter = jitter
+	}
+}
+
+func New(client redis.IRedis, opts ...Option) *Cache {
+	c := &Cache{
+		client: client,
+		opts: options{
+			codec:       redis.JSONCodec{},
+			negativeTTL: time.Minute,
+			jitter:      0.1,
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	return c
+}
+
+// GetOrLoad decodes the value of key into dst. When key is not cached, it calls load and
+// caches the value it returns for ttl, 0 caching it without expiration. The concurrent
+// calls for the same key wait for the first one to load the value, the loader getting the
+// ctx of that call. GetOrLoad returns ErrNotFound when load did not find a value.
+//
+// Redis being unavailable does not fail GetOrLoad, which then loads the value.
+func (c *Cache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, dst interface{}, load Loader) error {
+	data, err := c.client.Get(ctx, key).Result()
+	if err != nil {
+		data, err = c.loads.do(key, func() (string, error) {
+			return c.load(ctx, key, ttl, load)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if data == notFound {
+		return ErrNotFound
+	}
+	if err := c.opts.codec.Unmarshal([]byte(data), dst); err != nil {
+		return cExceptions.InternalError("[cache] unmarshal value of %s failed, err: %v", key, err)
+	}
+	return nil
+}
+
+// load calls load and caches the value it returns, encoded.
+func (c *Cache) load(ctx context.Context, key string, ttl time.Duration, load Loader) (string, error) {
+	v, err := load(ctx)
+	if err == ErrNotFound || err == redis.Nil {
+		if c.opts.negativeTTL > 0 {
+			c.client.Set(ctx, key, notFound, c.jittered(c.opts.negativeTTL))
+		}
+		return notFound, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	data, err := c.opts.codec.Marshal(v)
+	if err != nil {
+		return "", cExceptions.InvalidParamError("[cache] marshal value of %s failed, err: %v", key, err)
+	}
+	// The value is returned even when it could not be cached.
+	c.client.Set(ctx, key, string(data), c.jittered(ttl))
+	return string(data), nil
+}
+
+func (c *Cache) jittered(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.opts.jitter <= 0 {
+		return ttl
+	}
+	if max := int64(float64(ttl) * c.opts.jitter); max > 0 {
+		ttl += time.Duration(rand.Int63n(max))
+	}
+	return ttl
+}
+
+// Set caches v for key, e.g. right after writing it to the database.
+func (c *Cache) Set(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	data, err := c.opts.codec.Marshal(v)
+	if err != nil {
+		return cExceptions.InvalidParamError("[cache] marshal value of %s failed, err: %v", key, err)
+	}
+	return c.client.Set(ctx, key, string(data), c.jittered(ttl)).Err()
+}
+
+// Invalidate removes keys from the cache, for their next GetOrLoad to load them again.
+func (c *Cache) Invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// AfterWrite invalidates keys when the write that returned err succeeded, and returns err
+// otherwise. It wraps the updates and deletes of the records behind keys:
+//
+//	err := c.AfterWrite(ctx, query.Update(ctx, record), "user:42")
+func (c *Cache) AfterWrite(ctx context.Context, err error, keys ...string) error {
+	if err != nil {
+		return err
+	}
+	return c.Invalidate(ctx, keys...)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+)
+
+var ctx = context.Background()
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetOrLoad(t *testing.T) {
+	cli := redistest.NewRedis()
+	c := New(cli, WithJitter(0))
+
+	var loads int
+	load := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return user{ID: 42, Name: "bob"}, nil
+	}
+	for i := 0; i < 3; i++ {
+		var u user
+		assert.NoError(t, c.GetOrLoad(ctx, "user:42", time.Hour, &u, load))
+		assert.Equal(t, user{ID: 42, Name: "bob"}, u)
+	}
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, `{"id":42,"name":"bob"}`, cli.Get(ctx, "user:42").Val())
+	assert.Equal(t, time.Hour, cli.TTL(ctx, "user:42").Val())
+
+	assert.NoError(t, c.AfterWrite(ctx, nil, "user:42"))
+	var u user
+	assert.NoError(t, c.GetOrLoad(ctx, "user:42", time.Hour, &u, load))
+	assert.Equal(t, 2, loads)
+
+	failed := errors.New("update failed")
+	assert.Equal(t, failed, c.AfterWrite(ctx, failed, "user:42"))
+	assert.EqualValues(t, 1, cli.Exists(ctx, "user:42").Val())
+
+	assert.NoError(t, c.Set(ctx, "user:7", user{ID: 7}, time.Hour))
+	assert.NoError(t, c.GetOrLoad(ctx, "user:7", time.Hour, &u, load))
+	assert.Equal(t, user{ID: 7}, u)
+	assert.Equal(t, 2, loads)
+}
+
+func TestGetOrLoad_Errors(t *testing.T) {
+	cli := redistest.NewRedis()
+	c := New(cli, WithNegativeTTL(time.Minute), WithJitter(0))
+
+	var loads int
+	notFound := func(ctx context.Context) (interface{}, error) {
+		loads++
+		return nil, ErrNotFound
+	}
+	var u user
+	assert.Equal(t, ErrNotFound, c.GetOrLoad(ctx, "user:1", time.Hour, &u, notFound))
+	assert.Equal(t, ErrNotFound, c.GetOrLoad(ctx, "user:1", time.Hour, &u, notFound))
+	assert.Equal(t, 1, loads)
+	assert.Equal(t, time.Minute, cli.TTL(ctx, "user:1").Val())
+
+	nilLoader := func(ctx context.Context) (interface{}, error) {
+		return nil, redis.Nil
+	}
+	assert.Equal(t, ErrNotFound, c.GetOrLoad(ctx, "user:2", time.Hour, &u, nilLoader))
+
+	failed := errors.New("mongo down")
+	failing := func(ctx context.Context) (interface{}, error) {
+		return nil, failed
+	}
+	assert.Equal(t, failed, c.GetOrLoad(ctx, "user:3", time.Hour, &u, failing))
+	assert.EqualValues(t, 0, cli.Exists(ctx, "user:3").Val())
+
+	noNegative := New(cli, WithNegativeTTL(0))
+	assert.Equal(t, ErrNotFound, noNegative.GetOrLoad(ctx, "user:4", time.Hour, &u, notFound))
+	assert.EqualValues(t, 0, cli.Exists(ctx, "user:4").Val())
+}
+
+func TestGetOrLoad_Singleflight(t *testing.T) {
+	c := New(redistest.NewRedis())
+
+	var loads int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return user{ID: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var u user
+			assert.NoError(t, c.GetOrLoad(ctx, "user:1", time.Hour, &u, load))
+			assert.Equal(t, user{ID: 1}, u)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&loads))
+}
+
+func TestGetOrLoad_LoaderPanic(t *testing.T) {
+	c := New(redistest.NewRedis())
+
+	release := make(chan struct{})
+	load := func(ctx context.Context) (interface{}, error) {
+		<-release
+		panic("boom")
+	}
+
+	var panics, errs int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					assert.Equal(t, "boom", r)
+					atomic.AddInt32(&panics, 1)
+				}
+			}()
+			var u user
+			err := c.GetOrLoad(ctx, "user:1", time.Hour, &u, load)
+			assert.Error(t, err)
+			assert.NotEqual(t, ErrNotFound, err)
+			atomic.AddInt32(&errs, 1)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.EqualValues(t, 1, atomic.LoadInt32(&panics))
+	assert.EqualValues(t, 9, atomic.LoadInt32(&errs))
+
+	// the key is loaded again by the next call
+	var u user
+	assert.NoError(t, c.GetOrLoad(ctx, "user:1", time.Hour, &u, func(ctx context.Context) (interface{}, error) {
+		return user{ID: 1}, nil
+	}))
+	assert.Equal(t, user{ID: 1}, u)
+}
+
+func TestJitter(t *testing.T) {
+	c := New(redistest.NewRedis(), WithJitter(0.5))
+	for i := 0; i < 100; i++ {
+		ttl := c.jittered(time.Minute)
+		assert.True(t, ttl >= time.Minute && ttl < 90*time.Second, ttl)
+	}
+	assert.Zero(t, c.jittered(0))
+}
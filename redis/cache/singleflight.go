@@ -0,0 +1,57 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package cache
+
+import (
+	"sync"
+
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// call is a load in progress or done.
+type call struct {
+	wg   sync.WaitGroup
+	data string
+	err  error
+}
+
+// group deduplicates the concurrent loads of a key.
+type group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do runs fn once for the concurrent calls with the same key, which all get its result. When
+// fn panics, the other calls get an error and the call running fn panics again.
+func (g *group) do(key string, fn func() (string, error)) (string, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.data, c.err
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		r := recover()
+		if r != nil {
+			c.data, c.err = "", cExceptions.InternalError("[cache] load of %s panicked: %v", key, r)
+		}
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	c.data, c.err = fn()
+	return c.data, c.err
+}
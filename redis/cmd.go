@@ -4,7 +4,7 @@
 package redis
 
 import (
-	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	"bytes"
 	"context"
 	"encoding/json"
 	"strconv"
@@ -22,6 +22,8 @@ type IRedis interface {
 	GetSet(ctx context.Context, key string, value interface{}) *StringCmd
 	Get(ctx context.Context, key string) *StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *StatusCmd
+	SetValue(ctx context.Context, key string, v interface{}, expiration time.Duration) *StatusCmd
+	GetInto(ctx context.Context, key string, dst interface{}) error
 	Del(ctx context.Context, keys ...string) *IntCmd
 	Exists(ctx context.Context, keys ...string) *IntCmd
 	Expire(ctx context.Context, key string, expiration time.Duration) *BoolCmd
@@ -44,6 +46,12 @@ type IRedis interface {
 	GetBit(ctx context.Context, key string, offset int64) *IntCmd
 	SetBit(ctx context.Context, key string, offset int64, value int) *IntCmd
 	BitCount(ctx context.Context, key string, bitCount *BitCountArgs) *IntCmd
+	BitOpAnd(ctx context.Context, destKey string, keys ...string) *IntCmd
+	BitOpOr(ctx context.Context, destKey string, keys ...string) *IntCmd
+	BitOpXor(ctx context.Context, destKey string, keys ...string) *IntCmd
+	BitOpNot(ctx context.Context, destKey string, key string) *IntCmd
+	BitPos(ctx context.Context, key string, bit int64, pos ...int64) *IntCmd
+	BitField(ctx context.Context, key string, ops *BitFieldArgs) *IntPointerSliceCmd
 	HDel(ctx context.Context, key string, fields ...string) *IntCmd
 	HExists(ctx context.Context, key, field string) *BoolCmd
 	HGet(ctx context.Context, key, field string) *StringCmd
@@ -55,6 +63,9 @@ type IRedis interface {
 	HMSet(ctx context.Context, key string, pairs ...interface{}) *StatusCmd
 	HMGet(ctx context.Context, key string, fields ...string) *SliceCmd
 	HSet(ctx context.Context, key string, field string, value interface{}) *BoolCmd
+	HSetStruct(ctx context.Context, key string, value interface{}) *IntCmd
+	HSetValue(ctx context.Context, key, field string, v interface{}) *BoolCmd
+	HGetInto(ctx context.Context, key, field string, dst interface{}) error
 	HSetNX(ctx context.Context, key, field string, value interface{}) *BoolCmd
 	HVals(ctx context.Context, key string) *StrSliceCmd
 	LIndex(ctx context.Context, key string, index int64) *StringCmd
@@ -62,14 +73,24 @@ type IRedis interface {
 	LLen(ctx context.Context, key string) *IntCmd
 	LPop(ctx context.Context, key string) *StringCmd
 	LPush(ctx context.Context, key string, values ...interface{}) *IntCmd
+	LPushValue(ctx context.Context, key string, values ...interface{}) *IntCmd
 	LPushX(ctx context.Context, key string, values ...interface{}) *IntCmd
 	LRange(ctx context.Context, key string, start, stop int64) *StrSliceCmd
+	LRangeInto(ctx context.Context, key string, start, stop int64, dst interface{}) error
 	LRem(ctx context.Context, key string, count int64, value interface{}) *IntCmd
 	LSet(ctx context.Context, key string, index int64, value interface{}) *StatusCmd
 	LTrim(ctx context.Context, key string, start, stop int64) *StatusCmd
 	RPop(ctx context.Context, key string) *StringCmd
 	RPush(ctx context.Context, key string, values ...interface{}) *IntCmd
+	RPushValue(ctx context.Context, key string, values ...interface{}) *IntCmd
 	RPushX(ctx context.Context, key string, values ...interface{}) *IntCmd
+	BLPop(ctx context.Context, timeout time.Duration, keys ...string) *StrSliceCmd
+	BRPop(ctx context.Context, timeout time.Duration, keys ...string) *StrSliceCmd
+	LMove(ctx context.Context, source, destination, srcpos, destpos string) *StringCmd
+	BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) *StringCmd
+	LPos(ctx context.Context, key string, value string, a LPosArgs) *IntCmd
+	LPosCount(ctx context.Context, key string, value string, count int64, a LPosArgs) *IntSliceCmd
+	LMPop(ctx context.Context, direction string, count int64, keys ...string) *KeyValuesCmd
 	SAdd(ctx context.Context, key string, members ...interface{}) *IntCmd
 	SCard(ctx context.Context, key string) *IntCmd
 	SDiff(ctx context.Context, keys ...string) *StrSliceCmd
@@ -114,9 +135,73 @@ type IRedis interface {
 	ZRevRank(ctx context.Context, key, member string) *IntCmd
 	ZScore(ctx context.Context, key, member string) *FloatCmd
 	ZUnionStore(ctx context.Context, dest string, store *ZStore) *IntCmd
+	ZPopMin(ctx context.Context, key string, count ...int64) *ZSliceCmd
+	ZPopMax(ctx context.Context, key string, count ...int64) *ZSliceCmd
+	BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) *ZWithKeyCmd
+	BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) *ZWithKeyCmd
+	ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) *StrSliceCmd
+	ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) *StrSliceCmd
+	ZLexCount(ctx context.Context, key, min, max string) *IntCmd
+	ZRemRangeByLex(ctx context.Context, key, min, max string) *IntCmd
+	ZRandMember(ctx context.Context, key string, count int) *StrSliceCmd
+	ZRandMemberWithScores(ctx context.Context, key string, count int) *ZSliceCmd
+	ZMScore(ctx context.Context, key string, members ...string) *FloatSliceCmd
+	ZRangeStore(ctx context.Context, dst string, z ZRangeArgs) *IntCmd
+	ZDiff(ctx context.Context, keys ...string) *StrSliceCmd
+	ZDiffWithScores(ctx context.Context, keys ...string) *ZSliceCmd
+	ZInter(ctx context.Context, store *ZStore) *StrSliceCmd
+	ZInterWithScores(ctx context.Context, store *ZStore) *ZSliceCmd
+	ZUnion(ctx context.Context, store *ZStore) *StrSliceCmd
+	ZUnionWithScores(ctx context.Context, store *ZStore) *ZSliceCmd
 	PFAdd(ctx context.Context, key string, els ...interface{}) *IntCmd
 	PFCount(ctx context.Context, keys ...string) *IntCmd
 	PFMerge(ctx context.Context, dest string, keys ...string) *StatusCmd
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *Cmd
+	ScriptExists(ctx context.Context, hashes ...string) *BoolSliceCmd
+	ScriptLoad(ctx context.Context, script string) *StringCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *ScanCmd
+	ScanType(ctx context.Context, cursor uint64, match string, count int64, keyType string) *ScanCmd
+	HScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd
+	SScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd
+	ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd
+	XAdd(ctx context.Context, a *XAddArgs) *StringCmd
+	XDel(ctx context.Context, stream string, ids ...string) *IntCmd
+	XLen(ctx context.Context, stream string) *IntCmd
+	XRange(ctx context.Context, stream, start, stop string) *XMessageSliceCmd
+	XRangeN(ctx context.Context, stream, start, stop string, count int64) *XMessageSliceCmd
+	XRevRange(ctx context.Context, stream, start, stop string) *XMessageSliceCmd
+	XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *XMessageSliceCmd
+	XRead(ctx context.Context, a *XReadArgs) *XStreamSliceCmd
+	XReadStreams(ctx context.Context, streams ...string) *XStreamSliceCmd
+	XTrimMaxLen(ctx context.Context, stream string, maxLen int64) *IntCmd
+	XTrimMaxLenApprox(ctx context.Context, stream string, maxLen, limit int64) *IntCmd
+	XTrimMinID(ctx context.Context, stream string, minID string) *IntCmd
+	XTrimMinIDApprox(ctx context.Context, stream string, minID string, limit int64) *IntCmd
+	XGroupCreate(ctx context.Context, stream, group, start string) *StatusCmd
+	XGroupCreateMkStream(ctx context.Context, stream, group, start string) *StatusCmd
+	XGroupSetID(ctx context.Context, stream, group, start string) *StatusCmd
+	XGroupDestroy(ctx context.Context, stream, group string) *IntCmd
+	XGroupDelConsumer(ctx context.Context, stream, group, consumer string) *IntCmd
+	XReadGroup(ctx context.Context, a *XReadGroupArgs) *XStreamSliceCmd
+	XAck(ctx context.Context, stream, group string, ids ...string) *IntCmd
+	XPending(ctx context.Context, stream, group string) *XPendingCmd
+	XPendingExt(ctx context.Context, a *XPendingExtArgs) *XPendingExtCmd
+	XClaim(ctx context.Context, a *XClaimArgs) *XMessageSliceCmd
+	XClaimJustID(ctx context.Context, a *XClaimArgs) *StrSliceCmd
+	GeoAdd(ctx context.Context, key string, geoLocation ...*GeoLocation) *IntCmd
+	GeoPos(ctx context.Context, key string, members ...string) *GeoPosCmd
+	GeoDist(ctx context.Context, key string, member1, member2, unit string) *FloatCmd
+	GeoHash(ctx context.Context, key string, members ...string) *StrSliceCmd
+	GeoRadius(ctx context.Context, key string, longitude, latitude float64, query *GeoRadiusQuery) *GeoLocationCmd
+	GeoRadiusStore(ctx context.Context, key string, longitude, latitude float64, query *GeoRadiusQuery) *IntCmd
+	GeoRadiusByMember(ctx context.Context, key, member string, query *GeoRadiusQuery) *GeoLocationCmd
+	GeoRadiusByMemberStore(ctx context.Context, key, member string, query *GeoRadiusQuery) *IntCmd
+	GeoSearch(ctx context.Context, key string, q *GeoSearchQuery) *StrSliceCmd
+	GeoSearchLocation(ctx context.Context, key string, q *GeoSearchLocationQuery) *GeoLocationCmd
+	GeoSearchStore(ctx context.Context, key, store string, q *GeoSearchStoreQuery) *IntCmd
+	Pipeline() Pipeliner
+	Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error)
 }
 
 const Nil = ErrorRedis("redis: nil")
@@ -141,20 +226,65 @@ type baseCmd struct {
 	args   []interface{}
 	err    error
 	result *result
+
+	// strip removes the key prefix of the client from the keys of the reply, see WithKeyPrefix.
+	strip func(prefix string)
+}
+
+// Cmder is implemented by all the commands, e.g. to read the results of a pipeline.
+type Cmder interface {
+	Name() string
+	Args() []interface{}
+	Err() error
+
+	base() *baseCmd
+}
+
+func (c *baseCmd) Name() string {
+	return c.name
+}
+
+func (c *baseCmd) Args() []interface{} {
+	return c.args
 }
 
 func (c *baseCmd) Err() error {
 	return c.err
 }
 
+func (c *baseCmd) base() *baseCmd {
+	return c
+}
+
 type redisArgumentList struct {
 	Cmd  string        `json:"cmd"`
 	Args []interface{} `json:"args"`
 }
 
+func (c *baseCmd) argumentList() redisArgumentList {
+	if prefix := c.keyPrefix(); prefix != "" {
+		return redisArgumentList{Cmd: c.name, Args: prefixArgs(c.name, c.args, prefix)}
+	}
+	return redisArgumentList{Cmd: c.name, Args: c.args}
+}
+
+func (c *baseCmd) keyPrefix() string {
+	if c.client == nil {
+		return ""
+	}
+	return c.client.keyPrefix
+}
+
 // RedisCmdExecution Request
 func (c *baseCmd) request(ctx context.Context) {
-	data, extra, e := faasinfra.DoRequestRedis(ctx, redisArgumentList{Cmd: c.name, Args: c.args})
+	ctx, cancel := c.client.withApiTimeout(ctx)
+	defer cancel()
+	data, extra, e := c.client.getTransport().DoRequestRedis(ctx, c.argumentList())
+	c.readReply(data, extra, e)
+}
+
+// readReply fills the command with the response of its request.
+func (c *baseCmd) readReply(data []byte, extra map[string]interface{}, e error) {
 	if e != nil {
 		c.err = cExceptions.ErrWrap(e)
 		return
@@ -174,6 +304,10 @@ func (c *baseCmd) request(ctx context.Context) {
 		c.err = Nil
 		return
 	}
+
+	if prefix := c.keyPrefix(); prefix != "" && c.strip != nil {
+		c.strip(prefix)
+	}
 }
 
 type StringCmd struct {
@@ -345,6 +479,29 @@ func (c *SliceCmd) Result() ([]interface{}, error) {
 	return c.val, c.err
 }
 
+// Scan sets the fields of the struct dst points to from the values of HMGet or MGet, matching
+// the fields or keys asked for with the `redis:"name"` tags of the struct fields. The fields
+// or keys that do not exist leave their struct field untouched.
+func (c *SliceCmd) Scan(dst interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	var args []interface{}
+	switch c.name {
+	case "hmget":
+		args = c.args[1:]
+	case "mget":
+		args = c.args
+	default:
+		return cExceptions.InvalidParamError("[Redis] Scan does not support the %s command", c.name)
+	}
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i], _ = arg.(string)
+	}
+	return scanStruct(dst, names, c.val)
+}
+
 type FloatCmd struct {
 	baseCmd
 	val float64
@@ -380,6 +537,25 @@ type BoolCmd struct {
 	val bool
 }
 
+// boolReply decodes the integer, status or boolean replies of the commands returning a BoolCmd.
+type boolReply bool
+
+func (b *boolReply) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch val := v.(type) {
+	case bool:
+		*b = boolReply(val)
+	case float64:
+		*b = val != 0
+	case string:
+		*b = val != ""
+	}
+	return nil
+}
+
 func NewBoolCmd(client *Redis, name string, args ...interface{}) *BoolCmd {
 	cmd := &BoolCmd{
 		baseCmd: baseCmd{
@@ -389,7 +565,7 @@ func NewBoolCmd(client *Redis, name string, args ...interface{}) *BoolCmd {
 			result: &result{},
 		},
 	}
-	cmd.result.bind(&cmd.val)
+	cmd.result.bind((*boolReply)(&cmd.val))
 	return cmd
 }
 
@@ -427,6 +603,23 @@ func (c *StrStrMapCmd) Result() (map[string]string, error) {
 	return c.val, c.err
 }
 
+// Scan sets the fields of the struct dst points to from the hash returned by HGetAll, matching
+// the hash fields with the `redis:"name"` tags of the struct fields. Strings are converted to
+// the types of the struct fields: integers, floats, booleans, []byte, time.Duration, and the
+// types implementing encoding.TextUnmarshaler such as time.Time.
+func (c *StrStrMapCmd) Scan(dst interface{}) error {
+	if c.err != nil {
+		return c.err
+	}
+	names := make([]string, 0, len(c.val))
+	vals := make([]interface{}, 0, len(c.val))
+	for name, val := range c.val {
+		names = append(names, name)
+		vals = append(vals, val)
+	}
+	return scanStruct(dst, names, vals)
+}
+
 type StrSliceCmd struct {
 	baseCmd
 	val []string
@@ -442,6 +635,14 @@ func NewStrSliceCmd(client *Redis, name string, args ...interface{}) *StrSliceCm
 		},
 	}
 	cmd.result.bind(&cmd.val)
+	if name == "blpop" || name == "brpop" {
+		// The reply is the key the element was popped from, and the element.
+		cmd.strip = func(prefix string) {
+			if len(cmd.val) > 0 {
+				stripPrefix(&cmd.val[0], prefix)
+			}
+		}
+	}
 	return cmd
 }
 
@@ -478,3 +679,615 @@ func (c *ZSliceCmd) Val() []Z {
 func (c *ZSliceCmd) Result() ([]Z, error) {
 	return c.val, c.err
 }
+
+type BoolSliceCmd struct {
+	baseCmd
+	val []bool
+}
+
+func NewBoolSliceCmd(client *Redis, name string, args ...interface{}) *BoolSliceCmd {
+	cmd := &BoolSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind((*boolSliceReply)(&cmd.val))
+	return cmd
+}
+
+func (c *BoolSliceCmd) Val() []bool {
+	return c.val
+}
+
+func (c *BoolSliceCmd) Result() ([]bool, error) {
+	return c.val, c.err
+}
+
+type boolSliceReply []bool
+
+func (b *boolSliceReply) UnmarshalJSON(data []byte) error {
+	var replies []boolReply
+	if err := json.Unmarshal(data, &replies); err != nil {
+		return err
+	}
+	*b = make([]bool, len(replies))
+	for i, reply := range replies {
+		(*b)[i] = bool(reply)
+	}
+	return nil
+}
+
+// Cmd holds the reply of a command whose reply type is not known in advance, e.g. of a
+// script: nil, an int64, a string or a []interface{} of these.
+type Cmd struct {
+	baseCmd
+	val interface{}
+}
+
+// anyReply decodes integers as int64 rather than float64.
+type anyReply struct {
+	val *interface{}
+}
+
+func (r *anyReply) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return err
+	}
+	*r.val = convertNumbers(v)
+	return nil
+}
+
+func convertNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	case []interface{}:
+		for i, item := range val {
+			val[i] = convertNumbers(item)
+		}
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = convertNumbers(item)
+		}
+	}
+	return v
+}
+
+func NewCmd(client *Redis, name string, args ...interface{}) *Cmd {
+	cmd := &Cmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&anyReply{val: &cmd.val})
+	return cmd
+}
+
+func (c *Cmd) Val() interface{} {
+	return c.val
+}
+
+func (c *Cmd) Result() (interface{}, error) {
+	return c.val, c.err
+}
+
+func (c *Cmd) Text() (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	return toString(c.val)
+}
+
+func (c *Cmd) Int() (int, error) {
+	n, err := c.Int64()
+	return int(n), err
+}
+
+func (c *Cmd) Int64() (int64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	return toInt64(c.val)
+}
+
+func (c *Cmd) Uint64() (uint64, error) {
+	n, err := c.Int64()
+	return uint64(n), err
+}
+
+func (c *Cmd) Float64() (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	switch val := c.val.(type) {
+	case int64:
+		return float64(val), nil
+	case float64:
+		return val, nil
+	case string:
+		return strconv.ParseFloat(val, 64)
+	}
+	return 0, cExceptions.InternalError("[Redis] unexpected type %T for Float64", c.val)
+}
+
+func (c *Cmd) Bool() (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	switch val := c.val.(type) {
+	case int64:
+		return val != 0, nil
+	case string:
+		return strconv.ParseBool(val)
+	case bool:
+		return val, nil
+	}
+	return false, cExceptions.InternalError("[Redis] unexpected type %T for Bool", c.val)
+}
+
+func (c *Cmd) Slice() ([]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	val, ok := c.val.([]interface{})
+	if !ok {
+		return nil, cExceptions.InternalError("[Redis] unexpected type %T for Slice", c.val)
+	}
+	return val, nil
+}
+
+func (c *Cmd) StringSlice() ([]string, error) {
+	items, err := c.Slice()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(items))
+	for i, item := range items {
+		if res[i], err = toString(item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (c *Cmd) Int64Slice() ([]int64, error) {
+	items, err := c.Slice()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]int64, len(items))
+	for i, item := range items {
+		if res[i], err = toInt64(item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func toString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	}
+	return "", cExceptions.InternalError("[Redis] unexpected type %T for string", v)
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case float64:
+		return int64(val), nil
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	}
+	return 0, cExceptions.InternalError("[Redis] unexpected type %T for int64", v)
+}
+
+// ScanCmd holds a page of the keys or elements enumerated by a SCAN command, and the cursor
+// to send to get the next page, 0 once the enumeration is over.
+type ScanCmd struct {
+	baseCmd
+	page   []string
+	cursor uint64
+}
+
+// scanReply decodes the [cursor, elements] replies of the SCAN commands, the cursor being
+// a string or a number.
+type scanReply struct {
+	page   *[]string
+	cursor *uint64
+}
+
+func (r *scanReply) UnmarshalJSON(data []byte) error {
+	var reply []json.RawMessage
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return err
+	}
+	if len(reply) != 2 {
+		return cExceptions.InternalError("[Redis] scan got a reply of %d elements, expected 2", len(reply))
+	}
+	var cursor json.Number
+	if err := json.Unmarshal(bytes.Trim(reply[0], `"`), &cursor); err != nil {
+		return err
+	}
+	n, err := strconv.ParseUint(cursor.String(), 10, 64)
+	if err != nil {
+		return err
+	}
+	*r.cursor = n
+	return json.Unmarshal(reply[1], r.page)
+}
+
+func NewScanCmd(client *Redis, name string, args ...interface{}) *ScanCmd {
+	cmd := &ScanCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&scanReply{page: &cmd.page, cursor: &cmd.cursor})
+	if name == "scan" {
+		cmd.strip = func(prefix string) {
+			for i := range cmd.page {
+				stripPrefix(&cmd.page[i], prefix)
+			}
+		}
+	}
+	return cmd
+}
+
+func (c *ScanCmd) Val() (keys []string, cursor uint64) {
+	return c.page, c.cursor
+}
+
+func (c *ScanCmd) Result() (keys []string, cursor uint64, err error) {
+	return c.page, c.cursor, c.err
+}
+
+// Iterator returns an iterator over the elements of this page and of the next ones.
+func (c *ScanCmd) Iterator() *ScanIterator {
+	return &ScanIterator{cmd: c}
+}
+
+type XMessageSliceCmd struct {
+	baseCmd
+	val []XMessage
+}
+
+func NewXMessageSliceCmd(client *Redis, name string, args ...interface{}) *XMessageSliceCmd {
+	cmd := &XMessageSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *XMessageSliceCmd) Val() []XMessage {
+	return c.val
+}
+
+func (c *XMessageSliceCmd) Result() ([]XMessage, error) {
+	return c.val, c.err
+}
+
+type XStreamSliceCmd struct {
+	baseCmd
+	val []XStream
+}
+
+func NewXStreamSliceCmd(client *Redis, name string, args ...interface{}) *XStreamSliceCmd {
+	cmd := &XStreamSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	cmd.strip = func(prefix string) {
+		for i := range cmd.val {
+			stripPrefix(&cmd.val[i].Stream, prefix)
+		}
+	}
+	return cmd
+}
+
+func (c *XStreamSliceCmd) Val() []XStream {
+	return c.val
+}
+
+func (c *XStreamSliceCmd) Result() ([]XStream, error) {
+	return c.val, c.err
+}
+
+type XPendingCmd struct {
+	baseCmd
+	val *XPending
+}
+
+func NewXPendingCmd(client *Redis, name string, args ...interface{}) *XPendingCmd {
+	cmd := &XPendingCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *XPendingCmd) Val() *XPending {
+	return c.val
+}
+
+func (c *XPendingCmd) Result() (*XPending, error) {
+	return c.val, c.err
+}
+
+type XPendingExtCmd struct {
+	baseCmd
+	val []XPendingExt
+}
+
+func NewXPendingExtCmd(client *Redis, name string, args ...interface{}) *XPendingExtCmd {
+	cmd := &XPendingExtCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *XPendingExtCmd) Val() []XPendingExt {
+	return c.val
+}
+
+func (c *XPendingExtCmd) Result() ([]XPendingExt, error) {
+	return c.val, c.err
+}
+
+type GeoLocationCmd struct {
+	baseCmd
+	val []GeoLocation
+}
+
+func NewGeoLocationCmd(client *Redis, name string, args ...interface{}) *GeoLocationCmd {
+	cmd := &GeoLocationCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *GeoLocationCmd) Val() []GeoLocation {
+	return c.val
+}
+
+func (c *GeoLocationCmd) Result() ([]GeoLocation, error) {
+	return c.val, c.err
+}
+
+type GeoPosCmd struct {
+	baseCmd
+	val []*GeoPos
+}
+
+func NewGeoPosCmd(client *Redis, name string, args ...interface{}) *GeoPosCmd {
+	cmd := &GeoPosCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *GeoPosCmd) Val() []*GeoPos {
+	return c.val
+}
+
+func (c *GeoPosCmd) Result() ([]*GeoPos, error) {
+	return c.val, c.err
+}
+
+type IntSliceCmd struct {
+	baseCmd
+	val []int64
+}
+
+func NewIntSliceCmd(client *Redis, name string, args ...interface{}) *IntSliceCmd {
+	cmd := &IntSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *IntSliceCmd) Val() []int64 {
+	return c.val
+}
+
+func (c *IntSliceCmd) Result() ([]int64, error) {
+	return c.val, c.err
+}
+
+// IntPointerSliceCmd is an IntSliceCmd whose values are nil where redis replies with a nil.
+type IntPointerSliceCmd struct {
+	baseCmd
+	val []*int64
+}
+
+func NewIntPointerSliceCmd(client *Redis, name string, args ...interface{}) *IntPointerSliceCmd {
+	cmd := &IntPointerSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *IntPointerSliceCmd) Val() []*int64 {
+	return c.val
+}
+
+func (c *IntPointerSliceCmd) Result() ([]*int64, error) {
+	return c.val, c.err
+}
+
+type FloatSliceCmd struct {
+	baseCmd
+	val []float64
+}
+
+func NewFloatSliceCmd(client *Redis, name string, args ...interface{}) *FloatSliceCmd {
+	cmd := &FloatSliceCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	return cmd
+}
+
+func (c *FloatSliceCmd) Val() []float64 {
+	return c.val
+}
+
+func (c *FloatSliceCmd) Result() ([]float64, error) {
+	return c.val, c.err
+}
+
+type ZWithKeyCmd struct {
+	baseCmd
+	// val is not a pointer, for a null reply to leave the result unbound and become Nil.
+	val ZWithKey
+}
+
+func NewZWithKeyCmd(client *Redis, name string, args ...interface{}) *ZWithKeyCmd {
+	cmd := &ZWithKeyCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.val)
+	cmd.strip = func(prefix string) {
+		stripPrefix(&cmd.val.Key, prefix)
+	}
+	return cmd
+}
+
+// Val returns the popped member, nil when the command failed or timed out.
+func (c *ZWithKeyCmd) Val() *ZWithKey {
+	if c.err != nil {
+		return nil
+	}
+	return &c.val
+}
+
+func (c *ZWithKeyCmd) Result() (*ZWithKey, error) {
+	return c.Val(), c.err
+}
+
+// KeyValuesCmd is the reply of LMPop: the key the values were popped from, and the values.
+type KeyValuesCmd struct {
+	baseCmd
+	key string
+	val []string
+}
+
+// keyValuesReply decodes the [key, values] replies of LMPOP.
+type keyValuesReply struct {
+	key *string
+	val *[]string
+}
+
+func (r *keyValuesReply) UnmarshalJSON(data []byte) error {
+	var reply []json.RawMessage
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return err
+	}
+	if len(reply) != 2 {
+		return cExceptions.InternalError("[Redis] lmpop got a reply of %d elements, expected 2", len(reply))
+	}
+	if err := json.Unmarshal(reply[0], r.key); err != nil {
+		return err
+	}
+	return json.Unmarshal(reply[1], r.val)
+}
+
+func NewKeyValuesCmd(client *Redis, name string, args ...interface{}) *KeyValuesCmd {
+	cmd := &KeyValuesCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   name,
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&keyValuesReply{key: &cmd.key, val: &cmd.val})
+	cmd.strip = func(prefix string) {
+		stripPrefix(&cmd.key, prefix)
+	}
+	return cmd
+}
+
+func (c *KeyValuesCmd) Val() (string, []string) {
+	return c.key, c.val
+}
+
+func (c *KeyValuesCmd) Result() (string, []string, error) {
+	return c.key, c.val, c.err
+}
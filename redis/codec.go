@@ -0,0 +1,138 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes the values of SetValue, HSetValue, LPushValue and RPushValue, and decodes them
+// for GetInto, HGetInto and LRangeInto. Values travel as JSON strings, so a codec must produce
+// valid UTF-8: binary codecs are wrapped in a Base64Codec, as MsgpackCodec is.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec encodes values as JSON. It is the codec of a Redis by default.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Base64Codec encodes the output of Codec in base64, for binary codecs.
+type Base64Codec struct {
+	Codec Codec
+}
+
+func (c Base64Codec) Marshal(v interface{}) ([]byte, error) {
+	data, err := c.Codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(res, data)
+	return res, nil
+}
+
+func (c Base64Codec) Unmarshal(data []byte, v interface{}) error {
+	raw := make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+	n, err := base64.StdEncoding.Decode(raw, data)
+	if err != nil {
+		return err
+	}
+	return c.Codec.Unmarshal(raw[:n], v)
+}
+
+// MsgpackCodec encodes values as msgpack, which is more compact than JSON, in base64. Struct
+// fields are named by their msgpack tags.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return Base64Codec{Codec: msgpackCodec{}}.Marshal(v)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return Base64Codec{Codec: msgpackCodec{}}.Unmarshal(data, v)
+}
+
+// msgpackCodec encodes values as binary msgpack.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+// gzipPrefix starts the values compressed by GzipCodec. Neither JSON nor base64 start with it.
+const gzipPrefix = "gz:"
+
+// GzipCodec compresses the output of Codec, JSONCodec when nil, once it is MinSize bytes or
+// more, e.g. to keep large cached objects under the payload limits. Compressed values are
+// stored in base64 after a "gz:" prefix, and the smaller ones as Codec encodes them, so that
+// values written before compression was enabled are still read. Codec must not produce
+// values starting with "gz:".
+type GzipCodec struct {
+	Codec   Codec
+	MinSize int
+}
+
+func (c GzipCodec) codec() Codec {
+	if c.Codec == nil {
+		return JSONCodec{}
+	}
+	return c.Codec
+}
+
+func (c GzipCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := c.codec().Marshal(v)
+	if err != nil || len(data) < c.MinSize {
+		return data, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(gzipPrefix)
+	b64 := base64.NewEncoder(base64.StdEncoding, &buf)
+	zw := gzip.NewWriter(b64)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if err := b64.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c GzipCodec) Unmarshal(data []byte, v interface{}) error {
+	if !bytes.HasPrefix(data, []byte(gzipPrefix)) {
+		return c.codec().Unmarshal(data, v)
+	}
+	zr, err := gzip.NewReader(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(data[len(gzipPrefix):])))
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return err
+	}
+	return c.codec().Unmarshal(raw, v)
+}
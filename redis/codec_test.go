@@ -0,0 +1,120 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+)
+
+type order struct {
+	ID    int64    `json:"id" msgpack:"id"`
+	Items []string `json:"items" msgpack:"items"`
+}
+
+func TestCodec(t *testing.T) {
+	ctx := context.Background()
+	cli := redistest.NewRedis()
+
+	in := order{ID: 42, Items: []string{"a", "b"}}
+	assert.NoError(t, cli.SetValue(ctx, "order", in, time.Minute).Err())
+	assert.Equal(t, `{"id":42,"items":["a","b"]}`, cli.Get(ctx, "order").Val())
+	var out order
+	assert.NoError(t, cli.GetInto(ctx, "order", &out))
+	assert.Equal(t, in, out)
+	assert.Equal(t, redis.Nil, cli.GetInto(ctx, "missing", &out))
+
+	assert.NoError(t, cli.HSetValue(ctx, "orders", "42", in).Err())
+	out = order{}
+	assert.NoError(t, cli.HGetInto(ctx, "orders", "42", &out))
+	assert.Equal(t, in, out)
+
+	assert.Error(t, cli.SetValue(ctx, "bad", make(chan int), 0).Err())
+	cli.Set(ctx, "bad", "not json", 0)
+	assert.Error(t, cli.GetInto(ctx, "bad", &out))
+
+	_, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		assert.Error(t, p.GetInto(ctx, "order", &out))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestCodec_List(t *testing.T) {
+	ctx := context.Background()
+	cli := redistest.NewRedis()
+
+	assert.EqualValues(t, 2, cli.RPushValue(ctx, "orders", order{ID: 1}, order{ID: 2, Items: []string{"a"}}).Val())
+	assert.EqualValues(t, 3, cli.LPushValue(ctx, "orders", order{ID: 0}).Val())
+	assert.Equal(t, `{"id":1,"items":null}`, cli.LIndex(ctx, "orders", 1).Val())
+	var out []order
+	assert.NoError(t, cli.LRangeInto(ctx, "orders", 0, -1, &out))
+	assert.Equal(t, []order{{ID: 0}, {ID: 1}, {ID: 2, Items: []string{"a"}}}, out)
+	assert.NoError(t, cli.LRangeInto(ctx, "missing", 0, -1, &out))
+	assert.Empty(t, out)
+
+	// a slice is a single value
+	cli.RPushValue(ctx, "ids", []int{1, 2})
+	assert.Equal(t, []string{"[1,2]"}, cli.LRange(ctx, "ids", 0, -1).Val())
+
+	assert.Error(t, cli.RPushValue(ctx, "orders", make(chan int)).Err())
+	assert.EqualValues(t, 3, cli.LLen(ctx, "orders").Val())
+	assert.Error(t, cli.LRangeInto(ctx, "orders", 0, -1, out))
+	cli.RPush(ctx, "orders", "not json")
+	assert.Error(t, cli.LRangeInto(ctx, "orders", 0, -1, &out))
+}
+
+func TestMsgpackCodec(t *testing.T) {
+	ctx := context.Background()
+	cli := redis.NewRedis(redis.WithTransport(redistest.NewServer()), redis.WithCodec(redis.MsgpackCodec{}))
+
+	in := order{ID: 42, Items: []string{"a", "b"}}
+	assert.NoError(t, cli.SetValue(ctx, "order", in, 0).Err())
+	assert.NotContains(t, cli.Get(ctx, "order").Val(), "items")
+	var out order
+	assert.NoError(t, cli.GetInto(ctx, "order", &out))
+	assert.Equal(t, in, out)
+
+	cli.RPushValue(ctx, "orders", in)
+	var list []order
+	assert.NoError(t, cli.LRangeInto(ctx, "orders", 0, -1, &list))
+	assert.Equal(t, []order{in}, list)
+}
+
+func TestGzipCodec(t *testing.T) {
+	ctx := context.Background()
+	codec := redis.GzipCodec{MinSize: 64}
+	cli := redis.NewRedis(redis.WithTransport(redistest.NewServer()), redis.WithCodec(codec))
+
+	small := order{ID: 1}
+	cli.SetValue(ctx, "small", small, 0)
+	assert.Equal(t, `{"id":1,"items":null}`, cli.Get(ctx, "small").Val())
+
+	large := order{ID: 2, Items: []string{strings.Repeat("item", 100)}}
+	cli.SetValue(ctx, "large", large, 0)
+	stored := cli.Get(ctx, "large").Val()
+	assert.True(t, strings.HasPrefix(stored, "gz:"), stored)
+	assert.Less(t, len(stored), 200)
+
+	var out order
+	assert.NoError(t, cli.GetInto(ctx, "small", &out))
+	assert.Equal(t, small, out)
+	assert.NoError(t, cli.GetInto(ctx, "large", &out))
+	assert.Equal(t, large, out)
+
+	b64 := redis.Base64Codec{Codec: redis.JSONCodec{}}
+	data, err := b64.Marshal(small)
+	assert.NoError(t, err)
+	assert.Equal(t, "eyJpZCI6MSwiaXRlbXMiOm51bGx9", string(data))
+	out = order{}
+	assert.NoError(t, b64.Unmarshal(data, &out))
+	assert.Equal(t, small, out)
+}
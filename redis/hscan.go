@@ -0,0 +1,212 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structField is a struct field tagged `redis:"name"`, or `redis:"name,omitempty"` to skip
+// its zero value when the struct is written.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields caches the tagged fields of the struct types, by type.
+var structFields sync.Map
+
+func fieldsOf(t reflect.Type) []structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]structField)
+	}
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("redis")
+		if tag == "" || tag == "-" || f.PkgPath != "" {
+			continue
+		}
+		opts := strings.Split(tag, ",")
+		fields = append(fields, structField{name: opts[0], index: f.Index, omitEmpty: len(opts) > 1 && opts[1] == "omitempty"})
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// structValue returns the struct dst points to.
+func structValue(dst interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, cExceptions.InvalidParamError("[Redis] Scan expects a non-nil pointer to a struct, got %T", dst)
+	}
+	return v.Elem(), nil
+}
+
+// scanStruct sets the fields of dst tagged with the names of fields to the matching values.
+// The nil values, of the fields or keys that do not exist, leave their field untouched.
+func scanStruct(dst interface{}, names []string, vals []interface{}) error {
+	v, err := structValue(dst)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]structField)
+	for _, f := range fieldsOf(v.Type()) {
+		byName[f.name] = f
+	}
+	for i, name := range names {
+		f, ok := byName[name]
+		if !ok || vals[i] == nil {
+			continue
+		}
+		s, err := toString(vals[i])
+		if err != nil {
+			return err
+		}
+		if err := setField(v.FieldByIndex(f.index), s); err != nil {
+			return cExceptions.InvalidParamError("[Redis] Scan field %s failed, err: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		// Durations are written as "1m30s", but integers of nanoseconds are accepted too.
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			v.SetInt(n)
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// structArgs flattens the tagged fields of the struct src, or src points to, to field and
+// value arguments, in the format scanStruct reads back. Nil pointers are skipped.
+func structArgs(src interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, cExceptions.InvalidParamError("[Redis] expects a struct or a pointer to a struct, got %T", src)
+	}
+	var args []interface{}
+	for _, f := range fieldsOf(v.Type()) {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		s, err := formatField(fv)
+		if err != nil {
+			return nil, cExceptions.InvalidParamError("[Redis] format field %s failed, err: %v", f.name, err)
+		}
+		args = append(args, f.name, s)
+	}
+	return args, nil
+}
+
+func formatField(v reflect.Value) (string, error) {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String(), nil
+	case v.Type().Implements(textMarshalerType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return "", fmt.Errorf("unsupported type %s", v.Type())
+		}
+		return string(v.Bytes()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"context"
+	"sync"
+)
+
+// ScanIterator enumerates the elements of a SCAN command, following its cursor.
+// HScan and ZScan pages alternate fields and values, members and scores.
+//
+//	iter := cli.Scan(ctx, 0, "user:*", 100).Iterator()
+//	for iter.Next(ctx) {
+//		fmt.Println(iter.Val())
+//	}
+//	if err := iter.Err(); err != nil {
+//		return err
+//	}
+type ScanIterator struct {
+	mu  sync.Mutex
+	cmd *ScanCmd
+	pos int
+}
+
+// Err returns the error of the last request, or the error of the context given to Next.
+func (it *ScanIterator) Err() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.cmd.Err()
+}
+
+// Next advances to the next element, requesting the next page when needed. It returns false
+// when there are no elements left, on error, or once ctx is done.
+func (it *ScanIterator) Next(ctx context.Context) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	if it.cmd.Err() != nil {
+		return false
+	}
+	for {
+		if it.pos < len(it.cmd.page) {
+			it.pos++
+			return true
+		}
+		if it.cmd.cursor == 0 {
+			return false
+		}
+		if err := ctx.Err(); err != nil {
+			it.cmd.err = err
+			return false
+		}
+
+		args := make([]interface{}, len(it.cmd.args))
+		copy(args, it.cmd.args)
+		if it.cmd.name == "scan" {
+			args[0] = it.cmd.cursor
+		} else {
+			args[1] = it.cmd.cursor
+		}
+		next := NewScanCmd(it.cmd.client, it.cmd.name, args...)
+		next.request(ctx)
+		it.cmd, it.pos = next, 0
+		if next.Err() != nil {
+			return false
+		}
+	}
+}
+
+// Val returns the element Next advanced to.
+func (it *ScanIterator) Val() string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.pos == 0 || it.pos > len(it.cmd.page) {
+		return ""
+	}
+	return it.cmd.page[it.pos-1]
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keySpec returns the indexes of the key arguments of the command name, for WithKeyPrefix.
+type keySpec func(args []interface{}) []int
+
+var (
+	noKeys   keySpec = func(args []interface{}) []int { return nil }
+	firstKey keySpec = func(args []interface{}) []int { return keyRange(0, 1, len(args)) }
+	allKeys  keySpec = func(args []interface{}) []int { return keyRange(0, len(args), len(args)) }
+	// firstKeys returns the first n arguments, e.g. the source and destination of LMOVE.
+	firstKeys = func(n int) keySpec {
+		return func(args []interface{}) []int { return keyRange(0, n, len(args)) }
+	}
+	// numKeys returns the keys counted by the numkeys argument at index i.
+	numKeys = func(i int) keySpec {
+		return func(args []interface{}) []int {
+			if i >= len(args) {
+				return nil
+			}
+			n, ok := args[i].(int)
+			if !ok {
+				return nil
+			}
+			return keyRange(i+1, i+1+n, len(args))
+		}
+	}
+)
+
+// keySpecs lists the commands whose keys are not only their first argument.
+var keySpecs = map[string]keySpec{
+	"script":  noKeys,
+	"unwatch": noKeys,
+	"exec":    noKeys,
+
+	"del":         allKeys,
+	"exists":      allKeys,
+	"mget":        allKeys,
+	"watch":       allKeys,
+	"pfcount":     allKeys,
+	"pfmerge":     allKeys,
+	"sdiff":       allKeys,
+	"sdiffstore":  allKeys,
+	"sinter":      allKeys,
+	"sinterstore": allKeys,
+	"sunion":      allKeys,
+	"sunionstore": allKeys,
+
+	"smove":          firstKeys(2),
+	"lmove":          firstKeys(2),
+	"blmove":         firstKeys(2),
+	"zrangestore":    firstKeys(2),
+	"geosearchstore": firstKeys(2),
+
+	"blpop":    allButLast,
+	"brpop":    allButLast,
+	"bzpopmin": allButLast,
+	"bzpopmax": allButLast,
+
+	"zinter":      numKeys(0),
+	"zunion":      numKeys(0),
+	"zdiff":       numKeys(0),
+	"lmpop":       numKeys(0),
+	"eval":        numKeys(1),
+	"evalsha":     numKeys(1),
+	"zinterstore": func(args []interface{}) []int { return append([]int{0}, numKeys(1)(args)...) },
+	"zunionstore": func(args []interface{}) []int { return append([]int{0}, numKeys(1)(args)...) },
+
+	"bitop":  func(args []interface{}) []int { return keyRange(1, len(args), len(args)) },
+	"xgroup": func(args []interface{}) []int { return keyRange(1, 2, len(args)) },
+	"mset": func(args []interface{}) []int {
+		var res []int
+		for i := 0; i < len(args); i += 2 {
+			res = append(res, i)
+		}
+		return res
+	},
+	"xread":             streamKeys,
+	"xreadgroup":        streamKeys,
+	"georadius":         storeKeys(5),
+	"georadiusbymember": storeKeys(4),
+}
+
+func keyRange(from, to, n int) []int {
+	if to > n {
+		to = n
+	}
+	var res []int
+	for i := from; i < to; i++ {
+		res = append(res, i)
+	}
+	return res
+}
+
+// allButLast returns all the arguments but the timeout of the blocking commands.
+func allButLast(args []interface{}) []int {
+	return keyRange(0, len(args)-1, len(args))
+}
+
+// streamKeys returns the streams following the STREAMS argument of XREAD and XREADGROUP,
+// which are followed by as many IDs.
+func streamKeys(args []interface{}) []int {
+	for i, arg := range args {
+		if s, ok := arg.(string); ok && strings.EqualFold(s, "streams") {
+			n := (len(args) - i - 1) / 2
+			return keyRange(i+1, i+1+n, len(args))
+		}
+	}
+	return nil
+}
+
+// storeKeys returns the key of GEORADIUS and the keys following its STORE and STOREDIST
+// options, which start at index from.
+func storeKeys(from int) keySpec {
+	return func(args []interface{}) []int {
+		res := []int{0}
+		for i := from; i+1 < len(args); i++ {
+			if s, ok := args[i].(string); ok && (strings.EqualFold(s, "store") || strings.EqualFold(s, "storedist")) {
+				res = append(res, i+1)
+			}
+		}
+		return res
+	}
+}
+
+// prefixArgs returns a copy of the arguments of the command name with prefix added to its
+// keys. The pattern of SCAN is prefixed too, for it to only match the keys of the prefix.
+// The pairs of MSET, which may be a map or a slice, are flattened first, as they are sent.
+func prefixArgs(name string, args []interface{}, prefix string) []interface{} {
+	if name == "mset" {
+		args = appendArgs(nil, args)
+	}
+	res := make([]interface{}, len(args))
+	copy(res, args)
+
+	if name == "scan" {
+		return prefixScanArgs(res, prefix)
+	}
+
+	spec, ok := keySpecs[name]
+	if !ok {
+		spec = firstKey
+	}
+	for _, i := range spec(args) {
+		if key, ok := res[i].(string); ok {
+			res[i] = prefix + key
+		}
+	}
+	return res
+}
+
+// prefixScanArgs restricts the SCAN pattern to the keys starting with prefix.
+func prefixScanArgs(args []interface{}, prefix string) []interface{} {
+	for i := 1; i+1 < len(args); i++ {
+		if s, ok := args[i].(string); ok && strings.EqualFold(s, "match") {
+			if match, ok := args[i+1].(string); ok {
+				args[i+1] = escapeGlob(prefix) + match
+				return args
+			}
+		}
+	}
+	return append(args, "match", escapeGlob(prefix)+"*")
+}
+
+// escapeGlob escapes the characters of s that are special in redis patterns.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// cmdKeys returns the keys of the command name, or nil for the commands working on the whole
+// keyspace or on no key, such as SCAN or SCRIPT.
+func cmdKeys(name string, args []interface{}) []string {
+	if name == "scan" {
+		return nil
+	}
+	if name == "mset" {
+		args = appendArgs(nil, args)
+	}
+
+	spec, ok := keySpecs[name]
+	if !ok {
+		spec = firstKey
+	}
+	var keys []string
+	for _, i := range spec(args) {
+		keys = append(keys, fmt.Sprint(args[i]))
+	}
+	return keys
+}
+
+func stripPrefix(s *string, prefix string) {
+	*s = strings.TrimPrefix(*s, prefix)
+}
@@ -0,0 +1,113 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+)
+
+func TestKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	s := redistest.NewServer()
+	raw := redis.NewRedis(redis.WithTransport(s))
+	cli := redis.NewRedis(redis.WithTransport(s), redis.WithKeyPrefix("svc:"))
+
+	assert.NoError(t, cli.Set(ctx, "a", "1", 0).Err())
+	assert.NoError(t, cli.MSet(ctx, "b", "2", "c", "3").Err())
+	assert.NoError(t, cli.MSet(ctx, map[string]interface{}{"d": "4"}).Err())
+	assert.NoError(t, cli.MSet(ctx, []string{"e", "5"}).Err())
+	assert.NoError(t, cli.MSet(ctx, []interface{}{"f", "6", "g", "7"}).Err())
+	assert.NoError(t, cli.MSet(ctx, "h", "8").Err())
+	assert.NoError(t, raw.Set(ctx, "a", "other", 0).Err())
+	assert.Equal(t, "1", raw.Get(ctx, "svc:a").Val())
+	assert.Equal(t, "1", cli.Get(ctx, "a").Val())
+	assert.Equal(t, []interface{}{"1", "2", "3", "4", "5", "6", "7", "8"}, cli.MGet(ctx, "a", "b", "c", "d", "e", "f", "g", "h").Val())
+	assert.Equal(t, []interface{}{"5", "6", "7", "8"}, raw.MGet(ctx, "svc:e", "svc:f", "svc:g", "svc:h").Val())
+
+	cmd := cli.Del(ctx, "a", "b")
+	assert.EqualValues(t, 2, cmd.Val())
+	assert.Equal(t, []interface{}{"a", "b"}, cmd.Args())
+	assert.EqualValues(t, 1, raw.Exists(ctx, "a").Val())
+
+	cli.SAdd(ctx, "s1", "x", "y")
+	cli.SAdd(ctx, "s2", "y", "z")
+	assert.EqualValues(t, 1, cli.SInterStore("s3", ctx, "s1", "s2").Val())
+	assert.Equal(t, []string{"y"}, raw.SMembers(ctx, "svc:s3").Val())
+
+	cli.ZAdd(ctx, "z1", &redis.Z{Score: 1, Member: "m"})
+	cli.ZAdd(ctx, "z2", &redis.Z{Score: 2, Member: "m"})
+	assert.EqualValues(t, 1, cli.ZUnionStore(ctx, "z3", &redis.ZStore{Keys: []string{"z1", "z2"}}).Val())
+	assert.Equal(t, 3.0, raw.ZScore(ctx, "svc:z3", "m").Val())
+
+	cli.RPush(ctx, "l", "v")
+	assert.Equal(t, []string{"l", "v"}, cli.BLPop(ctx, time.Second, "empty", "l").Val())
+	cli.ZAdd(ctx, "z4", &redis.Z{Score: 1, Member: "m"})
+	assert.Equal(t, "z4", cli.BZPopMin(ctx, time.Second, "z4").Val().Key)
+	cli.RPush(ctx, "l", "w")
+	key, vals := cli.LMPop(ctx, "left", 1, "empty", "l").Val()
+	assert.Equal(t, "l", key)
+	assert.Equal(t, []string{"w"}, vals)
+
+	id := cli.XAdd(ctx, &redis.XAddArgs{Stream: "st", Values: map[string]interface{}{"n": 1}}).Val()
+	streams := cli.XRead(ctx, &redis.XReadArgs{Streams: []string{"st", "0"}}).Val()
+	assert.Len(t, streams, 1)
+	assert.Equal(t, "st", streams[0].Stream)
+	assert.Equal(t, id, streams[0].Messages[0].ID)
+
+	var keys []string
+	iter := cli.Scan(ctx, 0, "", 2).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	assert.NoError(t, iter.Err())
+	sort.Strings(keys)
+	assert.Equal(t, []string{"c", "d", "e", "f", "g", "h", "s1", "s2", "s3", "st", "z1", "z2", "z3"}, keys)
+	page, _ := cli.Scan(ctx, 0, "s?", 100).Val()
+	sort.Strings(page)
+	assert.Equal(t, []string{"s1", "s2", "s3", "st"}, page)
+}
+
+func TestKeyPrefix_Pipeline(t *testing.T) {
+	ctx := context.Background()
+	s := redistest.NewServer()
+	raw := redis.NewRedis(redis.WithTransport(s))
+	cli := redis.NewRedis(redis.WithTransport(s), redis.WithKeyPrefix("svc:"))
+
+	cmds, err := cli.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "a", "1", 0)
+		p.Incr(ctx, "n")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, "1", raw.Get(ctx, "svc:a").Val())
+	assert.Equal(t, "1", raw.Get(ctx, "svc:n").Val())
+
+	_, err = cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, "a", "n")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, raw.Exists(ctx, "svc:a", "svc:n").Val())
+}
+
+func TestKeyPrefix_Glob(t *testing.T) {
+	ctx := context.Background()
+	s := redistest.NewServer()
+	raw := redis.NewRedis(redis.WithTransport(s))
+	cli := redis.NewRedis(redis.WithTransport(s), redis.WithKeyPrefix("t[1]*:"))
+
+	cli.Set(ctx, "a", "1", 0)
+	raw.Set(ctx, "t1x:a", "1", 0)
+	page, _ := cli.Scan(ctx, 0, "*", 100).Val()
+	assert.Equal(t, []string{"a"}, page)
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package lock implements a distributed lock on top of redis.IRedis.
+//
+//	locker := lock.New(baas.Redis)
+//	l, err := locker.Obtain(ctx, "order:42", 10*time.Second,
+//		lock.WithRetryStrategy(lock.LimitRetry(lock.LinearBackoff(100*time.Millisecond), 20)))
+//	if err == lock.ErrNotObtained {
+//		// another invocation holds the lock
+//	}
+//	defer l.Release(ctx)
+//
+// A lock is a key holding a random token with a TTL. It is refreshed and released only by
+// its owner, the token being checked atomically by a script.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+var (
+	// ErrNotObtained is returned by Obtain when the lock is held by another owner.
+	ErrNotObtained = errors.New("lock: not obtained")
+	// ErrLockNotHeld is returned by Refresh and Release when the lock expired or was taken
+	// by another owner.
+	ErrLockNotHeld = errors.New("lock: lock not held")
+)
+
+var (
+	luaRefresh = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`)
+	luaRelease = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+	luaPTTL    = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pttl", KEYS[1]) else return 0 end`)
+)
+
+// Client obtains locks.
+type Client struct {
+	client redis.IRedis
+}
+
+func New(client redis.IRedis) *Client {
+	return &Client{client: client}
+}
+
+type options struct {
+	retryStrategy RetryStrategy
+	token         string
+}
+
+type Option func(o *options)
+
+// WithRetryStrategy sets how Obtain retries while the lock is held by another owner.
+// Obtain does not retry by default.
+func WithRetryStrategy(retryStrategy RetryStrategy) Option {
+	return func(o *options) {
+		o.retryStrategy = retryStrategy
+	}
+}
+
+// WithToken sets the token identifying the owner of the lock, a random one by default.
+// Owners sharing a token can refresh and release the locks of one another.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// Obtain takes the lock named key for ttl, which must be positive for the lock to expire. It
+// returns ErrNotObtained when the lock is held by another owner and the retry strategy gives
+// up, or the error of ctx when ctx is done first.
+func (c *Client) Obtain(ctx context.Context, key string, ttl time.Duration, opts ...Option) (*Lock, error) {
+	if ttl <= 0 {
+		return nil, cExceptions.InvalidParamError("[lock] Obtain received invalid ttl (%v), should be > 0", ttl)
+	}
+	o := &options{retryStrategy: NoRetry()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	token := o.token
+	if token == "" {
+		var err error
+		if token, err = randomToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		if ok {
+			return &Lock{client: c, key: key, token: token}, nil
+		}
+
+		backoff := o.retryStrategy.NextBackoff()
+		if backoff <= 0 {
+			return nil, ErrNotObtained
+		}
+		if timer == nil {
+			timer = time.NewTimer(backoff)
+		} else {
+			timer.Reset(backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", cExceptions.InternalError("[lock] generate token failed, err: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Lock is a lock obtained by Client.Obtain.
+type Lock struct {
+	client *Client
+	key    string
+	token  string
+}
+
+func (l *Lock) Key() string {
+	return l.key
+}
+
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// TTL returns the time the lock is still held for, 0 when it is not held anymore.
+func (l *Lock) TTL(ctx context.Context) (time.Duration, error) {
+	res, err := luaPTTL.Run(ctx, l.client.client, []string{l.key}, l.token).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	if res > 0 {
+		return time.Duration(res) * time.Millisecond, nil
+	}
+	return 0, nil
+}
+
+// Refresh extends the lock to ttl from now. It returns ErrLockNotHeld when the lock is not held anymore.
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return cExceptions.InvalidParamError("[lock] Refresh received invalid ttl (%v), should be > 0", ttl)
+	}
+	ttlMs := strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+	res, err := luaRefresh.Run(ctx, l.client.client, []string{l.key}, l.token, ttlMs).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+// Release releases the lock. It returns ErrLockNotHeld when the lock is not held anymore.
+func (l *Lock) Release(ctx context.Context) error {
+	res, err := luaRelease.Run(ctx, l.client.client, []string{l.key}, l.token).Int64()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	if res != 1 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+)
+
+var ctx = context.Background()
+
+func TestLock(t *testing.T) {
+	cli := redistest.NewRedis()
+	cli.SetTime(time.Unix(1700000000, 0))
+	locker := New(cli)
+
+	l, err := locker.Obtain(ctx, "lock", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "lock", l.Key())
+	assert.NotEmpty(t, l.Token())
+	assert.Equal(t, l.Token(), cli.Get(ctx, "lock").Val())
+
+	_, err = locker.Obtain(ctx, "lock", time.Minute)
+	assert.Equal(t, ErrNotObtained, err)
+
+	ttl, err := l.TTL(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl)
+	assert.NoError(t, l.Refresh(ctx, time.Hour))
+	ttl, err = l.TTL(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, ttl)
+
+	assert.NoError(t, l.Release(ctx))
+	assert.Equal(t, ErrLockNotHeld, l.Release(ctx))
+	assert.Equal(t, ErrLockNotHeld, l.Refresh(ctx, time.Hour))
+	ttl, err = l.TTL(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, ttl)
+}
+
+func TestLock_NotOwner(t *testing.T) {
+	cli := redistest.NewRedis()
+	locker := New(cli)
+
+	l, err := locker.Obtain(ctx, "lock", time.Second)
+	assert.NoError(t, err)
+	cli.FastForward(time.Second)
+	other, err := locker.Obtain(ctx, "lock", time.Minute)
+	assert.NoError(t, err)
+
+	// the expired lock cannot touch the lock of its successor
+	assert.Equal(t, ErrLockNotHeld, l.Release(ctx))
+	assert.Equal(t, ErrLockNotHeld, l.Refresh(ctx, time.Minute))
+	assert.Equal(t, other.Token(), cli.Get(ctx, "lock").Val())
+	assert.NoError(t, other.Release(ctx))
+}
+
+func TestLock_Retry(t *testing.T) {
+	cli := redistest.NewRedis()
+	locker := New(cli)
+
+	_, err := locker.Obtain(ctx, "lock", time.Minute, WithToken("a"))
+	assert.NoError(t, err)
+
+	// retries until the lock is released
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cli.Del(ctx, "lock")
+	}()
+	l, err := locker.Obtain(ctx, "lock", time.Minute, WithRetryStrategy(LinearBackoff(5*time.Millisecond)))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "a", l.Token())
+
+	_, err = locker.Obtain(ctx, "lock", time.Minute, WithRetryStrategy(LimitRetry(LinearBackoff(time.Millisecond), 3)))
+	assert.Equal(t, ErrNotObtained, err)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = locker.Obtain(timeout, "lock", time.Minute, WithRetryStrategy(ExponentialBackoff(time.Millisecond, 5*time.Millisecond)))
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestLock_InvalidTTL(t *testing.T) {
+	cli := redistest.NewRedis()
+	locker := New(cli)
+
+	_, err := locker.Obtain(ctx, "lock", 0)
+	assert.Error(t, err)
+	_, err = locker.Obtain(ctx, "lock", -time.Second)
+	assert.Error(t, err)
+	assert.EqualValues(t, 0, cli.Exists(ctx, "lock").Val())
+
+	l, err := locker.Obtain(ctx, "lock", time.Minute)
+	assert.NoError(t, err)
+	assert.Error(t, l.Refresh(ctx, 0))
+	ttl, err := l.TTL(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttl.Round(time.Second))
+}
+
+func TestRetryStrategy(t *testing.T) {
+	assert.Zero(t, NoRetry().NextBackoff())
+
+	exp := ExponentialBackoff(time.Millisecond, 5*time.Millisecond)
+	var backoffs []time.Duration
+	for i := 0; i < 5; i++ {
+		backoffs = append(backoffs, exp.NextBackoff())
+	}
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 5 * time.Millisecond, 5 * time.Millisecond}, backoffs)
+
+	limited := LimitRetry(LinearBackoff(time.Second), 2)
+	assert.Equal(t, time.Second, limited.NextBackoff())
+	assert.Equal(t, time.Second, limited.NextBackoff())
+	assert.Zero(t, limited.NextBackoff())
+}
@@ -0,0 +1,85 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package lock
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryStrategy tells Obtain how long to wait before trying again, a duration <= 0 stopping
+// the retries. A strategy is used by a single Obtain, so it may keep state.
+type RetryStrategy interface {
+	NextBackoff() time.Duration
+}
+
+type noRetry struct{}
+
+func (noRetry) NextBackoff() time.Duration { return 0 }
+
+// NoRetry gives up after the first attempt.
+func NoRetry() RetryStrategy {
+	return noRetry{}
+}
+
+type linearBackoff time.Duration
+
+func (b linearBackoff) NextBackoff() time.Duration { return time.Duration(b) }
+
+// LinearBackoff retries every backoff, until ctx is done.
+func LinearBackoff(backoff time.Duration) RetryStrategy {
+	return linearBackoff(backoff)
+}
+
+type exponentialBackoff struct {
+	mu   sync.Mutex
+	next time.Duration
+	max  time.Duration
+}
+
+func (b *exponentialBackoff) NextBackoff() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	backoff := b.next
+	if b.next < b.max {
+		b.next *= 2
+		if b.next > b.max {
+			b.next = b.max
+		}
+	}
+	return backoff
+}
+
+// ExponentialBackoff retries after min, doubling the wait up to max, until ctx is done.
+func ExponentialBackoff(min, max time.Duration) RetryStrategy {
+	if min <= 0 {
+		min = time.Millisecond
+	}
+	if max < min {
+		max = min
+	}
+	return &exponentialBackoff{next: min, max: max}
+}
+
+type limitedRetry struct {
+	mu    sync.Mutex
+	s     RetryStrategy
+	count int
+	max   int
+}
+
+func (r *limitedRetry) NextBackoff() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count >= r.max {
+		return 0
+	}
+	r.count++
+	return r.s.NextBackoff()
+}
+
+// LimitRetry retries with s at most max times.
+func LimitRetry(s RetryStrategy, max int) RetryStrategy {
+	return &limitedRetry{s: s, max: max}
+}
@@ -0,0 +1,264 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"context"
+	"sync"
+
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+const defaultPipelineConcurrency = 10
+
+// Pipeliner queues commands until Exec sends them. The commands are returned right away,
+// their results are filled in by Exec.
+//
+// The FaaS infra has no batch endpoint, so with the default HttpTransport Exec does not send
+// the commands in one round trip: it sends a request per command, at most 10 at once, see
+// WithPipelineConcurrency. Only the transports implementing
+// faasinfra.RedisPipelineTransport, e.g. the one of redistest, send them in one round trip.
+//
+//	cmds, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+//		for _, field := range fields {
+//			p.HGet(ctx, key, field)
+//		}
+//		return nil
+//	})
+type Pipeliner interface {
+	IRedis
+	// Len returns the number of queued commands.
+	Len() int
+	// Discard drops the queued commands.
+	Discard()
+	// Exec sends the queued commands and returns them, with the error of the first failed one.
+	// The commands failed before being sent, e.g. for their arguments are invalid, are not sent.
+	Exec(ctx context.Context) ([]Cmder, error)
+}
+
+type cmdQueue struct {
+	mu   sync.Mutex
+	cmds []Cmder
+}
+
+func (q *cmdQueue) add(cmd Cmder) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.cmds = append(q.cmds, cmd)
+}
+
+func (q *cmdQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.cmds)
+}
+
+func (q *cmdQueue) take() []Cmder {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cmds := q.cmds
+	q.cmds = nil
+	return cmds
+}
+
+type Pipeline struct {
+	*Redis
+	exec func(ctx context.Context, cmds []Cmder) error
+}
+
+// Pipeline returns a Pipeliner sending its commands through the transport of c.
+func (c *Redis) Pipeline() Pipeliner {
+	return c.newPipeline(func(ctx context.Context, cmds []Cmder) error {
+		c.execCmds(ctx, unsentCmds(cmds))
+		return firstCmdErr(cmds)
+	})
+}
+
+// Pipelined queues the commands issued by fn and sends them with Exec.
+func (c *Redis) Pipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return pipelined(ctx, c.Pipeline(), fn)
+}
+
+// newPipeline returns a Pipeline queueing its commands on a copy of c and sending them with exec.
+func (c *Redis) newPipeline(exec func(ctx context.Context, cmds []Cmder) error) *Pipeline {
+	client := &Redis{}
+	if c != nil {
+		*client = *c
+	}
+	client.queue = &cmdQueue{}
+	return &Pipeline{Redis: client, exec: exec}
+}
+
+func pipelined(ctx context.Context, p Pipeliner, fn func(Pipeliner) error) ([]Cmder, error) {
+	if err := fn(p); err != nil {
+		return nil, err
+	}
+	return p.Exec(ctx)
+}
+
+func (p *Pipeline) Len() int {
+	return p.queue.len()
+}
+
+func (p *Pipeline) Discard() {
+	p.queue.take()
+}
+
+func (p *Pipeline) Exec(ctx context.Context) ([]Cmder, error) {
+	cmds := p.queue.take()
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+	return cmds, p.exec(ctx, cmds)
+}
+
+// execCmds sends cmds in one round trip when the transport supports it, or else one request
+// per command, see WithPipelineConcurrency.
+func (c *Redis) execCmds(ctx context.Context, cmds []Cmder) {
+	if len(cmds) == 0 {
+		return
+	}
+	transport := c.getTransport()
+	if batch, ok := transport.(faasinfra.RedisPipelineTransport); ok {
+		ctx, cancel := c.withApiTimeout(ctx)
+		defer cancel()
+		params := make([]interface{}, len(cmds))
+		for i, cmd := range cmds {
+			params[i] = cmd.base().argumentList()
+		}
+		bodies, extra, err := batch.DoRequestRedisPipeline(ctx, params)
+		if err == nil && len(bodies) != len(cmds) {
+			err = cExceptions.InternalError("[Redis] pipeline got %d replies for %d commands", len(bodies), len(cmds))
+		}
+		for i, cmd := range cmds {
+			if err != nil {
+				cmd.base().readReply(nil, extra, err)
+			} else {
+				cmd.base().readReply(bodies[i], extra, nil)
+			}
+		}
+		return
+	}
+
+	concurrency := defaultPipelineConcurrency
+	if c != nil && c.pipelineConcurrency > 0 {
+		concurrency = c.pipelineConcurrency
+	}
+	for _, chains := range cmdChains(cmds) {
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for _, chain := range chains {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(chain []Cmder) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				for _, cmd := range chain {
+					cmd.base().request(ctx)
+				}
+			}(chain)
+		}
+		wg.Wait()
+	}
+}
+
+// cmdChains splits cmds into batches run one after the other, each made of chains of commands
+// run in order, which share no key with the other chains of the batch. The commands without
+// keys make batches of their own, for they may depend on any key.
+func cmdChains(cmds []Cmder) [][][]Cmder {
+	var batches [][][]Cmder
+	var chains [][]Cmder
+	chainOf := make(map[string]int)
+	pos := make(map[Cmder]int, len(cmds))
+	for i, cmd := range cmds {
+		pos[cmd] = i
+	}
+	flush := func() {
+		var batch [][]Cmder
+		for _, chain := range chains {
+			if chain != nil {
+				batch = append(batch, chain)
+			}
+		}
+		if len(batch) > 0 {
+			batches = append(batches, batch)
+		}
+		chains, chainOf = nil, make(map[string]int)
+	}
+
+	for _, cmd := range cmds {
+		keys := cmdKeys(cmd.Name(), cmd.Args())
+		if len(keys) == 0 {
+			flush()
+			batches = append(batches, [][]Cmder{{cmd}})
+			continue
+		}
+
+		// Merge the chains of the keys of cmd, keeping the order of their commands.
+		target := -1
+		for _, key := range keys {
+			i, ok := chainOf[key]
+			if !ok || i == target {
+				continue
+			}
+			if target < 0 {
+				target = i
+				continue
+			}
+			chains[target] = mergeChains(chains[target], chains[i], pos)
+			for k, j := range chainOf {
+				if j == i {
+					chainOf[k] = target
+				}
+			}
+			chains[i] = nil
+		}
+		if target < 0 {
+			target = len(chains)
+			chains = append(chains, nil)
+		}
+		chains[target] = append(chains[target], cmd)
+		for _, key := range keys {
+			chainOf[key] = target
+		}
+	}
+	flush()
+	return batches
+}
+
+// mergeChains merges two chains into the order of the positions of their commands in pos.
+func mergeChains(a, b []Cmder, pos map[Cmder]int) []Cmder {
+	res := make([]Cmder, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if pos[a[0]] < pos[b[0]] {
+			res, a = append(res, a[0]), a[1:]
+		} else {
+			res, b = append(res, b[0]), b[1:]
+		}
+	}
+	return append(append(res, a...), b...)
+}
+
+// unsentCmds returns the commands of cmds that did not fail before being sent.
+func unsentCmds(cmds []Cmder) []Cmder {
+	res := make([]Cmder, 0, len(cmds))
+	for _, cmd := range cmds {
+		if cmd.Err() == nil {
+			res = append(res, cmd)
+		}
+	}
+	return res
+}
+
+func firstCmdErr(cmds []Cmder) error {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+)
+
+// countingTransport hides the pipeline support of its transport and counts the requests in flight.
+type countingTransport struct {
+	faasinfra.RedisTransport
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	requests int64
+}
+
+func (t *countingTransport) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	t.mu.Lock()
+	t.inFlight++
+	if t.inFlight > t.maxSeen {
+		t.maxSeen = t.inFlight
+	}
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.inFlight--
+		t.mu.Unlock()
+	}()
+	atomic.AddInt64(&t.requests, 1)
+	return t.RedisTransport.DoRequestRedis(ctx, param)
+}
+
+func TestPipelined(t *testing.T) {
+	ctx := context.Background()
+	cli := redistest.NewRedis()
+	cli.HSet(ctx, "h", "f1", "v1")
+
+	var get *redis.StringCmd
+	var exists *redis.BoolCmd
+	cmds, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Incr(ctx, "n")
+		p.Incr(ctx, "n")
+		get = p.HGet(ctx, "h", "f1")
+		exists = p.HExists(ctx, "h", "f2")
+		assert.Equal(t, 4, p.Len())
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 4)
+	assert.EqualValues(t, 2, cmds[1].(*redis.IntCmd).Val())
+	assert.Equal(t, "v1", get.Val())
+	assert.False(t, exists.Val())
+	assert.NoError(t, exists.Err())
+}
+
+func TestPipeline_FirstError(t *testing.T) {
+	ctx := context.Background()
+	cli := redistest.NewRedis()
+
+	p := cli.Pipeline()
+	set := p.Set(ctx, "k", "v", 0)
+	get := p.Get(ctx, "missing")
+	assert.NoError(t, set.Err())
+	cmds, err := p.Exec(ctx)
+	assert.Len(t, cmds, 2)
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, "OK", set.Val())
+	assert.Equal(t, redis.Nil, get.Err())
+	assert.Equal(t, 0, p.Len())
+
+	p.Incr(ctx, "n")
+	p.Discard()
+	cmds, err = p.Exec(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, cmds)
+
+	_, err = cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Incr(ctx, "n")
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+	assert.EqualValues(t, 0, cli.Exists(ctx, "n").Val())
+}
+
+func TestPipeline_FanOut(t *testing.T) {
+	ctx := context.Background()
+	transport := &countingTransport{RedisTransport: redistest.NewServer()}
+	cli := redis.NewRedis(redis.WithTransport(transport), redis.WithPipelineConcurrency(3))
+
+	cmds, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i := 0; i < 20; i++ {
+			p.Set(ctx, "k"+strconv.Itoa(i), i, 0)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, cmds, 20)
+	assert.EqualValues(t, 20, transport.requests)
+	assert.LessOrEqual(t, transport.maxSeen, 3)
+	assert.Equal(t, "7", cli.Get(ctx, "k7").Val())
+}
+
+// slowTransport delays the requests on the keys of slow, so that a pipeline not keeping the
+// order of the commands runs them out of order.
+type slowTransport struct {
+	faasinfra.RedisTransport
+	slow map[string]bool
+}
+
+func (t *slowTransport) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	b, _ := json.Marshal(param)
+	var cmd struct {
+		Args []interface{} `json:"args"`
+	}
+	_ = json.Unmarshal(b, &cmd)
+	if len(cmd.Args) > 0 {
+		if key, ok := cmd.Args[0].(string); ok && t.slow[key] {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	return t.RedisTransport.DoRequestRedis(ctx, param)
+}
+
+func TestPipeline_FanOutOrder(t *testing.T) {
+	ctx := context.Background()
+	transport := &slowTransport{RedisTransport: redistest.NewServer(), slow: map[string]bool{"a": true, "n": true}}
+	cli := redis.NewRedis(redis.WithTransport(transport), redis.WithPipelineConcurrency(4))
+	cli.Set(ctx, "a", "old", 0)
+
+	var ttl *redis.DurationCmd
+	_, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, "a")
+		p.Set(ctx, "a", "new", 0)
+		p.Set(ctx, "b", "new", 0)
+		p.Incr(ctx, "n")
+		p.Expire(ctx, "n", time.Minute)
+		p.MSet(ctx, "a", "m", "b", "m")
+		p.Append(ctx, "a", "!")
+		p.Append(ctx, "b", "?")
+		p.RPush(ctx, "l", "x")
+		p.LMove(ctx, "l", "l2", "left", "left")
+		ttl = p.TTL(ctx, "n")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "m!", cli.Get(ctx, "a").Val())
+	assert.Equal(t, "m?", cli.Get(ctx, "b").Val())
+	assert.Equal(t, time.Minute, ttl.Val())
+	assert.Equal(t, []string{"x"}, cli.LRange(ctx, "l2", 0, -1).Val())
+
+	keys, _ := cli.Scan(ctx, 0, "", 100).Val()
+	_, err = cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "n", "1", 0)
+		p.Del(ctx, keys...)
+		p.Set(ctx, "a", "again", 0)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cli.Exists(ctx, "a").Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "n").Val())
+}
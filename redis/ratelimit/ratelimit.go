@@ -0,0 +1,237 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package ratelimit throttles requests with counters kept in redis, shared by all the
+// invocations of a cloud function.
+//
+//	limiter := ratelimit.New(baas.Redis)
+//	res, err := limiter.Allow(ctx, "user:42", ratelimit.PerMinute(60))
+//	if err != nil {
+//		return err
+//	}
+//	if !res.Allowed {
+//		// try again in res.RetryAfter
+//	}
+//
+// Each check runs as a single script, so that concurrent checks of a key never exceed its
+// limit. The scripts read the clock of redis, which is shared by all the invocations.
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// keyPrefix starts the keys holding the state of the limits.
+const keyPrefix = "ratelimit:"
+
+// Algorithm is how a Limiter counts requests.
+type Algorithm int
+
+const (
+	// TokenBucket allows Limit.Burst requests at once, and then a request every
+	// Limit.Period / Limit.Rate. It is implemented with GCRA, which stores a single timestamp.
+	TokenBucket Algorithm = iota
+	// FixedWindow allows Limit.Rate requests in windows of Limit.Period starting with the
+	// first request of each window. Twice the rate may pass around the end of a window.
+	FixedWindow
+	// SlidingWindow allows Limit.Rate requests in any Limit.Period. It stores a sorted set
+	// entry per request, so it suits small limits.
+	SlidingWindow
+)
+
+var (
+	luaTokenBucket = redis.NewScript(`redis.replicate_commands()
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2]) / tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+local new_tat = tat + cost * interval
+local diff = now - (new_tat - burst * interval)
+if diff < 0 then
+	return {0, math.floor((diff + cost * interval) / interval), math.ceil(-diff), math.ceil(tat - now)}
+end
+local reset_after = new_tat - now
+redis.call("SET", KEYS[1], string.format("%.3f", new_tat), "PX", math.ceil(reset_after))
+return {1, math.floor(diff / interval), 0, math.ceil(reset_after)}`)
+
+	luaFixedWindow = redis.NewScript(`local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	count = 0
+	ttl = period
+end
+if count + cost > limit then
+	return {0, limit - count, ttl, ttl}
+end
+if count == 0 then
+	redis.call("SET", KEYS[1], cost, "PX", period)
+else
+	redis.call("INCRBY", KEYS[1], cost)
+end
+return {1, limit - count - cost, 0, ttl}`)
+
+	luaSlidingWindow = redis.NewScript(`redis.replicate_commands()
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - period)
+local count = redis.call("ZCARD", KEYS[1])
+if count + cost > limit then
+	local first = redis.call("ZRANGE", KEYS[1], count + cost - limit - 1, count + cost - limit - 1, "WITHSCORES")
+	local last = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+	return {0, limit - count, tonumber(first[2]) + period - now, tonumber(last[2]) + period - now}
+end
+for i = 1, cost do
+	redis.call("ZADD", KEYS[1], now, ARGV[4] .. ":" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], period)
+return {1, limit - count - cost, 0, period}`)
+)
+
+// Limit is a number of requests allowed per period.
+type Limit struct {
+	Rate   int64
+	Period time.Duration
+	// Burst is the number of requests TokenBucket allows at once, Rate when 0. The windows
+	// ignore it.
+	Burst int64
+}
+
+func PerSecond(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+func PerMinute(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+func PerHour(rate int64) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+// capacity returns the most requests allowed at once.
+func (l Limit) capacity(algorithm Algorithm) int64 {
+	if algorithm == TokenBucket && l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	// Allowed reports whether the requests are allowed. The denied requests do not use any quota.
+	Allowed bool
+	// Remaining is the number of requests still allowed right now.
+	Remaining int64
+	// RetryAfter is the time to wait for the requests to be allowed, 0 when they are.
+	RetryAfter time.Duration
+	// ResetAfter is the time until the whole quota is available again, and ResetAt the time
+	// it is, by the local clock.
+	ResetAfter time.Duration
+	ResetAt    time.Time
+}
+
+// Limiter checks requests against limits.
+type Limiter struct {
+	client    redis.IRedis
+	algorithm Algorithm
+}
+
+type Option func(l *Limiter)
+
+// WithAlgorithm sets how requests are counted, TokenBucket by default. The limiters of
+// different algorithms must not check the same keys.
+func WithAlgorithm(algorithm Algorithm) Option {
+	return func(l *Limiter) {
+		l.algorithm = algorithm
+	}
+}
+
+func New(client redis.IRedis, opts ...Option) *Limiter {
+	l := &Limiter{client: client}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Allow checks a request to key, e.g. "user:42", against limit.
+func (l *Limiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.AllowN(ctx, key, limit, 1)
+}
+
+// AllowN checks n requests at once, which are all allowed or all denied. n must not exceed
+// the requests limit allows at once.
+func (l *Limiter) AllowN(ctx context.Context, key string, limit Limit, n int64) (Result, error) {
+	if limit.Rate <= 0 || limit.Period < time.Millisecond || limit.Burst < 0 {
+		return Result{}, cExceptions.InvalidParamError("[ratelimit] invalid limit %+v", limit)
+	}
+	if n <= 0 || n > limit.capacity(l.algorithm) {
+		return Result{}, cExceptions.InvalidParamError("[ratelimit] n must be between 1 and %d, got %d", limit.capacity(l.algorithm), n)
+	}
+
+	keys := []string{keyPrefix + key}
+	period := int64(limit.Period / time.Millisecond)
+	var cmd *redis.Cmd
+	switch l.algorithm {
+	case TokenBucket:
+		cmd = luaTokenBucket.Run(ctx, l.client, keys, limit.capacity(l.algorithm), period, limit.Rate, n)
+	case FixedWindow:
+		cmd = luaFixedWindow.Run(ctx, l.client, keys, limit.Rate, period, n)
+	case SlidingWindow:
+		id, err := requestID()
+		if err != nil {
+			return Result{}, err
+		}
+		cmd = luaSlidingWindow.Run(ctx, l.client, keys, limit.Rate, period, n, id)
+	default:
+		return Result{}, cExceptions.InvalidParamError("[ratelimit] unknown algorithm %d", l.algorithm)
+	}
+
+	res, err := cmd.Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(res) != 4 {
+		return Result{}, cExceptions.InternalError("[ratelimit] got a reply of %d elements, expected 4", len(res))
+	}
+	resetAfter := time.Duration(res[3]) * time.Millisecond
+	return Result{
+		Allowed:    res[0] == 1,
+		Remaining:  res[1],
+		RetryAfter: time.Duration(res[2]) * time.Millisecond,
+		ResetAfter: resetAfter,
+		ResetAt:    time.Now().Add(resetAfter),
+	}, nil
+}
+
+// Reset forgets the requests to key, restoring its whole quota.
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.client.Del(ctx, keyPrefix+key).Err()
+}
+
+// requestID returns a random ID telling apart the sorted set entries of the requests made
+// within the same millisecond.
+func requestID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", cExceptions.InternalError("[ratelimit] generate request id failed, err: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
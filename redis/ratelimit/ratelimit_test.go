@@ -0,0 +1,124 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis/redistest"
+)
+
+var ctx = context.Background()
+
+// newRedis returns a fake redis at a frozen time.
+func newRedis() *redistest.Redis {
+	cli := redistest.NewRedis()
+	cli.SetTime(time.Unix(1700000000, 0))
+	return cli
+}
+
+func TestTokenBucket(t *testing.T) {
+	cli := newRedis()
+	limiter := New(cli)
+	limit := Limit{Rate: 10, Period: time.Second, Burst: 3}
+
+	for i := int64(2); i >= 0; i-- {
+		res, err := limiter.Allow(ctx, "user:1", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+		assert.Zero(t, res.RetryAfter)
+	}
+	res, err := limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+	assert.Equal(t, 100*time.Millisecond, res.RetryAfter)
+	assert.Equal(t, 300*time.Millisecond, res.ResetAfter)
+
+	cli.FastForward(100 * time.Millisecond)
+	res, err = limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+
+	cli.FastForward(time.Second)
+	res, err = limiter.AllowN(ctx, "user:1", limit, 3)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	res, err = limiter.Allow(ctx, "user:2", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Remaining)
+	assert.NoError(t, limiter.Reset(ctx, "user:2"))
+	assert.EqualValues(t, 0, cli.Exists(ctx, "ratelimit:user:2").Val())
+}
+
+func TestFixedWindow(t *testing.T) {
+	cli := newRedis()
+	limiter := New(cli, WithAlgorithm(FixedWindow))
+	limit := PerMinute(2)
+
+	for i := int64(1); i >= 0; i-- {
+		res, err := limiter.Allow(ctx, "user:1", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, i, res.Remaining)
+	}
+	cli.FastForward(20 * time.Second)
+	res, err := limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 40*time.Second, res.RetryAfter)
+	assert.Equal(t, 40*time.Second, res.ResetAfter)
+
+	cli.FastForward(40 * time.Second)
+	res, err = limiter.AllowN(ctx, "user:1", limit, 2)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+	assert.Equal(t, time.Minute, res.ResetAfter)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	cli := newRedis()
+	limiter := New(cli, WithAlgorithm(SlidingWindow))
+	limit := PerMinute(2)
+
+	res, err := limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	cli.FastForward(20 * time.Second)
+	res, err = limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+
+	res, err = limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 40*time.Second, res.RetryAfter)
+	assert.Equal(t, time.Minute, res.ResetAfter)
+
+	cli.FastForward(40 * time.Second)
+	res, err = limiter.Allow(ctx, "user:1", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Zero(t, res.Remaining)
+	assert.EqualValues(t, 2, cli.ZCard(ctx, "ratelimit:user:1").Val())
+}
+
+func TestAllow_Invalid(t *testing.T) {
+	limiter := New(newRedis())
+	_, err := limiter.Allow(ctx, "user:1", Limit{Rate: 0, Period: time.Second})
+	assert.Error(t, err)
+	_, err = limiter.AllowN(ctx, "user:1", PerSecond(2), 3)
+	assert.Error(t, err)
+	_, err = limiter.AllowN(ctx, "user:1", PerSecond(2), 0)
+	assert.Error(t, err)
+}
@@ -5,48 +5,200 @@ package redis
 
 import (
 	"context"
+	"reflect"
+	"strconv"
 	"time"
+
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
 )
 
-type Redis struct{}
+type Redis struct {
+	transport           faasinfra.RedisTransport
+	pipelineConcurrency int
+	apiTimeout          time.Duration
+	codec               Codec
+	keyPrefix           string
+
+	// queue is set on the clients of a Pipeline, which queue commands instead of sending them.
+	queue *cmdQueue
+}
+
+type Option func(c *Redis)
+
+// WithTransport replaces the transport used to send commands, e.g. with an in-memory one in tests.
+func WithTransport(transport faasinfra.RedisTransport) Option {
+	return func(c *Redis) {
+		c.transport = transport
+	}
+}
+
+// WithPipelineConcurrency bounds the requests sent at once by Pipeline.Exec when the
+// transport cannot send a pipeline in one round trip, and sends a request per command. This
+// is always the case with the default HttpTransport, as the FaaS infra has no batch endpoint.
+// It defaults to 10. The commands still run in the order they were queued in as far as it
+// matters: those sharing a key run one after the other, and those without keys, such as
+// SCAN, after all the previous commands and before all the next ones. Only the commands on
+// different keys run at once.
+func WithPipelineConcurrency(n int) Option {
+	return func(c *Redis) {
+		c.pipelineConcurrency = n
+	}
+}
+
+// WithApiTimeout bounds each request to redis with a deadline timeout after it is sent. The
+// blocking commands block for less than it and than the deadline of their ctx, so that they
+// reply before their request times out. Set it, or a deadline on ctx, to the timeout of the
+// FaaS infra redis API: without either, the blocking commands are sent with their timeout as
+// it is, and may outlive their request.
+func WithApiTimeout(timeout time.Duration) Option {
+	return func(c *Redis) {
+		c.apiTimeout = timeout
+	}
+}
+
+// WithCodec sets how SetValue, HSetValue, LPushValue and RPushValue encode values, and
+// GetInto, HGetInto and LRangeInto decode them, JSONCodec by default. The other commands,
+// e.g. Set, HSet and LPush, send their values as they are, without the codec.
+func WithCodec(codec Codec) Option {
+	return func(c *Redis) {
+		c.codec = codec
+	}
+}
+
+// WithKeyPrefix prepends prefix to the keys of the commands, e.g. "svc:orders:" for the
+// services sharing a redis to keep apart. The keys of the replies, e.g. of Scan, BLPop or
+// XRead, are returned without it, and Scan only enumerates the keys starting with it. The
+// keys inside scripts are not rewritten, only the KEYS of Eval and EvalSha.
+func WithKeyPrefix(prefix string) Option {
+	return func(c *Redis) {
+		c.keyPrefix = prefix
+	}
+}
+
+func NewRedis(opts ...Option) *Redis {
+	c := &Redis{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *Redis) getTransport() faasinfra.RedisTransport {
+	if c == nil {
+		return faasinfra.GetRedisTransport(nil)
+	}
+	return faasinfra.GetRedisTransport(c.transport)
+}
+
+// blockTimeoutMargin is kept between the end of a blocking command and the timeout of its
+// request, for the reply to travel back.
+const blockTimeoutMargin = 500 * time.Millisecond
+
+// blockTimeout returns the timeout of a blocking command. A timeout of 0, blocking forever in
+// redis, and the timeouts ending after the deadline of ctx or the timeout of WithApiTimeout
+// are shortened to end before both, so that the command replies Nil in time. Without either,
+// timeout is returned as it is.
+func (c *Redis) blockTimeout(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	var limit time.Duration
+	bounded := false
+	if c != nil && c.apiTimeout > 0 {
+		limit, bounded = c.apiTimeout, true
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline); !bounded || d < limit {
+			limit, bounded = d, true
+		}
+	}
+	if !bounded {
+		return timeout, nil
+	}
+	limit -= blockTimeoutMargin
+	if limit <= 0 {
+		return 0, ctxErr(ctx)
+	}
+	if timeout <= 0 || timeout > limit {
+		timeout = limit
+	}
+	if timeout < time.Millisecond {
+		timeout = time.Millisecond
+	}
+	return timeout, nil
+}
+
+// ctxErr returns the error of ctx, or context.DeadlineExceeded when its deadline is too close
+// for a request to complete.
+func ctxErr(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return context.DeadlineExceeded
+}
+
+// blockSeconds returns the timeout argument of BLPOP and the other blocking commands taking
+// seconds, see blockTimeout.
+func (c *Redis) blockSeconds(ctx context.Context, timeout time.Duration) (string, error) {
+	timeout, err := c.blockTimeout(ctx, timeout)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(float64(timeout/time.Millisecond)/1000, 'f', -1, 64), nil
+}
 
-func NewRedis() *Redis {
-	return &Redis{}
+// withApiTimeout bounds ctx by the timeout set with WithApiTimeout.
+func (c *Redis) withApiTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c == nil || c.apiTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.apiTimeout)
+}
+
+// process sends cmd, or queues it when c belongs to a Pipeline. A cmd failed before being
+// sent, e.g. for its arguments are invalid, is not sent; a Pipeline reports its error.
+func (c *Redis) process(ctx context.Context, cmd Cmder) {
+	if c != nil && c.queue != nil {
+		c.queue.add(cmd)
+		return
+	}
+	if cmd.Err() != nil {
+		return
+	}
+	cmd.base().request(ctx)
 }
 
 func (c *Redis) TTL(ctx context.Context, key string) *DurationCmd {
 	cmd := NewDurationCmd(c, "ttl", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Type(ctx context.Context, key string) *StatusCmd {
 	cmd := NewStatusCmd(c, "type", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Append(ctx context.Context, key, value string) *IntCmd {
 	cmd := NewIntCmd(c, "append", key, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) GetRange(ctx context.Context, key string, start, end int64) *StringCmd {
 	cmd := NewStringCmd(c, "getrange", key, start, end)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) GetSet(ctx context.Context, key string, value interface{}) *StringCmd {
 	cmd := NewStringCmd(c, "getset", key, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Get(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(c, "get", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -60,7 +212,7 @@ func (c *Redis) Set(ctx context.Context, key string, value interface{}, expirati
 		}
 	}
 	cmd := NewStatusCmd(c, "set", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -70,7 +222,7 @@ func (c *Redis) Del(ctx context.Context, keys ...string) *IntCmd {
 		args[i] = key
 	}
 	cmd := NewIntCmd(c, "del", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -80,73 +232,73 @@ func (c *Redis) Exists(ctx context.Context, keys ...string) *IntCmd {
 		args[i] = key
 	}
 	cmd := NewIntCmd(c, "exists", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Expire(ctx context.Context, key string, expiration time.Duration) *BoolCmd {
-	cmd := NewIntCmd(c, "expire", key, formatSecond(expiration))
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "expire", key, formatSecond(expiration))
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) ExpireAt(ctx context.Context, key string, tm time.Time) *BoolCmd {
-	cmd := NewIntCmd(c, "expireat", key, tm.Unix())
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "expireat", key, tm.Unix())
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) Persist(ctx context.Context, key string) *BoolCmd {
-	cmd := NewIntCmd(c, "persist", key)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "persist", key)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) PExpire(ctx context.Context, key string, expiration time.Duration) *BoolCmd {
-	cmd := NewIntCmd(c, "pexpire", key, formatMils(expiration))
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "pexpire", key, formatMils(expiration))
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) PExpireAt(ctx context.Context, key string, tm time.Time) *BoolCmd {
-	cmd := NewIntCmd(c, "pexpireat", key, tm.UnixNano()/int64(time.Millisecond))
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "pexpireat", key, tm.UnixNano()/int64(time.Millisecond))
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) PTTL(ctx context.Context, key string) *DurationCmd {
 	cmd := NewDurationCmd(c, "pttl", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Incr(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "incr", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) Decr(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "decr", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) IncrBy(ctx context.Context, key string, value int64) *IntCmd {
 	cmd := NewIntCmd(c, "incrby", key, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) DecrBy(ctx context.Context, key string, value int64) *IntCmd {
 	cmd := NewIntCmd(c, "decrby", key, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) IncrByFloat(ctx context.Context, key string, value float64) *FloatCmd {
 	cmd := NewFloatCmd(c, "incrbyfloat", key, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -156,7 +308,7 @@ func (c *Redis) MGet(ctx context.Context, keys ...string) *SliceCmd {
 		args[i] = key
 	}
 	cmd := NewSliceCmd(c, "mget", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -166,45 +318,44 @@ func (c *Redis) MGet(ctx context.Context, keys ...string) *SliceCmd {
 // 2: "k1", "v1", "k2", "v2"
 func (c *Redis) MSet(ctx context.Context, pairs ...interface{}) *StatusCmd {
 	cmd := NewStatusCmd(c, "mset", pairs...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 // SetNX is short for "SET If Not Exists".
 func (c *Redis) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *BoolCmd {
-	var statusCmd *StatusCmd
 	args := []interface{}{key, value}
 	if expiration <= 0 {
-		intCmd := NewIntCmd(c, "setnx", key, value)
-		intCmd.request(ctx)
-		return tranIntCmd2BoolCmd(intCmd)
+		cmd := NewBoolCmd(c, "setnx", key, value)
+		c.process(ctx, cmd)
+		return cmd
 	}
 	if usePrecise(expiration) {
 		args = append(args, "px", formatMils(expiration), "nx")
 	} else {
 		args = append(args, "ex", formatSecond(expiration), "nx")
 	}
-	statusCmd = NewStatusCmd(c, "set", args...)
-	statusCmd.request(ctx)
-	return tranStatusCmd2BoolCmd(statusCmd)
+	cmd := NewBoolCmd(c, "set", args...)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 // SetXX is short for "SET If Exists".
 func (c *Redis) SetXX(ctx context.Context, key string, value interface{}) *BoolCmd {
-	cmd := NewStatusCmd(c, "set", key, value, "xx")
-	cmd.request(ctx)
-	return tranStatusCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "set", key, value, "xx")
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) SetRange(ctx context.Context, key string, offset int64, value string) *IntCmd {
 	cmd := NewIntCmd(c, "setrange", key, offset, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) StrLen(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "strlen", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -213,13 +364,13 @@ func (c *Redis) StrLen(ctx context.Context, key string) *IntCmd {
 
 func (c *Redis) GetBit(ctx context.Context, key string, offset int64) *IntCmd {
 	cmd := NewIntCmd(c, "getbit", key, offset)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) SetBit(ctx context.Context, key string, offset int64, value int) *IntCmd {
 	cmd := NewIntCmd(c, "setbit", key, offset, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -229,7 +380,70 @@ func (c *Redis) BitCount(ctx context.Context, key string, bitCount *BitCountArgs
 		args = append(args, bitCount.Start, bitCount.End)
 	}
 	cmd := NewIntCmd(c, "bitcount", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) bitOp(ctx context.Context, op, destKey string, keys ...string) *IntCmd {
+	args := make([]interface{}, 2+len(keys))
+	args[0] = op
+	args[1] = destKey
+	for i, key := range keys {
+		args[2+i] = key
+	}
+	cmd := NewIntCmd(c, "bitop", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// BitOpAnd stores the bitwise AND of keys to destKey, and returns its length in bytes.
+func (c *Redis) BitOpAnd(ctx context.Context, destKey string, keys ...string) *IntCmd {
+	return c.bitOp(ctx, "and", destKey, keys...)
+}
+
+func (c *Redis) BitOpOr(ctx context.Context, destKey string, keys ...string) *IntCmd {
+	return c.bitOp(ctx, "or", destKey, keys...)
+}
+
+func (c *Redis) BitOpXor(ctx context.Context, destKey string, keys ...string) *IntCmd {
+	return c.bitOp(ctx, "xor", destKey, keys...)
+}
+
+func (c *Redis) BitOpNot(ctx context.Context, destKey string, key string) *IntCmd {
+	return c.bitOp(ctx, "not", destKey, key)
+}
+
+// BitPos returns the position of the first bit set to bit, looking from the start byte and
+// up to the end byte when given, or -1.
+func (c *Redis) BitPos(ctx context.Context, key string, bit int64, pos ...int64) *IntCmd {
+	args := make([]interface{}, 2, 2+len(pos))
+	args[0] = key
+	args[1] = bit
+	switch len(pos) {
+	case 0:
+	case 1, 2:
+		for _, p := range pos {
+			args = append(args, p)
+		}
+	default:
+		cmd := NewIntCmd(c, "bitpos", args...)
+		cmd.err = cExceptions.InvalidParamError("[Redis] BitPos takes at most start and end, got %d positions", len(pos))
+		c.process(ctx, cmd)
+		return cmd
+	}
+	cmd := NewIntCmd(c, "bitpos", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// BitField runs the operations of ops on key and returns the value of each GET, SET and
+// INCRBY, nil for the operations failing with OverflowFail.
+func (c *Redis) BitField(ctx context.Context, key string, ops *BitFieldArgs) *IntPointerSliceCmd {
+	args := make([]interface{}, 1, 1+len(ops.args))
+	args[0] = key
+	args = append(args, ops.args...)
+	cmd := NewIntPointerSliceCmd(c, "bitfield", args...)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -242,49 +456,49 @@ func (c *Redis) HDel(ctx context.Context, key string, fields ...string) *IntCmd
 		args[i+1] = field
 	}
 	cmd := NewIntCmd(c, "hdel", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HExists(ctx context.Context, key, field string) *BoolCmd {
-	cmd := NewIntCmd(c, "hexists", key, field)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "hexists", key, field)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) HGet(ctx context.Context, key, field string) *StringCmd {
 	cmd := NewStringCmd(c, "hget", key, field)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HGetAll(ctx context.Context, key string) *StrStrMapCmd {
 	cmd := NewStrStrMapCmd(c, "hgetall", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HIncrBy(ctx context.Context, key, field string, incrI64 int64) *IntCmd {
 	cmd := NewIntCmd(c, "hincrby", key, field, incrI64)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HIncrByFloat(ctx context.Context, key, field string, incrF64 float64) *FloatCmd {
 	cmd := NewFloatCmd(c, "hincrbyfloat", key, field, incrF64)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HKeys(ctx context.Context, key string) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "hkeys", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HLen(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "hlen", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -297,7 +511,7 @@ func (c *Redis) HMSet(ctx context.Context, key string, pairs ...interface{}) *St
 	args[0] = key
 	args = appendArgs(args, pairs)
 	cmd := NewStatusCmd(c, "hmset", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -308,25 +522,40 @@ func (c *Redis) HMGet(ctx context.Context, key string, fields ...string) *SliceC
 		args[i+1] = field
 	}
 	cmd := NewSliceCmd(c, "hmget", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) HSet(ctx context.Context, key string, field string, value interface{}) *BoolCmd {
-	cmd := NewIntCmd(c, "hset", key, field, value)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "hset", key, field, value)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// HSetStruct sets the fields of the hash key from the fields of the struct value, or value
+// points to, tagged `redis:"name"`, in the format StrStrMapCmd.Scan reads back. The fields
+// tagged `redis:"name,omitempty"` are skipped when they are zero, and the nil pointers are
+// skipped. It returns the number of fields added to the hash.
+func (c *Redis) HSetStruct(ctx context.Context, key string, value interface{}) *IntCmd {
+	fields, err := structArgs(value)
+	if err == nil && len(fields) == 0 {
+		err = cExceptions.InvalidParamError("[Redis] HSetStruct got no field to set from %T", value)
+	}
+	cmd := NewIntCmd(c, "hset", append([]interface{}{key}, fields...)...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) HSetNX(ctx context.Context, key, field string, value interface{}) *BoolCmd {
-	cmd := NewIntCmd(c, "hsetnx", key, field, value)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "hsetnx", key, field, value)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 func (c *Redis) HVals(ctx context.Context, key string) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "hvals", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -334,25 +563,25 @@ func (c *Redis) HVals(ctx context.Context, key string) *StrSliceCmd {
 // List
 func (c *Redis) LIndex(ctx context.Context, key string, index int64) *StringCmd {
 	cmd := NewStringCmd(c, "lindex", key, index)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LInsert(ctx context.Context, key, op string, pivot, value interface{}) *IntCmd {
 	cmd := NewIntCmd(c, "linsert", key, op, pivot, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LLen(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "llen", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LPop(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(c, "lpop", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -361,7 +590,7 @@ func (c *Redis) LPush(ctx context.Context, key string, values ...interface{}) *I
 	args[0] = key
 	args = appendArgs(args, values)
 	cmd := NewIntCmd(c, "lpush", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -370,37 +599,37 @@ func (c *Redis) LPushX(ctx context.Context, key string, values ...interface{}) *
 	args[0] = key
 	args = appendArgs(args, values)
 	cmd := NewIntCmd(c, "lpushx", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LRange(ctx context.Context, key string, start, stop int64) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "lrange", key, start, stop)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LRem(ctx context.Context, key string, count int64, value interface{}) *IntCmd {
 	cmd := NewIntCmd(c, "lrem", key, count, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LSet(ctx context.Context, key string, index int64, value interface{}) *StatusCmd {
 	cmd := NewStatusCmd(c, "lset", key, index, value)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) LTrim(ctx context.Context, key string, start, stop int64) *StatusCmd {
 	cmd := NewStatusCmd(c, "ltrim", key, start, stop)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) RPop(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(c, "rpop", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -409,7 +638,7 @@ func (c *Redis) RPush(ctx context.Context, key string, values ...interface{}) *I
 	args[0] = key
 	args = append(args, values...)
 	cmd := NewIntCmd(c, "rpush", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -418,7 +647,90 @@ func (c *Redis) RPushX(ctx context.Context, key string, values ...interface{}) *
 	args[0] = key
 	args = append(args, values...)
 	cmd := NewIntCmd(c, "rpushx", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// BLPop pops the first element of the first non empty list of keys, waiting for one up to
+// timeout, and returns the key and the element. It returns Nil when the wait times out.
+// timeout is shortened to end before the deadline of ctx and the API timeout, 0 waiting
+// as long as both allow.
+func (c *Redis) BLPop(ctx context.Context, timeout time.Duration, keys ...string) *StrSliceCmd {
+	return c.bPop(ctx, "blpop", timeout, keys)
+}
+
+// BRPop is BLPop popping the last element of the lists.
+func (c *Redis) BRPop(ctx context.Context, timeout time.Duration, keys ...string) *StrSliceCmd {
+	return c.bPop(ctx, "brpop", timeout, keys)
+}
+
+func (c *Redis) bPop(ctx context.Context, name string, timeout time.Duration, keys []string) *StrSliceCmd {
+	args := make([]interface{}, len(keys), len(keys)+1)
+	for i, key := range keys {
+		args[i] = key
+	}
+	sec, err := c.blockSeconds(ctx, timeout)
+	cmd := NewStrSliceCmd(c, name, append(args, sec)...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// LMove pops the first ("LEFT") or last ("RIGHT") element of source, pushes it to the
+// destpos end of destination, and returns it. It returns Nil when source does not exist.
+func (c *Redis) LMove(ctx context.Context, source, destination, srcpos, destpos string) *StringCmd {
+	cmd := NewStringCmd(c, "lmove", source, destination, srcpos, destpos)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// BLMove is LMove waiting up to timeout for source to exist, as BLPop does.
+func (c *Redis) BLMove(ctx context.Context, source, destination, srcpos, destpos string, timeout time.Duration) *StringCmd {
+	sec, err := c.blockSeconds(ctx, timeout)
+	cmd := NewStringCmd(c, "blmove", source, destination, srcpos, destpos, sec)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// LPos returns the index of the first element of key equal to value, or Nil.
+func (c *Redis) LPos(ctx context.Context, key string, value string, a LPosArgs) *IntCmd {
+	cmd := NewIntCmd(c, "lpos", lPosArgs(key, value, a)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// LPosCount returns the indexes of up to count elements of key equal to value, 0 returning
+// all of them.
+func (c *Redis) LPosCount(ctx context.Context, key string, value string, count int64, a LPosArgs) *IntSliceCmd {
+	args := append(lPosArgs(key, value, a), "count", count)
+	cmd := NewIntSliceCmd(c, "lpos", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func lPosArgs(key, value string, a LPosArgs) []interface{} {
+	args := []interface{}{key, value}
+	if a.Rank != 0 {
+		args = append(args, "rank", a.Rank)
+	}
+	if a.MaxLen != 0 {
+		args = append(args, "maxlen", a.MaxLen)
+	}
+	return args
+}
+
+// LMPop pops up to count elements from the "LEFT" or "RIGHT" end of the first non empty list
+// of keys, and returns the key and the elements. It returns Nil when all the lists are empty.
+func (c *Redis) LMPop(ctx context.Context, direction string, count int64, keys ...string) *KeyValuesCmd {
+	args := make([]interface{}, 1, len(keys)+4)
+	args[0] = len(keys)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	args = append(args, direction, "count", count)
+	cmd := NewKeyValuesCmd(c, "lmpop", args...)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -430,13 +742,13 @@ func (c *Redis) SAdd(ctx context.Context, key string, members ...interface{}) *I
 	args[0] = key
 	args = append(args, members...)
 	cmd := NewIntCmd(c, "sadd", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) SCard(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "scard", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -446,7 +758,7 @@ func (c *Redis) SDiff(ctx context.Context, keys ...string) *StrSliceCmd {
 		args[i] = key
 	}
 	cmd := NewStrSliceCmd(c, "sdiff", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -457,7 +769,7 @@ func (c *Redis) SDiffStore(destination string, ctx context.Context, keys ...stri
 		args[1+i] = key
 	}
 	cmd := NewIntCmd(c, "sdiffstore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -467,7 +779,7 @@ func (c *Redis) SInter(ctx context.Context, keys ...string) *StrSliceCmd {
 		args[i] = key
 	}
 	cmd := NewStrSliceCmd(c, "sinter", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -478,54 +790,54 @@ func (c *Redis) SInterStore(destination string, ctx context.Context, keys ...str
 		args[1+i] = key
 	}
 	cmd := NewIntCmd(c, "sinterstore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) SIsMember(ctx context.Context, key string, member interface{}) *BoolCmd {
-	cmd := NewIntCmd(c, "sismember", key, member)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "sismember", key, member)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 // SMembers `SMEMBERS key` command output as a slice.
 func (c *Redis) SMembers(ctx context.Context, key string) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "smembers", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) SMove(ctx context.Context, source, destination string, member interface{}) *BoolCmd {
-	cmd := NewIntCmd(c, "smove", source, destination, member)
-	cmd.request(ctx)
-	return tranIntCmd2BoolCmd(cmd)
+	cmd := NewBoolCmd(c, "smove", source, destination, member)
+	c.process(ctx, cmd)
+	return cmd
 }
 
 // SPop `SPOP key` command.
 func (c *Redis) SPop(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(c, "spop", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 // SPOP `SPOP ctx context.Context, key count` command.
 func (c *Redis) SPopN(ctx context.Context, key string, count int64) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "spop", key, count)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 // SRandMember `SRANDMEMBER key` command.
 func (c *Redis) SRandMember(ctx context.Context, key string) *StringCmd {
 	cmd := NewStringCmd(c, "srandmember", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 // SRandMemberN `SRANDMEMBER ctx context.Context, key count` command.
 func (c *Redis) SRandMemberN(ctx context.Context, key string, count int64) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "srandmember", key, count)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -534,7 +846,7 @@ func (c *Redis) SRem(ctx context.Context, key string, members ...interface{}) *I
 	args[0] = key
 	args = append(args, members...)
 	cmd := NewIntCmd(c, "srem", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -544,7 +856,7 @@ func (c *Redis) SUnion(ctx context.Context, keys ...string) *StrSliceCmd {
 		args[i] = key
 	}
 	cmd := NewStrSliceCmd(c, "sunion", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -555,7 +867,7 @@ func (c *Redis) SUnionStore(destination string, ctx context.Context, keys ...str
 		args[1+i] = key
 	}
 	cmd := NewIntCmd(c, "sunionstore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -568,7 +880,7 @@ func (c *Redis) executeZSetIntCmd(ctx context.Context, name string, key string,
 	}
 
 	cmd := NewIntCmd(c, name, l...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -581,7 +893,7 @@ func (c *Redis) executeZSetFloatCmd(ctx context.Context, name string, key string
 	}
 
 	cmd := NewFloatCmd(c, name, l...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -632,19 +944,19 @@ func (c *Redis) ZIncrXX(ctx context.Context, key string, member *Z) *FloatCmd {
 
 func (c *Redis) ZCard(ctx context.Context, key string) *IntCmd {
 	cmd := NewIntCmd(c, "zcard", key)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZCount(ctx context.Context, key, min, max string) *IntCmd {
 	cmd := NewIntCmd(c, "zcount", key, min, max)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZIncrBy(ctx context.Context, key string, increment float64, member string) *FloatCmd {
 	cmd := NewFloatCmd(c, "zincrby", key, increment, member)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -665,7 +977,7 @@ func (c *Redis) ZInterStore(ctx context.Context, destination string, store *ZSto
 		args = append(args, "aggregate", store.Aggregate)
 	}
 	cmd := NewIntCmd(c, "zinterstore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -675,7 +987,7 @@ func (c *Redis) zRange(ctx context.Context, key string, start, stop int64, withS
 		args = append(args, "withscores")
 	}
 	cmd := NewStrSliceCmd(c, "zrange", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -685,7 +997,7 @@ func (c *Redis) ZRange(ctx context.Context, key string, start, stop int64) *StrS
 
 func (c *Redis) ZRangeWithScores(ctx context.Context, key string, start, stop int64) *ZSliceCmd {
 	cmd := NewZSliceCmd(c, "zrange", key, start, stop, "withscores")
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -698,7 +1010,7 @@ func (c *Redis) zRangeBy(ctx context.Context, zcmd string, key string, opt *ZRan
 		args = append(args, "limit", opt.Offset, opt.Count)
 	}
 	cmd := NewStrSliceCmd(c, zcmd, args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -712,46 +1024,46 @@ func (c *Redis) ZRangeByScoreWithScores(ctx context.Context, key string, opt *ZR
 		args = append(args, "limit", opt.Offset, opt.Count)
 	}
 	cmd := NewZSliceCmd(c, "zrangebyscore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRank(ctx context.Context, key, member string) *IntCmd {
 	cmd := NewIntCmd(c, "zrank", key, member)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRem(ctx context.Context, key string, members ...interface{}) *IntCmd {
-	args := make([]interface{}, 2, 2+len(members))
+	args := make([]interface{}, 1, 1+len(members))
 	args[0] = key
 	args = appendArgs(args, members)
 	cmd := NewIntCmd(c, "zrem", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRemRangeByRank(ctx context.Context, key string, start, stop int64) *IntCmd {
 	cmd := NewIntCmd(c, "zremrangebyrank", key, start, stop)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRemRangeByScore(ctx context.Context, key, min, max string) *IntCmd {
 	cmd := NewIntCmd(c, "zremrangebyscore", key, min, max)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRevRange(ctx context.Context, key string, start, stop int64) *StrSliceCmd {
 	cmd := NewStrSliceCmd(c, "zrevrange", key, start, stop)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) *ZSliceCmd {
 	cmd := NewZSliceCmd(c, "zrevrange", key, start, stop, "withscores")
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -761,7 +1073,7 @@ func (c *Redis) zRevRangeBy(ctx context.Context, zcmd, key string, opt *ZRangeBy
 		args = append(args, "limit", opt.Offset, opt.Count)
 	}
 	cmd := NewStrSliceCmd(c, zcmd, args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -770,24 +1082,24 @@ func (c *Redis) ZRevRangeByScore(ctx context.Context, key string, opt *ZRangeBy)
 }
 
 func (c *Redis) ZRevRangeByScoreWithScores(ctx context.Context, key string, opt *ZRangeBy) *ZSliceCmd {
-	args := []interface{}{key, opt.Min, opt.Max, "withscores"}
+	args := []interface{}{key, opt.Max, opt.Min, "withscores"}
 	if opt.Offset != 0 || opt.Count != 0 {
 		args = append(args, "limit", opt.Offset, opt.Count)
 	}
 	cmd := NewZSliceCmd(c, "zrevrangebyscore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZRevRank(ctx context.Context, key, member string) *IntCmd {
 	cmd := NewIntCmd(c, "zrevrank", key, member)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
 func (c *Redis) ZScore(ctx context.Context, key, member string) *FloatCmd {
 	cmd := NewFloatCmd(c, "zscore", key, member)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -809,10 +1121,194 @@ func (c *Redis) ZUnionStore(ctx context.Context, dest string, store *ZStore) *In
 	}
 
 	cmd := NewIntCmd(c, "zunionstore", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZPopMin pops the count members, 1 by default, with the lowest scores.
+func (c *Redis) ZPopMin(ctx context.Context, key string, count ...int64) *ZSliceCmd {
+	return c.zPop(ctx, "zpopmin", key, count)
+}
+
+// ZPopMax pops the count members, 1 by default, with the highest scores.
+func (c *Redis) ZPopMax(ctx context.Context, key string, count ...int64) *ZSliceCmd {
+	return c.zPop(ctx, "zpopmax", key, count)
+}
+
+func (c *Redis) zPop(ctx context.Context, name, key string, count []int64) *ZSliceCmd {
+	args := []interface{}{key}
+	switch len(count) {
+	case 0:
+	case 1:
+		args = append(args, count[0])
+	default:
+		cmd := NewZSliceCmd(c, name, args...)
+		cmd.err = cExceptions.InvalidParamError("[Redis] %s takes at most one count, got %d", name, len(count))
+		c.process(ctx, cmd)
+		return cmd
+	}
+	cmd := NewZSliceCmd(c, name, args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// BZPopMin pops the member with the lowest score of the first non empty sorted set of keys,
+// waiting for one up to timeout as BLPop does. It returns Nil when the wait times out.
+func (c *Redis) BZPopMin(ctx context.Context, timeout time.Duration, keys ...string) *ZWithKeyCmd {
+	return c.bzPop(ctx, "bzpopmin", timeout, keys)
+}
+
+// BZPopMax is BZPopMin popping the member with the highest score.
+func (c *Redis) BZPopMax(ctx context.Context, timeout time.Duration, keys ...string) *ZWithKeyCmd {
+	return c.bzPop(ctx, "bzpopmax", timeout, keys)
+}
+
+func (c *Redis) bzPop(ctx context.Context, name string, timeout time.Duration, keys []string) *ZWithKeyCmd {
+	args := make([]interface{}, len(keys), len(keys)+1)
+	for i, key := range keys {
+		args[i] = key
+	}
+	sec, err := c.blockSeconds(ctx, timeout)
+	cmd := NewZWithKeyCmd(c, name, append(args, sec)...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZRangeByLex returns the members between opt.Min and opt.Max, e.g. "[a" and "(c" or "-"
+// and "+", of a sorted set whose members all have the same score.
+func (c *Redis) ZRangeByLex(ctx context.Context, key string, opt *ZRangeBy) *StrSliceCmd {
+	return c.zRangeBy(ctx, "zrangebylex", key, opt, false)
+}
+
+func (c *Redis) ZRevRangeByLex(ctx context.Context, key string, opt *ZRangeBy) *StrSliceCmd {
+	return c.zRevRangeBy(ctx, "zrevrangebylex", key, opt)
+}
+
+func (c *Redis) ZLexCount(ctx context.Context, key, min, max string) *IntCmd {
+	cmd := NewIntCmd(c, "zlexcount", key, min, max)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ZRemRangeByLex(ctx context.Context, key, min, max string) *IntCmd {
+	cmd := NewIntCmd(c, "zremrangebylex", key, min, max)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZRandMember returns count distinct random members, or -count members that may repeat
+// when count is negative.
+func (c *Redis) ZRandMember(ctx context.Context, key string, count int) *StrSliceCmd {
+	cmd := NewStrSliceCmd(c, "zrandmember", key, count)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ZRandMemberWithScores(ctx context.Context, key string, count int) *ZSliceCmd {
+	cmd := NewZSliceCmd(c, "zrandmember", key, count, "withscores")
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZMScore returns the scores of members, 0 for the members that do not exist.
+func (c *Redis) ZMScore(ctx context.Context, key string, members ...string) *FloatSliceCmd {
+	args := make([]interface{}, 1+len(members))
+	args[0] = key
+	for i, member := range members {
+		args[1+i] = member
+	}
+	cmd := NewFloatSliceCmd(c, "zmscore", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZRangeStore stores the members of z.Key within the range of z to dst, and returns their number.
+func (c *Redis) ZRangeStore(ctx context.Context, dst string, z ZRangeArgs) *IntCmd {
+	args := []interface{}{dst, z.Key, z.Start, z.Stop}
+	switch {
+	case z.ByScore:
+		args = append(args, "byscore")
+	case z.ByLex:
+		args = append(args, "bylex")
+	}
+	if z.Rev {
+		args = append(args, "rev")
+	}
+	if z.Offset != 0 || z.Count != 0 {
+		args = append(args, "limit", z.Offset, z.Count)
+	}
+	cmd := NewIntCmd(c, "zrangestore", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZDiff returns the members of the first of keys that are in none of the others.
+func (c *Redis) ZDiff(ctx context.Context, keys ...string) *StrSliceCmd {
+	cmd := NewStrSliceCmd(c, "zdiff", zDiffArgs(keys)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ZDiffWithScores(ctx context.Context, keys ...string) *ZSliceCmd {
+	cmd := NewZSliceCmd(c, "zdiff", append(zDiffArgs(keys), "withscores")...)
+	c.process(ctx, cmd)
 	return cmd
 }
 
+func zDiffArgs(keys []string) []interface{} {
+	args := make([]interface{}, 1+len(keys))
+	args[0] = len(keys)
+	for i, key := range keys {
+		args[1+i] = key
+	}
+	return args
+}
+
+// ZInter returns the members of all of store.Keys, as ZInterStore would store them.
+func (c *Redis) ZInter(ctx context.Context, store *ZStore) *StrSliceCmd {
+	cmd := NewStrSliceCmd(c, "zinter", zStoreArgs(nil, store)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ZInterWithScores(ctx context.Context, store *ZStore) *ZSliceCmd {
+	cmd := NewZSliceCmd(c, "zinter", append(zStoreArgs(nil, store), "withscores")...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZUnion returns the members of any of store.Keys, as ZUnionStore would store them.
+func (c *Redis) ZUnion(ctx context.Context, store *ZStore) *StrSliceCmd {
+	cmd := NewStrSliceCmd(c, "zunion", zStoreArgs(nil, store)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ZUnionWithScores(ctx context.Context, store *ZStore) *ZSliceCmd {
+	cmd := NewZSliceCmd(c, "zunion", append(zStoreArgs(nil, store), "withscores")...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// zStoreArgs returns the arguments of the ZINTER and ZUNION commands, appended to args.
+func zStoreArgs(args []interface{}, store *ZStore) []interface{} {
+	args = append(args, len(store.Keys))
+	for _, key := range store.Keys {
+		args = append(args, key)
+	}
+	if len(store.Weights) > 0 {
+		args = append(args, "weights")
+		for _, weight := range store.Weights {
+			args = append(args, weight)
+		}
+	}
+	if store.Aggregate != "" {
+		args = append(args, "aggregate", store.Aggregate)
+	}
+	return args
+}
+
 //------------------------------------------------------------------------------
 // HyperLogLog
 
@@ -821,7 +1317,7 @@ func (c *Redis) PFAdd(ctx context.Context, key string, els ...interface{}) *IntC
 	args[0] = key
 	args = appendArgs(args, els)
 	cmd := NewIntCmd(c, "pfadd", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -831,7 +1327,7 @@ func (c *Redis) PFCount(ctx context.Context, keys ...string) *IntCmd {
 		args[i] = key
 	}
 	cmd := NewIntCmd(c, "pfcount", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
 	return cmd
 }
 
@@ -842,6 +1338,672 @@ func (c *Redis) PFMerge(ctx context.Context, dest string, keys ...string) *Statu
 		args[1+i] = key
 	}
 	cmd := NewStatusCmd(c, "pfmerge", args...)
-	cmd.request(ctx)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+//------------------------------------------------------------------------------
+// Scripting
+
+func (c *Redis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *Cmd {
+	cmd := NewCmd(c, "eval", evalArgs(script, keys, args)...)
+	c.process(ctx, cmd)
 	return cmd
 }
+
+func (c *Redis) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *Cmd {
+	cmd := NewCmd(c, "evalsha", evalArgs(sha1, keys, args)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func evalArgs(script string, keys []string, args []interface{}) []interface{} {
+	res := make([]interface{}, 2, 2+len(keys)+len(args))
+	res[0] = script
+	res[1] = len(keys)
+	for _, key := range keys {
+		res = append(res, key)
+	}
+	return appendArgs(res, args)
+}
+
+func (c *Redis) ScriptExists(ctx context.Context, hashes ...string) *BoolSliceCmd {
+	args := make([]interface{}, 1+len(hashes))
+	args[0] = "exists"
+	for i, hash := range hashes {
+		args[1+i] = hash
+	}
+	cmd := NewBoolSliceCmd(c, "script", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) ScriptLoad(ctx context.Context, script string) *StringCmd {
+	cmd := NewStringCmd(c, "script", "load", script)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+//------------------------------------------------------------------------------
+// Scanning
+
+// Scan returns a page of the keys matching match, "" matching all the keys. count is a hint of
+// the number of keys a page holds, 0 leaving it to the server. Start with cursor 0, then
+// send the cursor of the previous page until it is 0, or use ScanCmd.Iterator.
+func (c *Redis) Scan(ctx context.Context, cursor uint64, match string, count int64) *ScanCmd {
+	cmd := NewScanCmd(c, "scan", scanArgs([]interface{}{cursor}, match, count)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ScanType is Scan returning only the keys holding a value of keyType, e.g. "hash".
+func (c *Redis) ScanType(ctx context.Context, cursor uint64, match string, count int64, keyType string) *ScanCmd {
+	args := scanArgs([]interface{}{cursor}, match, count)
+	if keyType != "" {
+		args = append(args, "type", keyType)
+	}
+	cmd := NewScanCmd(c, "scan", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// HScan is Scan over the fields of a hash, the page alternating fields and values.
+func (c *Redis) HScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd {
+	cmd := NewScanCmd(c, "hscan", scanArgs([]interface{}{key, cursor}, match, count)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// SScan is Scan over the members of a set.
+func (c *Redis) SScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd {
+	cmd := NewScanCmd(c, "sscan", scanArgs([]interface{}{key, cursor}, match, count)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// ZScan is Scan over the members of a sorted set, the page alternating members and scores.
+func (c *Redis) ZScan(ctx context.Context, key string, cursor uint64, match string, count int64) *ScanCmd {
+	cmd := NewScanCmd(c, "zscan", scanArgs([]interface{}{key, cursor}, match, count)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func scanArgs(args []interface{}, match string, count int64) []interface{} {
+	if match != "" {
+		args = append(args, "match", match)
+	}
+	if count > 0 {
+		args = append(args, "count", count)
+	}
+	return args
+}
+
+//------------------------------------------------------------------------------
+// Streams
+
+// XAdd appends a message to a stream and returns its ID.
+func (c *Redis) XAdd(ctx context.Context, a *XAddArgs) *StringCmd {
+	args := make([]interface{}, 0, 11)
+	args = append(args, a.Stream)
+	if a.NoMkStream {
+		args = append(args, "nomkstream")
+	}
+	switch {
+	case a.MaxLen > 0:
+		args = appendTrimArgs(args, "maxlen", a.MaxLen, a.Approx, a.Limit)
+	case a.MinID != "":
+		args = appendTrimArgs(args, "minid", a.MinID, a.Approx, a.Limit)
+	}
+	if a.ID != "" {
+		args = append(args, a.ID)
+	} else {
+		args = append(args, "*")
+	}
+	args = appendArg(args, a.Values)
+
+	cmd := NewStringCmd(c, "xadd", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func appendTrimArgs(args []interface{}, strategy string, threshold interface{}, approx bool, limit int64) []interface{} {
+	args = append(args, strategy)
+	if approx {
+		args = append(args, "~")
+	}
+	args = append(args, threshold)
+	if approx && limit > 0 {
+		args = append(args, "limit", limit)
+	}
+	return args
+}
+
+func (c *Redis) XDel(ctx context.Context, stream string, ids ...string) *IntCmd {
+	args := make([]interface{}, 1+len(ids))
+	args[0] = stream
+	for i, id := range ids {
+		args[1+i] = id
+	}
+	cmd := NewIntCmd(c, "xdel", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XLen(ctx context.Context, stream string) *IntCmd {
+	cmd := NewIntCmd(c, "xlen", stream)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XRange returns the messages with an ID between start and stop, "-" and "+" being the
+// smallest and the greatest IDs.
+func (c *Redis) XRange(ctx context.Context, stream, start, stop string) *XMessageSliceCmd {
+	cmd := NewXMessageSliceCmd(c, "xrange", stream, start, stop)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XRangeN(ctx context.Context, stream, start, stop string, count int64) *XMessageSliceCmd {
+	cmd := NewXMessageSliceCmd(c, "xrange", stream, start, stop, "count", count)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XRevRange is XRange in reverse order, from start the greatest ID down to stop.
+func (c *Redis) XRevRange(ctx context.Context, stream, start, stop string) *XMessageSliceCmd {
+	cmd := NewXMessageSliceCmd(c, "xrevrange", stream, start, stop)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XRevRangeN(ctx context.Context, stream, start, stop string, count int64) *XMessageSliceCmd {
+	cmd := NewXMessageSliceCmd(c, "xrevrange", stream, start, stop, "count", count)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XRead returns the messages following the given IDs, or Nil when there are none.
+func (c *Redis) XRead(ctx context.Context, a *XReadArgs) *XStreamSliceCmd {
+	args := make([]interface{}, 0, 5+len(a.Streams))
+	if a.Count > 0 {
+		args = append(args, "count", a.Count)
+	}
+	var err error
+	if a.Block > 0 {
+		var block time.Duration
+		block, err = c.blockTimeout(ctx, a.Block)
+		args = append(args, "block", formatMils(block))
+	}
+	args = append(args, "streams")
+	for _, s := range a.Streams {
+		args = append(args, s)
+	}
+	cmd := NewXStreamSliceCmd(c, "xread", args...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XReadStreams reads streams, which lists the streams followed by their IDs.
+func (c *Redis) XReadStreams(ctx context.Context, streams ...string) *XStreamSliceCmd {
+	return c.XRead(ctx, &XReadArgs{Streams: streams})
+}
+
+// XTrimMaxLen evicts the oldest messages of stream to keep maxLen of them.
+func (c *Redis) XTrimMaxLen(ctx context.Context, stream string, maxLen int64) *IntCmd {
+	return c.xTrim(ctx, stream, "maxlen", maxLen, false, 0)
+}
+
+// XTrimMaxLenApprox is XTrimMaxLen allowing redis to keep a few more messages, which is
+// faster, evicting at most limit messages when limit > 0.
+func (c *Redis) XTrimMaxLenApprox(ctx context.Context, stream string, maxLen, limit int64) *IntCmd {
+	return c.xTrim(ctx, stream, "maxlen", maxLen, true, limit)
+}
+
+// XTrimMinID evicts the messages of stream whose ID is lower than minID.
+func (c *Redis) XTrimMinID(ctx context.Context, stream string, minID string) *IntCmd {
+	return c.xTrim(ctx, stream, "minid", minID, false, 0)
+}
+
+func (c *Redis) XTrimMinIDApprox(ctx context.Context, stream string, minID string, limit int64) *IntCmd {
+	return c.xTrim(ctx, stream, "minid", minID, true, limit)
+}
+
+func (c *Redis) xTrim(ctx context.Context, stream, strategy string, threshold interface{}, approx bool, limit int64) *IntCmd {
+	args := appendTrimArgs([]interface{}{stream}, strategy, threshold, approx, limit)
+	cmd := NewIntCmd(c, "xtrim", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XGroupCreate creates a consumer group reading stream after start, "$" meaning the
+// messages added from now on and "0" all the messages.
+func (c *Redis) XGroupCreate(ctx context.Context, stream, group, start string) *StatusCmd {
+	cmd := NewStatusCmd(c, "xgroup", "create", stream, group, start)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XGroupCreateMkStream is XGroupCreate creating the stream when it does not exist.
+func (c *Redis) XGroupCreateMkStream(ctx context.Context, stream, group, start string) *StatusCmd {
+	cmd := NewStatusCmd(c, "xgroup", "create", stream, group, start, "mkstream")
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XGroupSetID(ctx context.Context, stream, group, start string) *StatusCmd {
+	cmd := NewStatusCmd(c, "xgroup", "setid", stream, group, start)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XGroupDestroy(ctx context.Context, stream, group string) *IntCmd {
+	cmd := NewIntCmd(c, "xgroup", "destroy", stream, group)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XGroupDelConsumer deletes a consumer of group and returns the number of its pending messages,
+// which are not pending anymore.
+func (c *Redis) XGroupDelConsumer(ctx context.Context, stream, group, consumer string) *IntCmd {
+	cmd := NewIntCmd(c, "xgroup", "delconsumer", stream, group, consumer)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XReadGroup reads streams as consumer of group. The messages read with ">" are pending until
+// acknowledged with XAck, unless NoAck is set.
+func (c *Redis) XReadGroup(ctx context.Context, a *XReadGroupArgs) *XStreamSliceCmd {
+	args := make([]interface{}, 0, 9+len(a.Streams))
+	args = append(args, "group", a.Group, a.Consumer)
+	if a.Count > 0 {
+		args = append(args, "count", a.Count)
+	}
+	var err error
+	if a.Block > 0 {
+		var block time.Duration
+		block, err = c.blockTimeout(ctx, a.Block)
+		args = append(args, "block", formatMils(block))
+	}
+	if a.NoAck {
+		args = append(args, "noack")
+	}
+	args = append(args, "streams")
+	for _, s := range a.Streams {
+		args = append(args, s)
+	}
+	cmd := NewXStreamSliceCmd(c, "xreadgroup", args...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func (c *Redis) XAck(ctx context.Context, stream, group string, ids ...string) *IntCmd {
+	args := make([]interface{}, 2+len(ids))
+	args[0] = stream
+	args[1] = group
+	for i, id := range ids {
+		args[2+i] = id
+	}
+	cmd := NewIntCmd(c, "xack", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XPending summarizes the messages delivered to group and not acknowledged yet.
+func (c *Redis) XPending(ctx context.Context, stream, group string) *XPendingCmd {
+	cmd := NewXPendingCmd(c, "xpending", stream, group)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XPendingExt lists the messages delivered to group and not acknowledged yet.
+func (c *Redis) XPendingExt(ctx context.Context, a *XPendingExtArgs) *XPendingExtCmd {
+	args := make([]interface{}, 0, 9)
+	args = append(args, a.Stream, a.Group)
+	if a.Idle > 0 {
+		args = append(args, "idle", formatMils(a.Idle))
+	}
+	args = append(args, a.Start, a.End, a.Count)
+	if a.Consumer != "" {
+		args = append(args, a.Consumer)
+	}
+	cmd := NewXPendingExtCmd(c, "xpending", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XClaim transfers to consumer the pending messages idle for at least MinIdle, and
+// returns them.
+func (c *Redis) XClaim(ctx context.Context, a *XClaimArgs) *XMessageSliceCmd {
+	cmd := NewXMessageSliceCmd(c, "xclaim", xClaimArgs(a)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// XClaimJustID is XClaim returning the IDs of the messages only.
+func (c *Redis) XClaimJustID(ctx context.Context, a *XClaimArgs) *StrSliceCmd {
+	args := append(xClaimArgs(a), "justid")
+	cmd := NewStrSliceCmd(c, "xclaim", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func xClaimArgs(a *XClaimArgs) []interface{} {
+	args := make([]interface{}, 0, 5+len(a.Messages))
+	args = append(args, a.Stream, a.Group, a.Consumer, formatMils(a.MinIdle))
+	for _, id := range a.Messages {
+		args = append(args, id)
+	}
+	return args
+}
+
+//------------------------------------------------------------------------------
+// Geo
+
+func (c *Redis) GeoAdd(ctx context.Context, key string, geoLocation ...*GeoLocation) *IntCmd {
+	args := make([]interface{}, 1, 1+3*len(geoLocation))
+	args[0] = key
+	for _, eachLoc := range geoLocation {
+		args = append(args, eachLoc.Longitude, eachLoc.Latitude, eachLoc.Name)
+	}
+	cmd := NewIntCmd(c, "geoadd", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoPos returns the positions of members, nil for the members that do not exist.
+func (c *Redis) GeoPos(ctx context.Context, key string, members ...string) *GeoPosCmd {
+	cmd := NewGeoPosCmd(c, "geopos", appendArg([]interface{}{key}, members)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoDist returns the distance between two members in unit, km by default, or Nil when one
+// of them does not exist.
+func (c *Redis) GeoDist(ctx context.Context, key string, member1, member2, unit string) *FloatCmd {
+	cmd := NewFloatCmd(c, "geodist", key, member1, member2, geoUnit(unit))
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoHash returns the geohash strings of members, "" for the members that do not exist.
+func (c *Redis) GeoHash(ctx context.Context, key string, members ...string) *StrSliceCmd {
+	cmd := NewStrSliceCmd(c, "geohash", appendArg([]interface{}{key}, members)...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoRadius returns the members within query.Radius of a position. query.Store and
+// query.StoreDist are not allowed, see GeoRadiusStore.
+func (c *Redis) GeoRadius(ctx context.Context, key string, longitude, latitude float64, query *GeoRadiusQuery) *GeoLocationCmd {
+	cmd := NewGeoLocationCmd(c, "georadius", geoLocationArgs(query, key, longitude, latitude)...)
+	cmd.err = geoStoreErr("GeoRadius", query, false)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoRadiusStore stores the members within query.Radius of a position to query.Store or
+// query.StoreDist, and returns their number.
+func (c *Redis) GeoRadiusStore(ctx context.Context, key string, longitude, latitude float64, query *GeoRadiusQuery) *IntCmd {
+	cmd := NewIntCmd(c, "georadius", geoLocationArgs(query, key, longitude, latitude)...)
+	cmd.err = geoStoreErr("GeoRadiusStore", query, true)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoRadiusByMember is GeoRadius around the position of member.
+func (c *Redis) GeoRadiusByMember(ctx context.Context, key, member string, query *GeoRadiusQuery) *GeoLocationCmd {
+	cmd := NewGeoLocationCmd(c, "georadiusbymember", geoLocationArgs(query, key, member)...)
+	cmd.err = geoStoreErr("GeoRadiusByMember", query, false)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoRadiusByMemberStore is GeoRadiusStore around the position of member.
+func (c *Redis) GeoRadiusByMemberStore(ctx context.Context, key, member string, query *GeoRadiusQuery) *IntCmd {
+	cmd := NewIntCmd(c, "georadiusbymember", geoLocationArgs(query, key, member)...)
+	cmd.err = geoStoreErr("GeoRadiusByMemberStore", query, true)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// geoStoreErr checks that query stores the members for the Store methods, and does not for
+// the others.
+func geoStoreErr(method string, query *GeoRadiusQuery, store bool) error {
+	stores := query.Store != "" || query.StoreDist != ""
+	if store && !stores {
+		return cExceptions.InvalidParamError("[Redis] %s requires Store or StoreDist", method)
+	}
+	if !store && stores {
+		return cExceptions.InvalidParamError("[Redis] %s does not support Store or StoreDist, use %sStore", method, method)
+	}
+	return nil
+}
+
+func geoLocationArgs(q *GeoRadiusQuery, args ...interface{}) []interface{} {
+	args = append(args, q.Radius, geoUnit(q.Unit))
+	if q.WithCoord {
+		args = append(args, "withcoord")
+	}
+	if q.WithDist {
+		args = append(args, "withdist")
+	}
+	if q.WithGeoHash {
+		args = append(args, "withhash")
+	}
+	if q.Count > 0 {
+		args = append(args, "count", q.Count)
+	}
+	if q.Sort != "" {
+		args = append(args, q.Sort)
+	}
+	if q.Store != "" {
+		args = append(args, "store", q.Store)
+	}
+	if q.StoreDist != "" {
+		args = append(args, "storedist", q.StoreDist)
+	}
+	return args
+}
+
+// GeoSearch returns the names of the members matching q.
+func (c *Redis) GeoSearch(ctx context.Context, key string, q *GeoSearchQuery) *StrSliceCmd {
+	args := geoSearchArgs(q, []interface{}{key})
+	cmd := NewStrSliceCmd(c, "geosearch", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoSearchLocation returns the members matching q, with the fields q asks for.
+func (c *Redis) GeoSearchLocation(ctx context.Context, key string, q *GeoSearchLocationQuery) *GeoLocationCmd {
+	args := geoSearchArgs(&q.GeoSearchQuery, []interface{}{key})
+	if q.WithCoord {
+		args = append(args, "withcoord")
+	}
+	if q.WithDist {
+		args = append(args, "withdist")
+	}
+	if q.WithHash {
+		args = append(args, "withhash")
+	}
+	cmd := NewGeoLocationCmd(c, "geosearch", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// GeoSearchStore stores the members of key matching q to store, and returns their number.
+func (c *Redis) GeoSearchStore(ctx context.Context, key, store string, q *GeoSearchStoreQuery) *IntCmd {
+	args := geoSearchArgs(&q.GeoSearchQuery, []interface{}{store, key})
+	if q.StoreDist {
+		args = append(args, "storedist")
+	}
+	cmd := NewIntCmd(c, "geosearchstore", args...)
+	c.process(ctx, cmd)
+	return cmd
+}
+
+func geoUnit(unit string) string {
+	if unit == "" {
+		return "km"
+	}
+	return unit
+}
+
+func geoSearchArgs(q *GeoSearchQuery, args []interface{}) []interface{} {
+	if q.Member != "" {
+		args = append(args, "frommember", q.Member)
+	} else {
+		args = append(args, "fromlonlat", q.Longitude, q.Latitude)
+	}
+
+	if q.Radius > 0 {
+		args = append(args, "byradius", q.Radius, geoUnit(q.RadiusUnit))
+	} else {
+		args = append(args, "bybox", q.BoxWidth, q.BoxHeight, geoUnit(q.BoxUnit))
+	}
+
+	if q.Sort != "" {
+		args = append(args, q.Sort)
+	}
+	if q.Count > 0 {
+		args = append(args, "count", q.Count)
+		if q.CountAny {
+			args = append(args, "any")
+		}
+	}
+	return args
+}
+
+//------------------------------------------------------------------------------
+// Codec
+
+func (c *Redis) getCodec() Codec {
+	if c == nil || c.codec == nil {
+		return JSONCodec{}
+	}
+	return c.codec
+}
+
+func (c *Redis) marshal(v interface{}) (string, error) {
+	data, err := c.getCodec().Marshal(v)
+	if err != nil {
+		return "", cExceptions.InvalidParamError("[Redis] marshal value failed, err: %v", err)
+	}
+	return string(data), nil
+}
+
+// notQueued fails in a pipeline, where the commands are queued and have no value yet.
+func (c *Redis) notQueued(method string) error {
+	if c != nil && c.queue != nil {
+		return cExceptions.InvalidParamError("[Redis] %s cannot be used in a pipeline", method)
+	}
+	return nil
+}
+
+// unmarshal decodes the value read by cmd into dst.
+func (c *Redis) unmarshal(cmd *StringCmd, dst interface{}) error {
+	val, err := cmd.Result()
+	if err != nil {
+		return err
+	}
+	if err := c.getCodec().Unmarshal([]byte(val), dst); err != nil {
+		return cExceptions.InternalError("[Redis] unmarshal value failed, err: %v", err)
+	}
+	return nil
+}
+
+// SetValue is Set storing v encoded by the codec of c.
+func (c *Redis) SetValue(ctx context.Context, key string, v interface{}, expiration time.Duration) *StatusCmd {
+	data, err := c.marshal(v)
+	if err != nil {
+		cmd := NewStatusCmd(c, "set", key, nil)
+		cmd.err = err
+		c.process(ctx, cmd)
+		return cmd
+	}
+	return c.Set(ctx, key, data, expiration)
+}
+
+// GetInto decodes the value of key, stored by SetValue, into dst. It returns Nil when key
+// does not exist. It cannot be used in a pipeline, whose commands are not sent yet.
+func (c *Redis) GetInto(ctx context.Context, key string, dst interface{}) error {
+	if err := c.notQueued("GetInto"); err != nil {
+		return err
+	}
+	return c.unmarshal(c.Get(ctx, key), dst)
+}
+
+// HSetValue is HSet storing v encoded by the codec of c.
+func (c *Redis) HSetValue(ctx context.Context, key, field string, v interface{}) *BoolCmd {
+	data, err := c.marshal(v)
+	if err != nil {
+		cmd := NewBoolCmd(c, "hset", key, field, nil)
+		cmd.err = err
+		c.process(ctx, cmd)
+		return cmd
+	}
+	return c.HSet(ctx, key, field, data)
+}
+
+// HGetInto decodes the value of field, stored by HSetValue, into dst. It returns Nil when
+// field does not exist. It cannot be used in a pipeline, whose commands are not sent yet.
+func (c *Redis) HGetInto(ctx context.Context, key, field string, dst interface{}) error {
+	if err := c.notQueued("HGetInto"); err != nil {
+		return err
+	}
+	return c.unmarshal(c.HGet(ctx, key, field), dst)
+}
+
+// LPushValue is LPush storing each of values encoded by the codec of c, a slice being a single
+// value.
+func (c *Redis) LPushValue(ctx context.Context, key string, values ...interface{}) *IntCmd {
+	return c.pushValues(ctx, "lpush", key, values)
+}
+
+// RPushValue is RPush storing each of values encoded by the codec of c, as LPushValue.
+func (c *Redis) RPushValue(ctx context.Context, key string, values ...interface{}) *IntCmd {
+	return c.pushValues(ctx, "rpush", key, values)
+}
+
+func (c *Redis) pushValues(ctx context.Context, name, key string, values []interface{}) *IntCmd {
+	args := make([]interface{}, 1, 1+len(values))
+	args[0] = key
+	var err error
+	for _, v := range values {
+		var data string
+		if data, err = c.marshal(v); err != nil {
+			break
+		}
+		args = append(args, data)
+	}
+	cmd := NewIntCmd(c, name, args...)
+	cmd.err = err
+	c.process(ctx, cmd)
+	return cmd
+}
+
+// LRangeInto decodes the elements of key between start and stop, stored by LPushValue or
+// RPushValue, into dst, a pointer to a slice. It cannot be used in a pipeline, whose commands
+// are not sent yet.
+func (c *Redis) LRangeInto(ctx context.Context, key string, start, stop int64, dst interface{}) error {
+	if err := c.notQueued("LRangeInto"); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return cExceptions.InvalidParamError("[Redis] LRangeInto expects a pointer to a slice, got %T", dst)
+	}
+	vals, err := c.LRange(ctx, key, start, stop).Result()
+	if err != nil {
+		return err
+	}
+	slice := reflect.MakeSlice(rv.Elem().Type(), len(vals), len(vals))
+	for i, val := range vals {
+		if err := c.getCodec().Unmarshal([]byte(val), slice.Index(i).Addr().Interface()); err != nil {
+			return cExceptions.InternalError("[Redis] unmarshal value failed, err: %v", err)
+		}
+	}
+	rv.Elem().Set(slice)
+	return nil
+}
@@ -0,0 +1,293 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(map[string]command{
+		"bitop":    {3, cmdBitOp},
+		"bitpos":   {2, cmdBitPos},
+		"bitfield": {1, cmdBitField},
+	})
+}
+
+func cmdBitOp(s *Server, _ string, args []string) (interface{}, error) {
+	op, keys := strings.ToLower(args[0]), args[2:]
+	if op == "not" && len(keys) != 1 {
+		return nil, replyError("ERR BITOP NOT must be called with a single source key.")
+	}
+	srcs := make([]string, len(keys))
+	size := 0
+	for i, key := range keys {
+		v, _, err := s.getString(key)
+		if err != nil {
+			return nil, err
+		}
+		srcs[i] = v
+		if len(v) > size {
+			size = len(v)
+		}
+	}
+
+	res := make([]byte, size)
+	for i := range res {
+		byteAt := func(v string) byte {
+			if i < len(v) {
+				return v[i]
+			}
+			return 0
+		}
+		switch op {
+		case "and":
+			res[i] = 0xff
+			for _, v := range srcs {
+				res[i] &= byteAt(v)
+			}
+		case "or":
+			for _, v := range srcs {
+				res[i] |= byteAt(v)
+			}
+		case "xor":
+			for _, v := range srcs {
+				res[i] ^= byteAt(v)
+			}
+		case "not":
+			res[i] = ^byteAt(srcs[0])
+		default:
+			return nil, errSyntax
+		}
+	}
+	if size == 0 {
+		delete(s.keys, args[1])
+	} else {
+		s.setString(args[1], string(res))
+	}
+	return int64(size), nil
+}
+
+func cmdBitPos(s *Server, _ string, args []string) (interface{}, error) {
+	if len(args) > 4 {
+		return nil, errSyntax
+	}
+	if args[1] != "0" && args[1] != "1" {
+		return nil, replyError("ERR The bit argument must be 1 or 0.")
+	}
+	bit := int(args[1][0] - '0')
+	v, ok, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if bit == 1 {
+			return int64(-1), nil
+		}
+		return int64(0), nil
+	}
+
+	start, end := int64(0), int64(len(v))-1
+	if len(args) > 2 {
+		if start, err = parseInt(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if len(args) > 3 {
+		if end, err = parseInt(args[3]); err != nil {
+			return nil, err
+		}
+	}
+	start, end, inRange := normRange(start, end, int64(len(v)))
+	if !inRange {
+		return int64(-1), nil
+	}
+	for offset := start * 8; offset < (end+1)*8; offset++ {
+		if getBit(v, offset) == bit {
+			return offset, nil
+		}
+	}
+	// As in redis, the string is padded with zeros on the right when no end is given.
+	if bit == 0 && len(args) < 4 {
+		return (end + 1) * 8, nil
+	}
+	return int64(-1), nil
+}
+
+// bitField is the type of a BITFIELD field, e.g. i8 or u16.
+type bitField struct {
+	signed bool
+	bits   uint
+}
+
+func parseBitField(t, offset string) (bitField, int64, error) {
+	var f bitField
+	t = strings.ToLower(t)
+	if len(t) < 2 || (t[0] != 'i' && t[0] != 'u') {
+		return f, 0, replyError("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	}
+	f.signed = t[0] == 'i'
+	n, err := strconv.Atoi(t[1:])
+	if err != nil || n < 1 || n > 64 || (!f.signed && n == 64) {
+		return f, 0, replyError("ERR Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+	}
+	f.bits = uint(n)
+
+	multiply := strings.HasPrefix(offset, "#")
+	if multiply {
+		offset = offset[1:]
+	}
+	o, err := parseBitOffset(offset)
+	if err != nil {
+		return f, 0, err
+	}
+	if multiply {
+		o *= int64(n)
+	}
+	return f, o, nil
+}
+
+func (f bitField) get(v []byte, offset int64) int64 {
+	var u uint64
+	for i := int64(0); i < int64(f.bits); i++ {
+		u <<= 1
+		if pos := offset + i; pos/8 < int64(len(v)) {
+			u |= uint64(v[pos/8]>>(7-uint(pos%8))) & 1
+		}
+	}
+	if f.signed && f.bits < 64 && u&(1<<(f.bits-1)) != 0 {
+		u |= ^uint64(0) << f.bits
+	}
+	return int64(u)
+}
+
+func (f bitField) set(v []byte, offset, value int64) []byte {
+	if need := int((offset+int64(f.bits)-1)/8) + 1; need > len(v) {
+		v = append(v, make([]byte, need-len(v))...)
+	}
+	u := uint64(value)
+	for i := int64(0); i < int64(f.bits); i++ {
+		pos := offset + i
+		mask := byte(1) << (7 - uint(pos%8))
+		if u&(1<<(f.bits-1-uint(i))) != 0 {
+			v[pos/8] |= mask
+		} else {
+			v[pos/8] &^= mask
+		}
+	}
+	return v
+}
+
+// fit brings n in the range of f according to the overflow mode, reporting false when the
+// mode is FAIL and n is out of range.
+func (f bitField) fit(n *big.Int, overflow string) (int64, bool) {
+	min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), f.bits)
+	if f.signed {
+		min.Neg(new(big.Int).Rsh(max, 1))
+		max.Rsh(max, 1)
+	}
+	max.Sub(max, big.NewInt(1))
+	if n.Cmp(min) >= 0 && n.Cmp(max) <= 0 {
+		return n.Int64(), true
+	}
+	switch overflow {
+	case "sat":
+		if n.Cmp(min) < 0 {
+			return min.Int64(), true
+		}
+		return max.Int64(), true
+	case "fail":
+		return 0, false
+	default:
+		size := new(big.Int).Lsh(big.NewInt(1), f.bits)
+		m := new(big.Int).Sub(n, min)
+		m.Mod(m, size)
+		return m.Add(m, min).Int64(), true
+	}
+}
+
+func cmdBitField(s *Server, _ string, args []string) (interface{}, error) {
+	// Parse all the operations first, as redis does not run any of them on a syntax error.
+	type op struct {
+		name     string
+		field    bitField
+		offset   int64
+		value    int64
+		overflow string
+	}
+	var ops []op
+	overflow, write := "wrap", false
+	for i := 1; i < len(args); {
+		name := strings.ToLower(args[i])
+		switch name {
+		case "overflow":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			overflow = strings.ToLower(args[i+1])
+			if overflow != "wrap" && overflow != "sat" && overflow != "fail" {
+				return nil, replyError("ERR Invalid OVERFLOW type specified")
+			}
+			i += 2
+		case "get", "set", "incrby":
+			n := 3
+			if name != "get" {
+				n = 4
+			}
+			if i+n > len(args) {
+				return nil, errSyntax
+			}
+			f, offset, err := parseBitField(args[i+1], args[i+2])
+			if err != nil {
+				return nil, err
+			}
+			o := op{name: name, field: f, offset: offset, overflow: overflow}
+			if name != "get" {
+				if o.value, err = parseInt(args[i+3]); err != nil {
+					return nil, err
+				}
+				write = true
+			}
+			ops = append(ops, o)
+			i += n
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	str, _, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	v := []byte(str)
+	res := make([]interface{}, len(ops))
+	for i, o := range ops {
+		old := o.field.get(v, o.offset)
+		switch o.name {
+		case "get":
+			res[i] = old
+		case "set":
+			if n, ok := o.field.fit(big.NewInt(o.value), o.overflow); ok {
+				v = o.field.set(v, o.offset, n)
+				res[i] = old
+			}
+		case "incrby":
+			sum := new(big.Int).Add(big.NewInt(old), big.NewInt(o.value))
+			if n, ok := o.field.fit(sum, o.overflow); ok {
+				v = o.field.set(v, o.offset, n)
+				res[i] = n
+			}
+		}
+	}
+	if write {
+		e, err := s.getOrCreate(args[0], kindString)
+		if err != nil {
+			return nil, err
+		}
+		e.setStr(string(v))
+	}
+	return res, nil
+}
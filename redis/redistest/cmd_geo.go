@@ -0,0 +1,483 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+func init() {
+	register(map[string]command{
+		"geoadd":            {4, cmdGeoAdd},
+		"geopos":            {1, cmdGeoPos},
+		"geodist":           {3, cmdGeoDist},
+		"geohash":           {1, cmdGeoHash},
+		"georadius":         {5, cmdGeoRadius},
+		"georadiusbymember": {4, cmdGeoRadius},
+		"geosearch":         {5, cmdGeoSearch},
+		"geosearchstore":    {6, cmdGeoSearch},
+	})
+}
+
+// Members of a geospatial index are sorted set members scored with the 52 bits geohash of
+// their position, as in redis.
+const (
+	geoStep      = 26
+	geoLatMax    = 85.05112878
+	geoLonMax    = 180.0
+	earthRadiusM = 6372797.560856
+)
+
+// geoLocation is a member as it is encoded in the replies decoded into redis.GeoLocation.
+type geoLocation struct {
+	Name                      string
+	Longitude, Latitude, Dist float64
+	GeoHash                   int64
+}
+
+type geoPos struct {
+	Longitude, Latitude float64
+}
+
+func geoEncode(lon, lat, latMax float64) uint64 {
+	latBits := uint64((lat + latMax) / (2 * latMax) * (1 << geoStep))
+	lonBits := uint64((lon + geoLonMax) / (2 * geoLonMax) * (1 << geoStep))
+	var hash uint64
+	for i := geoStep - 1; i >= 0; i-- {
+		hash = hash<<2 | (lonBits>>uint(i)&1)<<1 | latBits>>uint(i)&1
+	}
+	return hash
+}
+
+// geoDecode returns the center of the cell of hash.
+func geoDecode(hash uint64) (lon, lat float64) {
+	var latBits, lonBits uint64
+	for i := geoStep - 1; i >= 0; i-- {
+		latBits |= (hash >> uint(2*i) & 1) << uint(i)
+		lonBits |= (hash >> uint(2*i+1) & 1) << uint(i)
+	}
+	lat = -geoLatMax + (float64(latBits)+0.5)/(1<<geoStep)*2*geoLatMax
+	lon = -geoLonMax + (float64(lonBits)+0.5)/(1<<geoStep)*2*geoLonMax
+	return lon, lat
+}
+
+// geoDistance is the haversine distance in meters used by redis.
+func geoDistance(lon1, lat1, lon2, lat2 float64) float64 {
+	rad := math.Pi / 180
+	u := math.Sin((lat2 - lat1) * rad / 2)
+	v := math.Sin((lon2 - lon1) * rad / 2)
+	a := u*u + math.Cos(lat1*rad)*math.Cos(lat2*rad)*v*v
+	return 2 * earthRadiusM * math.Asin(math.Sqrt(a))
+}
+
+func geoUnit(unit string) (float64, error) {
+	switch strings.ToLower(unit) {
+	case "m":
+		return 1, nil
+	case "km":
+		return 1000, nil
+	case "mi":
+		return 1609.34, nil
+	case "ft":
+		return 0.3048, nil
+	}
+	return 0, replyError("ERR unsupported unit provided. please use M, KM, FT, MI")
+}
+
+func parseLonLat(lonArg, latArg string) (float64, float64, error) {
+	lon, err := parseFloat(lonArg)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err := parseFloat(latArg)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lon < -geoLonMax || lon > geoLonMax || lat < -geoLatMax || lat > geoLatMax {
+		return 0, 0, replyError("ERR invalid longitude,latitude pair " + lonArg + "," + latArg)
+	}
+	return lon, lat, nil
+}
+
+func cmdGeoAdd(s *Server, name string, args []string) (interface{}, error) {
+	if (len(args)-1)%3 != 0 {
+		return nil, errWrongArgs(name)
+	}
+	scores := make(map[string]float64)
+	var members []string
+	for i := 1; i < len(args); i += 3 {
+		lon, lat, err := parseLonLat(args[i], args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := scores[args[i+2]]; !ok {
+			members = append(members, args[i+2])
+		}
+		scores[args[i+2]] = float64(geoEncode(lon, lat, geoLatMax))
+	}
+
+	e, err := s.getOrCreate(args[0], kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, member := range members {
+		if _, ok := e.zset[member]; !ok {
+			n++
+		}
+		e.zset[member] = scores[member]
+	}
+	return n, nil
+}
+
+func cmdGeoPos(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*geoPos, len(args)-1)
+	for i, member := range args[1:] {
+		if e == nil {
+			continue
+		}
+		if score, ok := e.zset[member]; ok {
+			lon, lat := geoDecode(uint64(score))
+			res[i] = &geoPos{Longitude: lon, Latitude: lat}
+		}
+	}
+	return res, nil
+}
+
+func cmdGeoDist(s *Server, _ string, args []string) (interface{}, error) {
+	unit := "m"
+	if len(args) > 3 {
+		unit = args[3]
+	}
+	toUnit, err := geoUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return nil, err
+	}
+	score1, ok1 := e.zset[args[1]]
+	score2, ok2 := e.zset[args[2]]
+	if !ok1 || !ok2 {
+		return nil, nil
+	}
+	lon1, lat1 := geoDecode(uint64(score1))
+	lon2, lat2 := geoDecode(uint64(score2))
+	return roundDist(geoDistance(lon1, lat1, lon2, lat2) / toUnit), nil
+}
+
+// roundDist rounds a distance to 4 decimals, as redis replies with them.
+func roundDist(d float64) float64 {
+	return math.Round(d*10000) / 10000
+}
+
+const geoAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+func cmdGeoHash(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, len(args)-1)
+	for i, member := range args[1:] {
+		if e == nil {
+			continue
+		}
+		score, ok := e.zset[member]
+		if !ok {
+			continue
+		}
+		// The strings are the standard geohashes, whose latitudes range over [-90, 90].
+		lon, lat := geoDecode(uint64(score))
+		hash := geoEncode(lon, lat, 90)
+		buf := make([]byte, 11)
+		for j := range buf {
+			var idx uint64
+			if j < 10 {
+				idx = hash >> uint(52-(j+1)*5) & 0x1f
+			}
+			buf[j] = geoAlphabet[idx]
+		}
+		res[i] = string(buf)
+	}
+	return res, nil
+}
+
+// geoQuery is a GEORADIUS or GEOSEARCH query, the shape being a circle or a box.
+type geoQuery struct {
+	lon, lat          float64
+	radius            float64
+	width, height     float64
+	toUnit            float64
+	withCoord         bool
+	withDist          bool
+	withHash          bool
+	count             int64
+	any               bool
+	desc, sorted      bool
+	store             string
+	storeDistAsScores bool
+	// locations replies with geoLocations even without WITHCOORD, WITHDIST or WITHHASH.
+	locations bool
+}
+
+// center sets the center of q to the position of member.
+func (q *geoQuery) center(e *entry, member string) error {
+	if e == nil {
+		return nil
+	}
+	score, ok := e.zset[member]
+	if !ok {
+		return replyError("ERR could not decode requested zset member")
+	}
+	q.lon, q.lat = geoDecode(uint64(score))
+	return nil
+}
+
+func cmdGeoRadius(s *Server, name string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	q := &geoQuery{}
+	if name == "georadius" {
+		if q.lon, q.lat, err = parseLonLat(args[1], args[2]); err != nil {
+			return nil, err
+		}
+		args = args[3:]
+	} else {
+		if err = q.center(e, args[1]); err != nil {
+			return nil, err
+		}
+		args = args[2:]
+	}
+	q.locations = true
+	if len(args) < 2 {
+		return nil, errSyntax
+	}
+	if q.radius, err = parseFloat(args[0]); err != nil {
+		return nil, err
+	}
+	if q.toUnit, err = geoUnit(args[1]); err != nil {
+		return nil, err
+	}
+	if err = q.parseOptions(args[2:], true); err != nil {
+		return nil, err
+	}
+	return s.geoSearch(e, q)
+}
+
+func cmdGeoSearch(s *Server, name string, args []string) (interface{}, error) {
+	q := &geoQuery{}
+	if name == "geosearchstore" {
+		q.store = args[0]
+		args = args[1:]
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	args = args[1:]
+
+	var rest []string
+	for len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "frommember":
+			if len(args) < 2 {
+				return nil, errSyntax
+			}
+			if err = q.center(e, args[1]); err != nil {
+				return nil, err
+			}
+			args = args[2:]
+		case "fromlonlat":
+			if len(args) < 3 {
+				return nil, errSyntax
+			}
+			if q.lon, q.lat, err = parseLonLat(args[1], args[2]); err != nil {
+				return nil, err
+			}
+			args = args[3:]
+		case "byradius":
+			if len(args) < 3 {
+				return nil, errSyntax
+			}
+			if q.radius, err = parseFloat(args[1]); err != nil {
+				return nil, err
+			}
+			if q.toUnit, err = geoUnit(args[2]); err != nil {
+				return nil, err
+			}
+			args = args[3:]
+		case "bybox":
+			if len(args) < 4 {
+				return nil, errSyntax
+			}
+			if q.width, err = parseFloat(args[1]); err != nil {
+				return nil, err
+			}
+			if q.height, err = parseFloat(args[2]); err != nil {
+				return nil, err
+			}
+			if q.toUnit, err = geoUnit(args[3]); err != nil {
+				return nil, err
+			}
+			args = args[4:]
+		case "storedist":
+			if q.store == "" {
+				return nil, errSyntax
+			}
+			q.storeDistAsScores = true
+			args = args[1:]
+		default:
+			rest = append(rest, args[0])
+			args = args[1:]
+		}
+	}
+	if q.toUnit == 0 {
+		return nil, errSyntax
+	}
+	if err = q.parseOptions(rest, false); err != nil {
+		return nil, err
+	}
+	if q.store != "" && (q.withCoord || q.withDist || q.withHash) {
+		return nil, errSyntax
+	}
+	return s.geoSearch(e, q)
+}
+
+func (q *geoQuery) parseOptions(args []string, radius bool) error {
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "withcoord":
+			q.withCoord = true
+		case "withdist":
+			q.withDist = true
+		case "withhash":
+			q.withHash = true
+		case "asc":
+			q.sorted, q.desc = true, false
+		case "desc":
+			q.sorted, q.desc = true, true
+		case "any":
+			q.any = true
+		case "count":
+			if i+1 >= len(args) {
+				return errSyntax
+			}
+			n, err := parseInt(args[i+1])
+			if err != nil {
+				return err
+			}
+			if n <= 0 {
+				return replyError("ERR COUNT must be > 0")
+			}
+			q.count = n
+			i++
+		case "store", "storedist":
+			if !radius || i+1 >= len(args) {
+				return errSyntax
+			}
+			q.store = args[i+1]
+			q.storeDistAsScores = strings.ToLower(args[i]) == "storedist"
+			i++
+		default:
+			return errSyntax
+		}
+	}
+	if q.any && q.count == 0 {
+		return replyError("ERR the ANY argument requires COUNT argument")
+	}
+	return nil
+}
+
+// contains reports whether the position lon, lat is in the shape of q, and returns its
+// distance to the center in meters.
+func (q *geoQuery) contains(lon, lat float64) (float64, bool) {
+	dist := geoDistance(q.lon, q.lat, lon, lat)
+	if q.width == 0 {
+		return dist, dist <= q.radius*q.toUnit
+	}
+	if geoDistance(lon, q.lat, lon, lat) > q.height*q.toUnit/2 {
+		return dist, false
+	}
+	return dist, geoDistance(q.lon, lat, lon, lat) <= q.width*q.toUnit/2
+}
+
+func (s *Server) geoSearch(e *entry, q *geoQuery) (interface{}, error) {
+	var found []geoLocation
+	if e != nil {
+		for member, score := range e.zset {
+			lon, lat := geoDecode(uint64(score))
+			dist, ok := q.contains(lon, lat)
+			if !ok {
+				continue
+			}
+			found = append(found, geoLocation{Name: member, Longitude: lon, Latitude: lat, Dist: dist, GeoHash: int64(score)})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Dist != found[j].Dist {
+			return found[i].Dist < found[j].Dist
+		}
+		return found[i].Name < found[j].Name
+	})
+	if q.sorted && q.desc {
+		for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+			found[i], found[j] = found[j], found[i]
+		}
+	}
+	if q.count > 0 && int64(len(found)) > q.count {
+		found = found[:q.count]
+	}
+
+	if q.store != "" {
+		delete(s.keys, q.store)
+		if len(found) == 0 {
+			return int64(0), nil
+		}
+		dest, err := s.getOrCreate(q.store, kindZSet)
+		if err != nil {
+			return nil, err
+		}
+		for _, loc := range found {
+			if q.storeDistAsScores {
+				dest.zset[loc.Name] = loc.Dist / q.toUnit
+			} else {
+				dest.zset[loc.Name] = float64(loc.GeoHash)
+			}
+		}
+		return int64(len(found)), nil
+	}
+
+	if !q.locations && !q.withCoord && !q.withDist && !q.withHash {
+		names := make([]string, len(found))
+		for i, loc := range found {
+			names[i] = loc.Name
+		}
+		return names, nil
+	}
+	for i := range found {
+		loc := &found[i]
+		if q.withDist {
+			loc.Dist = roundDist(loc.Dist / q.toUnit)
+		} else {
+			loc.Dist = 0
+		}
+		if !q.withCoord {
+			loc.Longitude, loc.Latitude = 0, 0
+		}
+		if !q.withHash {
+			loc.GeoHash = 0
+		}
+	}
+	return found, nil
+}
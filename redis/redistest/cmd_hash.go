@@ -0,0 +1,217 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"math"
+	"sort"
+)
+
+func init() {
+	register(map[string]command{
+		"hset":         {3, cmdHSet},
+		"hmset":        {3, cmdHSet},
+		"hsetnx":       {3, cmdHSetNX},
+		"hget":         {2, cmdHGet},
+		"hmget":        {2, cmdHMGet},
+		"hgetall":      {1, cmdHGetAll},
+		"hdel":         {2, cmdHDel},
+		"hexists":      {2, cmdHExists},
+		"hlen":         {1, cmdHLen},
+		"hkeys":        {1, cmdHKeys},
+		"hvals":        {1, cmdHVals},
+		"hincrby":      {3, cmdHIncrBy},
+		"hincrbyfloat": {3, cmdHIncrByFloat},
+	})
+}
+
+func sortedFields(h map[string]string) []string {
+	res := make([]string, 0, len(h))
+	for k := range h {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func cmdHSet(s *Server, name string, args []string) (interface{}, error) {
+	if len(args)%2 != 1 {
+		return nil, errWrongArgs(name)
+	}
+	e, err := s.getOrCreate(args[0], kindHash)
+	if err != nil {
+		return nil, err
+	}
+	var added int64
+	for i := 1; i < len(args); i += 2 {
+		if _, ok := e.hash[args[i]]; !ok {
+			added++
+		}
+		e.hash[args[i]] = args[i+1]
+	}
+	if name == "hmset" {
+		return "OK", nil
+	}
+	return added, nil
+}
+
+func cmdHSetNX(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getOrCreate(args[0], kindHash)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := e.hash[args[1]]; ok {
+		return int64(0), nil
+	}
+	e.hash[args[1]] = args[2]
+	return int64(1), nil
+}
+
+// getHash returns the fields of key, nil when it does not exist.
+func (s *Server) getHash(key string) (map[string]string, error) {
+	e, err := s.getKind(key, kindHash)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return e.hash, nil
+}
+
+func cmdHGet(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := h[args[1]]; ok {
+		return v, nil
+	}
+	return nil, nil
+}
+
+func cmdHMGet(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, 0, len(args)-1)
+	for _, field := range args[1:] {
+		if v, ok := h[field]; ok {
+			res = append(res, v)
+		} else {
+			res = append(res, nil)
+		}
+	}
+	return res, nil
+}
+
+func cmdHGetAll(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(h))
+	for k, v := range h {
+		res[k] = v
+	}
+	return res, nil
+}
+
+func cmdHDel(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, field := range args[1:] {
+		if _, ok := h[field]; ok {
+			delete(h, field)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdHExists(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := h[args[1]]; ok {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func cmdHLen(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(h)), nil
+}
+
+func cmdHKeys(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sortedFields(h), nil
+}
+
+func cmdHVals(s *Server, _ string, args []string) (interface{}, error) {
+	h, err := s.getHash(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(h))
+	for _, field := range sortedFields(h) {
+		res = append(res, h[field])
+	}
+	return res, nil
+}
+
+func cmdHIncrBy(s *Server, _ string, args []string) (interface{}, error) {
+	delta, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getOrCreate(args[0], kindHash)
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	if v, ok := e.hash[args[1]]; ok {
+		if n, err = parseInt(v); err != nil {
+			return nil, replyError("ERR hash value is not an integer")
+		}
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return nil, errOverflow
+	}
+	n += delta
+	e.hash[args[1]] = formatInt(n)
+	return n, nil
+}
+
+func cmdHIncrByFloat(s *Server, _ string, args []string) (interface{}, error) {
+	delta, err := parseFloat(args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getOrCreate(args[0], kindHash)
+	if err != nil {
+		return nil, err
+	}
+	var f float64
+	if v, ok := e.hash[args[1]]; ok {
+		if f, err = parseFloat(v); err != nil {
+			return nil, replyError("ERR hash value is not a float")
+		}
+	}
+	f += delta
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, replyError("ERR increment would produce NaN or Infinity")
+	}
+	e.hash[args[1]] = formatFloat(f)
+	return f, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+func init() {
+	register(map[string]command{
+		"pfadd":   {1, cmdPFAdd},
+		"pfcount": {1, cmdPFCount},
+		"pfmerge": {1, cmdPFMerge},
+	})
+}
+
+// hllMagic prefixes the string value of a HyperLogLog, like the header of a real one.
+const hllMagic = "HYLL"
+
+var errNotHLL = replyError("WRONGTYPE Key is not a valid HyperLogLog string value.")
+
+// getHLL returns the members of the HyperLogLog at key, nil when it does not exist.
+// The fake counts exactly, which is within the error bounds of a real HyperLogLog.
+func (s *Server) getHLL(key string) (map[string]struct{}, error) {
+	e, err := s.getKind(key, kindString)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	if e.hll == nil {
+		return nil, errNotHLL
+	}
+	return e.hll, nil
+}
+
+func (s *Server) setHLL(key string, members map[string]struct{}) {
+	e := s.keys[key]
+	if e == nil || e.kind != kindString {
+		e = newEntry(kindString)
+		s.keys[key] = e
+	}
+	e.str, e.hll = hllMagic, members
+}
+
+func cmdPFAdd(s *Server, _ string, args []string) (interface{}, error) {
+	hll, err := s.getHLL(args[0])
+	if err != nil {
+		return nil, err
+	}
+	created := hll == nil
+	if created {
+		hll = make(map[string]struct{})
+		s.setHLL(args[0], hll)
+	}
+	changed := created
+	for _, member := range args[1:] {
+		if _, ok := hll[member]; !ok {
+			hll[member] = struct{}{}
+			changed = true
+		}
+	}
+	if changed {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func cmdPFCount(s *Server, _ string, args []string) (interface{}, error) {
+	union, err := s.unionHLL(args)
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(union)), nil
+}
+
+func cmdPFMerge(s *Server, _ string, args []string) (interface{}, error) {
+	union, err := s.unionHLL(args)
+	if err != nil {
+		return nil, err
+	}
+	s.setHLL(args[0], union)
+	return "OK", nil
+}
+
+func (s *Server) unionHLL(keys []string) (map[string]struct{}, error) {
+	union := make(map[string]struct{})
+	for _, key := range keys {
+		hll, err := s.getHLL(key)
+		if err != nil {
+			return nil, err
+		}
+		for member := range hll {
+			union[member] = struct{}{}
+		}
+	}
+	return union, nil
+}
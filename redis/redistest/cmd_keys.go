@@ -0,0 +1,119 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"strconv"
+	"time"
+)
+
+func init() {
+	register(map[string]command{
+		"del":       {1, cmdDel},
+		"exists":    {1, cmdExists},
+		"type":      {1, cmdType},
+		"ttl":       {1, cmdTTL},
+		"pttl":      {1, cmdTTL},
+		"expire":    {2, cmdExpire},
+		"pexpire":   {2, cmdExpire},
+		"expireat":  {2, cmdExpire},
+		"pexpireat": {2, cmdExpire},
+		"persist":   {1, cmdPersist},
+		"time":      {0, cmdTime},
+	})
+}
+
+func cmdDel(s *Server, _ string, args []string) (interface{}, error) {
+	var n int64
+	for _, key := range args {
+		if s.get(key) != nil {
+			delete(s.keys, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdExists(s *Server, _ string, args []string) (interface{}, error) {
+	var n int64
+	for _, key := range args {
+		if s.get(key) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdType(s *Server, _ string, args []string) (interface{}, error) {
+	e := s.get(args[0])
+	if e == nil {
+		return "none", nil
+	}
+	return e.kind, nil
+}
+
+// cmdTTL replies with a time.Duration in nanoseconds, or with -2 when the key does not
+// exist and -1 when it has no TTL, like go-redis does.
+func cmdTTL(s *Server, name string, args []string) (interface{}, error) {
+	e := s.get(args[0])
+	if e == nil {
+		return time.Duration(-2), nil
+	}
+	if e.expireAt.IsZero() {
+		return time.Duration(-1), nil
+	}
+	left := e.expireAt.Sub(s.now())
+	if name == "ttl" {
+		return (left + time.Second/2) / time.Second * time.Second, nil
+	}
+	return (left + time.Millisecond/2) / time.Millisecond * time.Millisecond, nil
+}
+
+func cmdExpire(s *Server, name string, args []string) (interface{}, error) {
+	n, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	var at time.Time
+	switch name {
+	case "expire":
+		at = s.now().Add(time.Duration(n) * time.Second)
+	case "pexpire":
+		at = s.now().Add(time.Duration(n) * time.Millisecond)
+	case "expireat":
+		at = time.Unix(n, 0)
+	case "pexpireat":
+		at = time.Unix(0, n*int64(time.Millisecond))
+	}
+	return s.expireAt(args[0], at), nil
+}
+
+// expireAt sets the expiry of key, deleting it right away when at is not in the future.
+func (s *Server) expireAt(key string, at time.Time) int64 {
+	e := s.get(key)
+	if e == nil {
+		return 0
+	}
+	if !at.After(s.now()) {
+		delete(s.keys, key)
+		return 1
+	}
+	e.expireAt = at
+	return 1
+}
+
+func cmdPersist(s *Server, _ string, args []string) (interface{}, error) {
+	e := s.get(args[0])
+	if e == nil || e.expireAt.IsZero() {
+		return int64(0), nil
+	}
+	e.expireAt = time.Time{}
+	return int64(1), nil
+}
+
+// cmdTime replies with the server clock, in seconds and microseconds, for scripts.
+func cmdTime(s *Server, _ string, _ []string) (interface{}, error) {
+	now := s.now()
+	return []string{strconv.FormatInt(now.Unix(), 10), strconv.Itoa(now.Nanosecond() / 1000)}, nil
+}
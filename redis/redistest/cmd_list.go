@@ -0,0 +1,431 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"strings"
+)
+
+func init() {
+	register(map[string]command{
+		"lpush":   {2, cmdPush},
+		"rpush":   {2, cmdPush},
+		"lpushx":  {2, cmdPush},
+		"rpushx":  {2, cmdPush},
+		"lpop":    {1, cmdPop},
+		"rpop":    {1, cmdPop},
+		"llen":    {1, cmdLLen},
+		"lindex":  {2, cmdLIndex},
+		"lrange":  {3, cmdLRange},
+		"linsert": {4, cmdLInsert},
+		"lrem":    {3, cmdLRem},
+		"lset":    {3, cmdLSet},
+		"ltrim":   {3, cmdLTrim},
+		"blpop":   {2, cmdBPop},
+		"brpop":   {2, cmdBPop},
+		"lmove":   {4, cmdLMove},
+		"blmove":  {5, cmdLMove},
+		"lpos":    {2, cmdLPos},
+		"lmpop":   {3, cmdLMPop},
+	})
+}
+
+// getList returns the list entry of key, nil when it does not exist.
+func (s *Server) getList(key string) (*entry, error) {
+	return s.getKind(key, kindList)
+}
+
+func cmdPush(s *Server, name string, args []string) (interface{}, error) {
+	var e *entry
+	var err error
+	if strings.HasSuffix(name, "x") {
+		if e, err = s.getList(args[0]); err != nil || e == nil {
+			return int64(0), err
+		}
+	} else if e, err = s.getOrCreate(args[0], kindList); err != nil {
+		return nil, err
+	}
+
+	for _, v := range args[1:] {
+		if name[0] == 'l' {
+			e.list = append([]string{v}, e.list...)
+		} else {
+			e.list = append(e.list, v)
+		}
+	}
+	return int64(len(e.list)), nil
+}
+
+func cmdPop(s *Server, name string, args []string) (interface{}, error) {
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return popList(e, name[0] == 'l', 1)[0], nil
+	}
+
+	count, err := parseInt(args[1])
+	if err != nil || count < 0 {
+		return nil, replyError("ERR value is out of range, must be positive")
+	}
+	return popList(e, name[0] == 'l', count), nil
+}
+
+// popList removes up to count elements from the head, or the tail, of a non empty list.
+func popList(e *entry, head bool, count int64) []string {
+	if count > int64(len(e.list)) {
+		count = int64(len(e.list))
+	}
+	res := make([]string, 0, count)
+	for i := int64(0); i < count; i++ {
+		if head {
+			res = append(res, e.list[0])
+			e.list = e.list[1:]
+		} else {
+			res = append(res, e.list[len(e.list)-1])
+			e.list = e.list[:len(e.list)-1]
+		}
+	}
+	return res
+}
+
+func cmdLLen(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	return int64(len(e.list)), nil
+}
+
+func cmdLIndex(s *Server, _ string, args []string) (interface{}, error) {
+	index, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return nil, err
+	}
+	if index < 0 {
+		index += int64(len(e.list))
+	}
+	if index < 0 || index >= int64(len(e.list)) {
+		return nil, nil
+	}
+	return e.list[index], nil
+}
+
+func cmdLRange(s *Server, _ string, args []string) (interface{}, error) {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := []string{}
+	if e == nil {
+		return res, nil
+	}
+	if start, stop, ok := normRange(start, stop, int64(len(e.list))); ok {
+		res = append(res, e.list[start:stop+1]...)
+	}
+	return res, nil
+}
+
+func cmdLInsert(s *Server, _ string, args []string) (interface{}, error) {
+	where := strings.ToLower(args[1])
+	if where != "before" && where != "after" {
+		return nil, errSyntax
+	}
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	for i, v := range e.list {
+		if v != args[2] {
+			continue
+		}
+		if where == "after" {
+			i++
+		}
+		e.list = append(e.list[:i], append([]string{args[3]}, e.list[i:]...)...)
+		return int64(len(e.list)), nil
+	}
+	return int64(-1), nil
+}
+
+func cmdLRem(s *Server, _ string, args []string) (interface{}, error) {
+	count, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+
+	n := len(e.list)
+	removed := make([]bool, n)
+	var total int64
+	for i := 0; i < n; i++ {
+		idx := i
+		if count < 0 {
+			idx = n - 1 - i
+		}
+		if e.list[idx] != args[2] {
+			continue
+		}
+		removed[idx] = true
+		total++
+		if count != 0 && (total == count || total == -count) {
+			break
+		}
+	}
+	kept := make([]string, 0, n-int(total))
+	for i, v := range e.list {
+		if !removed[i] {
+			kept = append(kept, v)
+		}
+	}
+	e.list = kept
+	return total, nil
+}
+
+func cmdLSet(s *Server, _ string, args []string) (interface{}, error) {
+	index, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, errNoSuchKey
+	}
+	if index < 0 {
+		index += int64(len(e.list))
+	}
+	if index < 0 || index >= int64(len(e.list)) {
+		return nil, errIndexOutOfRange
+	}
+	e.list[index] = args[2]
+	return "OK", nil
+}
+
+func cmdLTrim(s *Server, _ string, args []string) (interface{}, error) {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getList(args[0])
+	if err != nil || e == nil {
+		return "OK", err
+	}
+	if start, stop, ok := normRange(start, stop, int64(len(e.list))); ok {
+		e.list = append([]string(nil), e.list[start:stop+1]...)
+	} else {
+		e.list = nil
+	}
+	return "OK", nil
+}
+
+// parseTimeout checks the timeout of a blocking command, in seconds. The commands of the
+// server never block: they reply as redis does when the timeout expires.
+func parseTimeout(v string) error {
+	timeout, err := parseFloat(v)
+	if err != nil {
+		return replyError("ERR timeout is not a float or out of range")
+	}
+	if timeout < 0 {
+		return replyError("ERR timeout is negative")
+	}
+	return nil
+}
+
+func cmdBPop(s *Server, name string, args []string) (interface{}, error) {
+	keys := args[:len(args)-1]
+	if err := parseTimeout(args[len(args)-1]); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		e, err := s.getList(key)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil && len(e.list) > 0 {
+			return []string{key, popList(e, name == "blpop", 1)[0]}, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseEnd parses the LEFT or RIGHT end of a list, reporting whether it is the head.
+func parseEnd(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "left":
+		return true, nil
+	case "right":
+		return false, nil
+	}
+	return false, errSyntax
+}
+
+func cmdLMove(s *Server, name string, args []string) (interface{}, error) {
+	if name == "blmove" {
+		if err := parseTimeout(args[4]); err != nil {
+			return nil, err
+		}
+	} else if len(args) != 4 {
+		return nil, errWrongArgs(name)
+	}
+	srcHead, err := parseEnd(args[2])
+	if err != nil {
+		return nil, err
+	}
+	dstHead, err := parseEnd(args[3])
+	if err != nil {
+		return nil, err
+	}
+	src, err := s.getList(args[0])
+	if err != nil || src == nil || len(src.list) == 0 {
+		return nil, err
+	}
+	if _, err := s.getList(args[1]); err != nil {
+		return nil, err
+	}
+
+	v := popList(src, srcHead, 1)[0]
+	dst, err := s.getOrCreate(args[1], kindList)
+	if err != nil {
+		return nil, err
+	}
+	if dstHead {
+		dst.list = append([]string{v}, dst.list...)
+	} else {
+		dst.list = append(dst.list, v)
+	}
+	return v, nil
+}
+
+func cmdLPos(s *Server, _ string, args []string) (interface{}, error) {
+	rank, count, maxLen := int64(1), int64(-1), int64(0)
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return nil, errSyntax
+		}
+		n, err := parseInt(args[i+1])
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(args[i]) {
+		case "rank":
+			if n == 0 {
+				return nil, replyError("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+			}
+			rank = n
+		case "count":
+			if n < 0 {
+				return nil, replyError("ERR COUNT can't be negative")
+			}
+			count = n
+		case "maxlen":
+			if n < 0 {
+				return nil, replyError("ERR MAXLEN can't be negative")
+			}
+			maxLen = n
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	e, err := s.getList(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	if e != nil {
+		list = e.list
+	}
+	matches := []int64{}
+	skip := rank - 1
+	if rank < 0 {
+		skip = -rank - 1
+	}
+	for i := 0; i < len(list) && (maxLen == 0 || int64(i) < maxLen); i++ {
+		idx := i
+		if rank < 0 {
+			idx = len(list) - 1 - i
+		}
+		if list[idx] != args[1] {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		matches = append(matches, int64(idx))
+		// Without COUNT, the first match is enough. COUNT 0 asks for all of them.
+		if count < 0 || int64(len(matches)) == count {
+			break
+		}
+	}
+	if count >= 0 {
+		return matches, nil
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return matches[0], nil
+}
+
+func cmdLMPop(s *Server, name string, args []string) (interface{}, error) {
+	numKeys, err := parseInt(args[0])
+	if err != nil || numKeys < 1 {
+		return nil, replyError("ERR numkeys should be greater than 0")
+	}
+	if numKeys+1 >= int64(len(args)) {
+		return nil, errSyntax
+	}
+	keys, opts := args[1:1+numKeys], args[1+numKeys:]
+	head, err := parseEnd(opts[0])
+	if err != nil {
+		return nil, err
+	}
+	count := int64(1)
+	switch len(opts) {
+	case 1:
+	case 3:
+		if strings.ToLower(opts[1]) != "count" {
+			return nil, errSyntax
+		}
+		if count, err = parseInt(opts[2]); err != nil || count < 1 {
+			return nil, replyError("ERR count should be greater than 0")
+		}
+	default:
+		return nil, errSyntax
+	}
+
+	for _, key := range keys {
+		e, err := s.getList(key)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil && len(e.list) > 0 {
+			return []interface{}{key, popList(e, head, count)}, nil
+		}
+	}
+	return nil, nil
+}
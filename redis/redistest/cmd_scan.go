@@ -0,0 +1,218 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(map[string]command{
+		"scan":  {1, cmdScan},
+		"hscan": {2, cmdScan},
+		"sscan": {2, cmdScan},
+		"zscan": {2, cmdScan},
+	})
+}
+
+// liveKeys returns the keys that have not expired, sorted.
+func (s *Server) liveKeys() []string {
+	keys := make([]string, 0, len(s.keys))
+	for key := range s.keys {
+		if s.get(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cmdScan walks the sorted keys or elements, the cursor being the index of the next one.
+// As in redis, count elements are visited before MATCH and TYPE filter them out, so that
+// a page may be empty while the cursor is not 0 yet.
+func cmdScan(s *Server, name string, args []string) (interface{}, error) {
+	var elems []string
+	var step int
+	if name == "scan" {
+		elems, step = s.liveKeys(), 1
+	} else {
+		var err error
+		if elems, step, err = s.scanElems(name, args[0]); err != nil {
+			return nil, err
+		}
+		args = args[1:]
+	}
+
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return nil, replyError("ERR invalid cursor")
+	}
+	match, keyType, count := "*", "", int64(10)
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return nil, errSyntax
+		}
+		switch strings.ToLower(args[i]) {
+		case "match":
+			match = args[i+1]
+		case "count":
+			if count, err = parseInt(args[i+1]); err != nil {
+				return nil, err
+			}
+			if count < 1 {
+				return nil, errSyntax
+			}
+		case "type":
+			if name != "scan" {
+				return nil, errSyntax
+			}
+			keyType = strings.ToLower(args[i+1])
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	n := uint64(len(elems) / step)
+	page := []string{}
+	for ; cursor < n && count > 0; cursor, count = cursor+1, count-1 {
+		elem := elems[int(cursor)*step : int(cursor+1)*step]
+		if !matchGlob(match, elem[0]) {
+			continue
+		}
+		if keyType != "" && s.get(elem[0]).kind != keyType {
+			continue
+		}
+		page = append(page, elem...)
+	}
+	if cursor >= n {
+		cursor = 0
+	}
+	return []interface{}{strconv.FormatUint(cursor, 10), page}, nil
+}
+
+// scanElems returns the sorted elements of key, step being the number of strings per element.
+func (s *Server) scanElems(name, key string) ([]string, int, error) {
+	var elems []string
+	switch name {
+	case "hscan":
+		e, err := s.getKind(key, kindHash)
+		if err != nil || e == nil {
+			return nil, 2, err
+		}
+		for _, field := range sortedMapKeys(e.hash) {
+			elems = append(elems, field, e.hash[field])
+		}
+		return elems, 2, nil
+	case "sscan":
+		e, err := s.getKind(key, kindSet)
+		if err != nil || e == nil {
+			return nil, 1, err
+		}
+		return sortedKeys(e.set), 1, nil
+	default:
+		e, err := s.getKind(key, kindZSet)
+		if err != nil || e == nil {
+			return nil, 2, err
+		}
+		members := make([]string, 0, len(e.zset))
+		for member := range e.zset {
+			members = append(members, member)
+		}
+		sort.Strings(members)
+		for _, member := range members {
+			elems = append(elems, member, formatFloat(e.zset[member]))
+		}
+		return elems, 2, nil
+	}
+}
+
+func sortedMapKeys(m map[string]string) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// matchGlob reports whether s matches the glob-style pattern of redis: * and ? wildcards,
+// [abc], [^abc] and [a-z] classes, and \ escapes.
+func matchGlob(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchGlob(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			rest, ok := matchClass(pattern[1:], s[0])
+			if !ok {
+				return false
+			}
+			s = s[1:]
+			pattern = rest
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass matches c against the class starting pattern, right after its '['. It returns
+// the pattern following the class.
+func matchClass(pattern string, c byte) (string, bool) {
+	not := len(pattern) > 0 && pattern[0] == '^'
+	if not {
+		pattern = pattern[1:]
+	}
+	match := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			match = match || pattern[1] == c
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			match = match || (lo <= c && c <= hi)
+			pattern = pattern[3:]
+		default:
+			match = match || pattern[0] == c
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return pattern, match != not
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(map[string]command{
+		"eval":    {2, cmdEval},
+		"evalsha": {2, cmdEval},
+		"script":  {1, cmdScript},
+	})
+}
+
+// Call runs a command from a ScriptFunc, like redis.call does from Lua. Its replies are the
+// ones of redis, e.g. PTTL replies with milliseconds rather than with a time.Duration.
+type Call func(name string, args ...string) (interface{}, error)
+
+// ScriptFunc replaces a Lua script with Go code, e.g. to stub a script out. Its reply is
+// converted the way redis converts Lua values: true to 1, false to nil, numbers to integers.
+type ScriptFunc func(call Call, keys, args []string) (interface{}, error)
+
+// RegisterScript makes EVAL of src, and EVALSHA of its digest once loaded, run fn rather
+// than src. It returns the digest of src.
+//
+//	srv.RegisterScript(src, func(call redistest.Call, keys, args []string) (interface{}, error) {
+//		return call("incrby", keys[0], args[0])
+//	})
+func (s *Server) RegisterScript(src string, fn ScriptFunc) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash := scriptHash(src)
+	s.scriptFuncs[hash] = fn
+	return hash
+}
+
+func scriptHash(src string) string {
+	sum := sha1.Sum([]byte(src))
+	return hex.EncodeToString(sum[:])
+}
+
+func cmdEval(s *Server, name string, args []string) (interface{}, error) {
+	hash, src := strings.ToLower(args[0]), args[0]
+	if name == "eval" {
+		hash = scriptHash(src)
+		s.scripts[hash] = src
+	} else {
+		loaded, ok := s.scripts[hash]
+		if !ok {
+			return nil, replyError("NOSCRIPT No matching script. Please use EVAL.")
+		}
+		src = loaded
+	}
+
+	numKeys, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if numKeys < 0 || numKeys > int64(len(args)-2) {
+		return nil, replyError("ERR Number of keys can't be greater than number of args")
+	}
+	keys, argv := args[2:2+numKeys], args[2+numKeys:]
+
+	fn, ok := s.scriptFuncs[hash]
+	if !ok {
+		return s.runLua(src, keys, argv)
+	}
+	reply, err := fn(func(name string, args ...string) (interface{}, error) {
+		reply, err := s.do(name, args)
+		return redisReply(name, reply), err
+	}, keys, argv)
+	if err != nil {
+		return nil, err
+	}
+	return luaReply(reply), nil
+}
+
+// redisReply undoes the conversions made for redis.Redis, which reads TTLs as time.Durations
+// and sorted set entries WITHSCORES as Zs rather than as members followed by their scores.
+func redisReply(name string, v interface{}) interface{} {
+	if zs, ok := v.([]z); ok {
+		res := make([]string, 0, 2*len(zs))
+		for _, m := range zs {
+			res = append(res, m.Member, formatFloat(m.Score))
+		}
+		return res
+	}
+	d, ok := v.(time.Duration)
+	switch {
+	case !ok:
+		return v
+	case d < 0:
+		return int64(d)
+	case strings.ToLower(name) == "ttl":
+		return int64(d / time.Second)
+	default:
+		return int64(d / time.Millisecond)
+	}
+}
+
+// luaReply converts the reply of a ScriptFunc as redis converts Lua values.
+func luaReply(v interface{}) interface{} {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return int64(1)
+		}
+		return nil
+	case int:
+		return int64(val)
+	case float64:
+		return int64(val)
+	case []string:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = item
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = luaReply(item)
+		}
+		return res
+	}
+	return v
+}
+
+func cmdScript(s *Server, _ string, args []string) (interface{}, error) {
+	switch strings.ToLower(args[0]) {
+	case "load":
+		if len(args) != 2 {
+			return nil, errWrongArgs("script|load")
+		}
+		hash := scriptHash(args[1])
+		s.scripts[hash] = args[1]
+		return hash, nil
+	case "exists":
+		res := make([]int64, 0, len(args)-1)
+		for _, hash := range args[1:] {
+			var n int64
+			if _, ok := s.scripts[strings.ToLower(hash)]; ok {
+				n = 1
+			}
+			res = append(res, n)
+		}
+		return res, nil
+	case "flush":
+		s.scripts = make(map[string]string)
+		return "OK", nil
+	}
+	return nil, errSyntax
+}
@@ -0,0 +1,243 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+func init() {
+	register(map[string]command{
+		"sadd":        {2, cmdSAdd},
+		"srem":        {2, cmdSRem},
+		"scard":       {1, cmdSCard},
+		"sismember":   {2, cmdSIsMember},
+		"smembers":    {1, cmdSMembers},
+		"smove":       {3, cmdSMove},
+		"spop":        {1, cmdSPop},
+		"srandmember": {1, cmdSRandMember},
+		"sdiff":       {1, cmdSetOp},
+		"sinter":      {1, cmdSetOp},
+		"sunion":      {1, cmdSetOp},
+		"sdiffstore":  {2, cmdSetOpStore},
+		"sinterstore": {2, cmdSetOpStore},
+		"sunionstore": {2, cmdSetOpStore},
+	})
+}
+
+// getSet returns the members of key, nil when it does not exist.
+func (s *Server) getSet(key string) (map[string]struct{}, error) {
+	e, err := s.getKind(key, kindSet)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return e.set, nil
+}
+
+func cmdSAdd(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getOrCreate(args[0], kindSet)
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := e.set[member]; !ok {
+			e.set[member] = struct{}{}
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdSRem(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdSCard(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(set)), nil
+}
+
+func cmdSIsMember(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := set[args[1]]; ok {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func cmdSMembers(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(set), nil
+}
+
+func cmdSMove(s *Server, _ string, args []string) (interface{}, error) {
+	src, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getKind(args[1], kindSet); err != nil {
+		return nil, err
+	}
+	if _, ok := src[args[2]]; !ok {
+		return int64(0), nil
+	}
+	delete(src, args[2])
+	dst, _ := s.getOrCreate(args[1], kindSet)
+	dst.set[args[2]] = struct{}{}
+	return int64(1), nil
+}
+
+func cmdSPop(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		if len(set) == 0 {
+			return nil, nil
+		}
+		member := s.randMembers(set, 1)[0]
+		delete(set, member)
+		return member, nil
+	}
+
+	count, err := parseInt(args[1])
+	if err != nil || count < 0 {
+		return nil, replyError("ERR value is out of range, must be positive")
+	}
+	if count > int64(len(set)) {
+		count = int64(len(set))
+	}
+	res := s.randMembers(set, count)
+	for _, member := range res {
+		delete(set, member)
+	}
+	return res, nil
+}
+
+// randMembers picks count distinct members of set.
+func (s *Server) randMembers(set map[string]struct{}, count int64) []string {
+	members := sortedKeys(set)
+	s.rand.Shuffle(len(members), func(i, j int) {
+		members[i], members[j] = members[j], members[i]
+	})
+	return members[:count]
+}
+
+func cmdSRandMember(s *Server, _ string, args []string) (interface{}, error) {
+	set, err := s.getSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		if len(set) == 0 {
+			return nil, nil
+		}
+		return s.randMembers(set, 1)[0], nil
+	}
+
+	count, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if count >= 0 {
+		if count > int64(len(set)) {
+			count = int64(len(set))
+		}
+		return s.randMembers(set, count), nil
+	}
+
+	// A negative count allows the same member to be returned several times.
+	res := make([]string, 0, -count)
+	if len(set) == 0 {
+		return res, nil
+	}
+	members := sortedKeys(set)
+	for i := int64(0); i < -count; i++ {
+		res = append(res, members[s.rand.Intn(len(members))])
+	}
+	return res, nil
+}
+
+// setOp computes the difference, intersection or union of the sets stored at keys.
+func (s *Server) setOp(name string, keys []string) (map[string]struct{}, error) {
+	sets := make([]map[string]struct{}, 0, len(keys))
+	for _, key := range keys {
+		set, err := s.getSet(key)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+
+	res := make(map[string]struct{})
+	switch name {
+	case "sunion":
+		for _, set := range sets {
+			for member := range set {
+				res[member] = struct{}{}
+			}
+		}
+	case "sinter":
+	next:
+		for member := range sets[0] {
+			for _, set := range sets[1:] {
+				if _, ok := set[member]; !ok {
+					continue next
+				}
+			}
+			res[member] = struct{}{}
+		}
+	case "sdiff":
+	nextDiff:
+		for member := range sets[0] {
+			for _, set := range sets[1:] {
+				if _, ok := set[member]; ok {
+					continue nextDiff
+				}
+			}
+			res[member] = struct{}{}
+		}
+	}
+	return res, nil
+}
+
+func cmdSetOp(s *Server, name string, args []string) (interface{}, error) {
+	res, err := s.setOp(name, args)
+	if err != nil {
+		return nil, err
+	}
+	return sortedKeys(res), nil
+}
+
+func cmdSetOpStore(s *Server, name string, args []string) (interface{}, error) {
+	res, err := s.setOp(name[:len(name)-len("store")], args[1:])
+	if err != nil {
+		return nil, err
+	}
+	delete(s.keys, args[0])
+	if len(res) > 0 {
+		e := newEntry(kindSet)
+		e.set = res
+		s.keys[args[0]] = e
+	}
+	return int64(len(res)), nil
+}
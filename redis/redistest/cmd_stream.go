@@ -0,0 +1,796 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(map[string]command{
+		"xadd":       {3, cmdXAdd},
+		"xdel":       {1, cmdXDel},
+		"xlen":       {1, cmdXLen},
+		"xrange":     {3, cmdXRange},
+		"xrevrange":  {3, cmdXRange},
+		"xtrim":      {3, cmdXTrim},
+		"xread":      {3, cmdXRead},
+		"xgroup":     {1, cmdXGroup},
+		"xreadgroup": {6, cmdXRead},
+		"xack":       {2, cmdXAck},
+		"xpending":   {2, cmdXPending},
+		"xclaim":     {4, cmdXClaim},
+	})
+}
+
+var (
+	errInvalidStreamID = replyError("ERR Invalid stream ID specified as stream command argument")
+	errStreamIDTooLow  = replyError("ERR The ID specified in XADD is equal or smaller than the target stream top item")
+)
+
+type streamID struct {
+	ms, seq uint64
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+func (id streamID) next() streamID {
+	if id.seq == math.MaxUint64 {
+		return streamID{ms: id.ms + 1}
+	}
+	return streamID{ms: id.ms, seq: id.seq + 1}
+}
+
+// parseStreamID parses an ID, "-" and "+" being the smallest and greatest ones. An ID
+// without sequence gets the sequence seq.
+func parseStreamID(v string, seq uint64) (streamID, error) {
+	switch v {
+	case "-":
+		return streamID{}, nil
+	case "+":
+		return streamID{ms: math.MaxUint64, seq: math.MaxUint64}, nil
+	}
+	msPart, seqPart := v, ""
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		msPart, seqPart = v[:i], v[i+1:]
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, errInvalidStreamID
+	}
+	if seqPart != "" {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return streamID{}, errInvalidStreamID
+		}
+	}
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+type streamEntry struct {
+	id     streamID
+	fields []string
+}
+
+type pendingEntry struct {
+	id          streamID
+	consumer    string
+	deliveredAt time.Time
+	count       int64
+}
+
+type streamGroup struct {
+	lastID    streamID
+	pending   []*pendingEntry
+	consumers map[string]struct{}
+}
+
+type stream struct {
+	entries []streamEntry
+	lastID  streamID
+	groups  map[string]*streamGroup
+}
+
+func newStream() *stream {
+	return &stream{groups: make(map[string]*streamGroup)}
+}
+
+// fingerprint describes the content of the stream, see Server.fingerprint.
+func (st *stream) fingerprint() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v %v", st.entries, st.lastID)
+	for _, name := range sortedStreamGroups(st.groups) {
+		g := st.groups[name]
+		fmt.Fprintf(&sb, " %s %v %v", name, g.lastID, len(g.pending))
+		for _, p := range g.pending {
+			fmt.Fprintf(&sb, " %v", *p)
+		}
+	}
+	return sb.String()
+}
+
+func sortedStreamGroups(groups map[string]*streamGroup) []string {
+	res := make([]string, 0, len(groups))
+	for name := range groups {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// index returns the index of the first entry whose ID is not lower than id.
+func (st *stream) index(id streamID) int {
+	return sort.Search(len(st.entries), func(i int) bool {
+		return !st.entries[i].id.less(id)
+	})
+}
+
+func (st *stream) lookup(id streamID) (streamEntry, bool) {
+	i := st.index(id)
+	if i < len(st.entries) && st.entries[i].id == id {
+		return st.entries[i], true
+	}
+	return streamEntry{}, false
+}
+
+// after returns at most count entries whose ID is greater than id, all of them when count <= 0.
+func (st *stream) after(id streamID, count int64) []streamEntry {
+	entries := st.entries[st.index(id.next()):]
+	if id.ms == math.MaxUint64 && id.seq == math.MaxUint64 {
+		entries = nil
+	}
+	if count > 0 && int64(len(entries)) > count {
+		entries = entries[:count]
+	}
+	return entries
+}
+
+func (st *stream) trim(strategy string, threshold string) (int64, error) {
+	var n int
+	switch strategy {
+	case "maxlen":
+		maxLen, err := parseInt(threshold)
+		if err != nil || maxLen < 0 {
+			return 0, errNotInteger
+		}
+		if int64(len(st.entries)) > maxLen {
+			n = len(st.entries) - int(maxLen)
+		}
+	case "minid":
+		minID, err := parseStreamID(threshold, 0)
+		if err != nil {
+			return 0, err
+		}
+		n = st.index(minID)
+	default:
+		return 0, errSyntax
+	}
+	st.entries = append([]streamEntry(nil), st.entries[n:]...)
+	return int64(n), nil
+}
+
+// xMessage is a message as it is encoded in the replies decoded into redis.XMessage.
+type xMessage struct {
+	ID     string
+	Values map[string]string
+}
+
+type xStream struct {
+	Stream   string
+	Messages []xMessage
+}
+
+type xPending struct {
+	Count     int64
+	Lower     string
+	Higher    string
+	Consumers map[string]int64
+}
+
+type xPendingExt struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+func messages(entries []streamEntry) []xMessage {
+	res := make([]xMessage, 0, len(entries))
+	for _, e := range entries {
+		res = append(res, message(e))
+	}
+	return res
+}
+
+func message(e streamEntry) xMessage {
+	msg := xMessage{ID: e.id.String(), Values: make(map[string]string, len(e.fields)/2)}
+	for i := 0; i+1 < len(e.fields); i += 2 {
+		msg.Values[e.fields[i]] = e.fields[i+1]
+	}
+	return msg
+}
+
+func (s *Server) getStream(key string) (*stream, error) {
+	e, err := s.getKind(key, kindStream)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	return e.stream, nil
+}
+
+func cmdXAdd(s *Server, name string, args []string) (interface{}, error) {
+	key := args[0]
+	args = args[1:]
+	mkStream := true
+	var strategy, threshold string
+options:
+	for len(args) > 0 {
+		switch opt := strings.ToLower(args[0]); opt {
+		case "nomkstream":
+			mkStream = false
+			args = args[1:]
+		case "maxlen", "minid":
+			var err error
+			if strategy, threshold, args, err = parseTrim(opt, args[1:]); err != nil {
+				return nil, err
+			}
+		default:
+			break options
+		}
+	}
+	if len(args) < 3 || len(args)%2 != 1 {
+		return nil, errWrongArgs(name)
+	}
+
+	e, err := s.getKind(key, kindStream)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		if !mkStream {
+			return nil, nil
+		}
+		if e, err = s.getOrCreate(key, kindStream); err != nil {
+			return nil, err
+		}
+	}
+	st := e.stream
+
+	var id streamID
+	if args[0] == "*" {
+		id = streamID{ms: uint64(s.now().UnixNano() / int64(time.Millisecond))}
+		if !st.lastID.less(id) {
+			id = st.lastID.next()
+		}
+	} else {
+		if id, err = parseStreamID(args[0], 0); err != nil {
+			return nil, err
+		}
+		if id == (streamID{}) {
+			return nil, replyError("ERR The ID specified in XADD must be greater than 0-0")
+		}
+		if !st.lastID.less(id) {
+			return nil, errStreamIDTooLow
+		}
+	}
+	st.entries = append(st.entries, streamEntry{id: id, fields: append([]string(nil), args[1:]...)})
+	st.lastID = id
+	if strategy != "" {
+		if _, err = st.trim(strategy, threshold); err != nil {
+			return nil, err
+		}
+	}
+	return id.String(), nil
+}
+
+// parseTrim parses the arguments of a MAXLEN or MINID option, and returns the ones following it.
+func parseTrim(strategy string, args []string) (string, string, []string, error) {
+	if len(args) > 0 && (args[0] == "~" || args[0] == "=") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", "", nil, errSyntax
+	}
+	threshold := args[0]
+	args = args[1:]
+	if len(args) >= 2 && strings.ToLower(args[0]) == "limit" {
+		if _, err := parseInt(args[1]); err != nil {
+			return "", "", nil, err
+		}
+		args = args[2:]
+	}
+	return strategy, threshold, args, nil
+}
+
+func cmdXDel(s *Server, _ string, args []string) (interface{}, error) {
+	st, err := s.getStream(args[0])
+	if err != nil || st == nil {
+		return int64(0), err
+	}
+	var n int64
+	for _, arg := range args[1:] {
+		id, err := parseStreamID(arg, 0)
+		if err != nil {
+			return nil, err
+		}
+		i := st.index(id)
+		if i < len(st.entries) && st.entries[i].id == id {
+			st.entries = append(st.entries[:i], st.entries[i+1:]...)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdXLen(s *Server, _ string, args []string) (interface{}, error) {
+	st, err := s.getStream(args[0])
+	if err != nil || st == nil {
+		return int64(0), err
+	}
+	return int64(len(st.entries)), nil
+}
+
+func cmdXRange(s *Server, name string, args []string) (interface{}, error) {
+	startArg, stopArg := args[1], args[2]
+	if name == "xrevrange" {
+		startArg, stopArg = stopArg, startArg
+	}
+	start, err := parseRangeID(startArg, 0)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseRangeID(stopArg, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(startArg, "(") {
+		start = start.next()
+	}
+	count := int64(-1)
+	if len(args) == 5 && strings.ToLower(args[3]) == "count" {
+		if count, err = parseInt(args[4]); err != nil {
+			return nil, err
+		}
+	} else if len(args) != 3 {
+		return nil, errSyntax
+	}
+
+	st, err := s.getStream(args[0])
+	if err != nil || st == nil {
+		return []xMessage{}, err
+	}
+	var entries []streamEntry
+	for _, e := range st.entries[st.index(start):] {
+		if stop.less(e.id) || (strings.HasPrefix(stopArg, "(") && e.id == stop) {
+			break
+		}
+		entries = append(entries, e)
+	}
+	if name == "xrevrange" {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if count >= 0 && int64(len(entries)) > count {
+		entries = entries[:count]
+	}
+	return messages(entries), nil
+}
+
+// parseRangeID parses an XRANGE bound, which may be exclusive with a "(" prefix.
+func parseRangeID(v string, seq uint64) (streamID, error) {
+	return parseStreamID(strings.TrimPrefix(v, "("), seq)
+}
+
+func cmdXTrim(s *Server, _ string, args []string) (interface{}, error) {
+	strategy, threshold, rest, err := parseTrim(strings.ToLower(args[1]), args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errSyntax
+	}
+	st, err := s.getStream(args[0])
+	if err != nil || st == nil {
+		return int64(0), err
+	}
+	return st.trim(strategy, threshold)
+}
+
+// cmdXRead serves XREAD and XREADGROUP. Reads never block: when there is nothing to read,
+// they reply with nil as if the BLOCK timeout had elapsed.
+func cmdXRead(s *Server, name string, args []string) (interface{}, error) {
+	var group, consumer string
+	count := int64(-1)
+	noAck := false
+	for len(args) > 0 && strings.ToLower(args[0]) != "streams" {
+		switch strings.ToLower(args[0]) {
+		case "group":
+			if name != "xreadgroup" || len(args) < 3 {
+				return nil, errSyntax
+			}
+			group, consumer = args[1], args[2]
+			args = args[3:]
+		case "count", "block":
+			if len(args) < 2 {
+				return nil, errSyntax
+			}
+			n, err := parseInt(args[1])
+			if err != nil {
+				return nil, err
+			}
+			if strings.ToLower(args[0]) == "count" {
+				count = n
+			}
+			args = args[2:]
+		case "noack":
+			noAck = true
+			args = args[1:]
+		default:
+			return nil, errSyntax
+		}
+	}
+	if len(args) < 3 || len(args)%2 != 1 || (name == "xreadgroup" && group == "") {
+		return nil, errSyntax
+	}
+	keys, ids := args[1:1+len(args)/2], args[1+len(args)/2:]
+
+	var res []xStream
+	for i, key := range keys {
+		st, err := s.getStream(key)
+		if err != nil {
+			return nil, err
+		}
+		if name == "xreadgroup" {
+			read, ok, err := s.readGroup(st, key, group, consumer, ids[i], count, noAck)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res = append(res, xStream{Stream: key, Messages: read})
+			}
+			continue
+		}
+
+		if st == nil {
+			continue
+		}
+		var after streamID
+		if ids[i] == "$" {
+			after = st.lastID
+		} else if after, err = parseStreamID(ids[i], 0); err != nil {
+			return nil, err
+		}
+		if entries := st.after(after, count); len(entries) > 0 {
+			res = append(res, xStream{Stream: key, Messages: messages(entries)})
+		}
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// readGroup reads st as consumer of group: the new messages when id is ">", the pending
+// messages of consumer after id otherwise. ok is false when there is nothing to reply.
+func (s *Server) readGroup(st *stream, key, group, consumer, id string, count int64, noAck bool) ([]xMessage, bool, error) {
+	var g *streamGroup
+	if st != nil {
+		g = st.groups[group]
+	}
+	if g == nil {
+		return nil, false, replyError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s' in XREADGROUP with GROUP option", key, group))
+	}
+	g.consumers[consumer] = struct{}{}
+
+	if id == ">" {
+		entries := st.after(g.lastID, count)
+		if len(entries) == 0 {
+			return nil, false, nil
+		}
+		g.lastID = entries[len(entries)-1].id
+		if !noAck {
+			for _, e := range entries {
+				g.removePending(e.id)
+				g.pending = append(g.pending, &pendingEntry{id: e.id, consumer: consumer, deliveredAt: s.now(), count: 1})
+			}
+			g.sortPending()
+		}
+		return messages(entries), true, nil
+	}
+
+	after, err := parseStreamID(id, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	res := []xMessage{}
+	for _, p := range g.pending {
+		if p.consumer != consumer || !after.less(p.id) {
+			continue
+		}
+		if count > 0 && int64(len(res)) >= count {
+			break
+		}
+		if e, ok := st.lookup(p.id); ok {
+			res = append(res, message(e))
+		} else {
+			res = append(res, xMessage{ID: p.id.String()})
+		}
+		p.deliveredAt = s.now()
+		p.count++
+	}
+	return res, true, nil
+}
+
+func (g *streamGroup) removePending(id streamID) *pendingEntry {
+	for i, p := range g.pending {
+		if p.id == id {
+			g.pending = append(g.pending[:i], g.pending[i+1:]...)
+			return p
+		}
+	}
+	return nil
+}
+
+func (g *streamGroup) findPending(id streamID) *pendingEntry {
+	for _, p := range g.pending {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (g *streamGroup) sortPending() {
+	sort.Slice(g.pending, func(i, j int) bool {
+		return g.pending[i].id.less(g.pending[j].id)
+	})
+}
+
+func cmdXGroup(s *Server, _ string, args []string) (interface{}, error) {
+	sub := strings.ToLower(args[0])
+	wantArgs := map[string]int{"create": 4, "setid": 4, "destroy": 3, "createconsumer": 4, "delconsumer": 4}[sub]
+	if wantArgs == 0 {
+		return nil, errSyntax
+	}
+	if len(args) < wantArgs {
+		return nil, errWrongArgs("xgroup|" + sub)
+	}
+	key, group := args[1], args[2]
+
+	st, err := s.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	if sub == "create" && st == nil && len(args) > 4 && strings.ToLower(args[4]) == "mkstream" {
+		e, err := s.getOrCreate(key, kindStream)
+		if err != nil {
+			return nil, err
+		}
+		st = e.stream
+	}
+	if st == nil {
+		return nil, replyError("ERR The XGROUP subcommand requires the key to exist. " +
+			"Note that for CREATE you may want to use the MKSTREAM option to create an empty stream automatically.")
+	}
+
+	g := st.groups[group]
+	switch sub {
+	case "create", "setid":
+		if sub == "create" && g != nil {
+			return nil, replyError("BUSYGROUP Consumer Group name already exists")
+		}
+		if sub == "setid" && g == nil {
+			return nil, errNoGroup(key, group)
+		}
+		lastID := st.lastID
+		if args[3] != "$" {
+			if lastID, err = parseStreamID(args[3], 0); err != nil {
+				return nil, err
+			}
+		}
+		if g == nil {
+			g = &streamGroup{consumers: make(map[string]struct{})}
+			st.groups[group] = g
+		}
+		g.lastID = lastID
+		return "OK", nil
+	case "destroy":
+		if g == nil {
+			return int64(0), nil
+		}
+		delete(st.groups, group)
+		return int64(1), nil
+	}
+
+	if g == nil {
+		return nil, errNoGroup(key, group)
+	}
+	consumer := args[3]
+	_, exists := g.consumers[consumer]
+	if sub == "createconsumer" {
+		g.consumers[consumer] = struct{}{}
+		if exists {
+			return int64(0), nil
+		}
+		return int64(1), nil
+	}
+
+	var n int64
+	pending := g.pending[:0]
+	for _, p := range g.pending {
+		if p.consumer == consumer {
+			n++
+		} else {
+			pending = append(pending, p)
+		}
+	}
+	g.pending = pending
+	delete(g.consumers, consumer)
+	return n, nil
+}
+
+func errNoGroup(key, group string) error {
+	return replyError(fmt.Sprintf("NOGROUP No such key '%s' or consumer group '%s'", key, group))
+}
+
+// getGroup returns the group of the stream stored at key, nil when either does not exist.
+func (s *Server) getGroup(key, group string) (*streamGroup, *stream, error) {
+	st, err := s.getStream(key)
+	if err != nil || st == nil {
+		return nil, nil, err
+	}
+	return st.groups[group], st, nil
+}
+
+func cmdXAck(s *Server, _ string, args []string) (interface{}, error) {
+	g, _, err := s.getGroup(args[0], args[1])
+	if err != nil || g == nil {
+		return int64(0), err
+	}
+	var n int64
+	for _, arg := range args[2:] {
+		id, err := parseStreamID(arg, 0)
+		if err != nil {
+			return nil, err
+		}
+		if g.removePending(id) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdXPending(s *Server, _ string, args []string) (interface{}, error) {
+	key, group := args[0], args[1]
+	g, _, err := s.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errNoGroup(key, group)
+	}
+
+	if len(args) == 2 {
+		res := xPending{Count: int64(len(g.pending))}
+		if len(g.pending) > 0 {
+			res.Lower = g.pending[0].id.String()
+			res.Higher = g.pending[len(g.pending)-1].id.String()
+			res.Consumers = make(map[string]int64)
+			for _, p := range g.pending {
+				res.Consumers[p.consumer]++
+			}
+		}
+		return res, nil
+	}
+
+	args = args[2:]
+	var minIdle time.Duration
+	if strings.ToLower(args[0]) == "idle" {
+		if len(args) < 2 {
+			return nil, errSyntax
+		}
+		ms, err := parseInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		minIdle = time.Duration(ms) * time.Millisecond
+		args = args[2:]
+	}
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errSyntax
+	}
+	start, err := parseRangeID(args[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseRangeID(args[1], math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	count, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	res := []xPendingExt{}
+	for _, p := range g.pending {
+		if p.id.less(start) || stop.less(p.id) || int64(len(res)) >= count {
+			continue
+		}
+		if len(args) == 4 && p.consumer != args[3] {
+			continue
+		}
+		idle := s.now().Sub(p.deliveredAt)
+		if idle < minIdle {
+			continue
+		}
+		res = append(res, xPendingExt{ID: p.id.String(), Consumer: p.consumer, Idle: idle, RetryCount: p.count})
+	}
+	return res, nil
+}
+
+func cmdXClaim(s *Server, _ string, args []string) (interface{}, error) {
+	key, group, consumer := args[0], args[1], args[2]
+	ms, err := parseInt(args[3])
+	if err != nil {
+		return nil, err
+	}
+	minIdle := time.Duration(ms) * time.Millisecond
+	justID := false
+	var ids []streamID
+	for _, arg := range args[4:] {
+		if strings.ToLower(arg) == "justid" {
+			justID = true
+			continue
+		}
+		id, err := parseStreamID(arg, 0)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	g, st, err := s.getGroup(key, group)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errNoGroup(key, group)
+	}
+	g.consumers[consumer] = struct{}{}
+
+	msgs, claimed := []xMessage{}, []string{}
+	for _, id := range ids {
+		p := g.findPending(id)
+		if p == nil || s.now().Sub(p.deliveredAt) < minIdle {
+			continue
+		}
+		e, ok := st.lookup(id)
+		if !ok {
+			g.removePending(id)
+			continue
+		}
+		p.consumer = consumer
+		p.deliveredAt = s.now()
+		if !justID {
+			p.count++
+		}
+		msgs = append(msgs, message(e))
+		claimed = append(claimed, id.String())
+	}
+	if justID {
+		return claimed, nil
+	}
+	return msgs, nil
+}
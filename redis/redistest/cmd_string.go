@@ -0,0 +1,361 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"math"
+	"math/bits"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(map[string]command{
+		"get":         {1, cmdGet},
+		"set":         {2, cmdSet},
+		"setnx":       {2, cmdSetNX},
+		"getset":      {2, cmdGetSet},
+		"mget":        {1, cmdMGet},
+		"mset":        {2, cmdMSet},
+		"append":      {2, cmdAppend},
+		"strlen":      {1, cmdStrLen},
+		"getrange":    {3, cmdGetRange},
+		"setrange":    {3, cmdSetRange},
+		"incr":        {1, cmdIncrBy},
+		"decr":        {1, cmdIncrBy},
+		"incrby":      {2, cmdIncrBy},
+		"decrby":      {2, cmdIncrBy},
+		"incrbyfloat": {2, cmdIncrByFloat},
+		"getbit":      {2, cmdGetBit},
+		"setbit":      {3, cmdSetBit},
+		"bitcount":    {1, cmdBitCount},
+	})
+}
+
+// getString returns the value of key and whether it exists.
+func (s *Server) getString(key string) (string, bool, error) {
+	e, err := s.getKind(key, kindString)
+	if err != nil || e == nil {
+		return "", false, err
+	}
+	return e.str, true, nil
+}
+
+// setString stores v under key, dropping any previous value and TTL.
+func (s *Server) setString(key, v string) *entry {
+	e := newEntry(kindString)
+	e.str = v
+	s.keys[key] = e
+	return e
+}
+
+func cmdGet(s *Server, _ string, args []string) (interface{}, error) {
+	v, ok, err := s.getString(args[0])
+	if err != nil || !ok {
+		return nil, err
+	}
+	return v, nil
+}
+
+func cmdSet(s *Server, name string, args []string) (interface{}, error) {
+	key, value := args[0], args[1]
+	var nx, xx, keepTTL bool
+	var ttl time.Duration
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); opt {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "keepttl":
+			keepTTL = true
+		case "ex", "px":
+			if i+1 >= len(args) || ttl != 0 {
+				return nil, errSyntax
+			}
+			i++
+			n, err := parseInt(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if n <= 0 {
+				return nil, replyError("ERR invalid expire time in '" + name + "' command")
+			}
+			ttl = time.Duration(n) * time.Second
+			if opt == "px" {
+				ttl = time.Duration(n) * time.Millisecond
+			}
+		default:
+			return nil, errSyntax
+		}
+	}
+	if (nx && xx) || (keepTTL && ttl != 0) {
+		return nil, errSyntax
+	}
+
+	old := s.get(key)
+	if (nx && old != nil) || (xx && old == nil) {
+		return nil, nil
+	}
+	e := s.setString(key, value)
+	switch {
+	case ttl > 0:
+		e.expireAt = s.now().Add(ttl)
+	case keepTTL && old != nil:
+		e.expireAt = old.expireAt
+	}
+	return "OK", nil
+}
+
+func cmdSetNX(s *Server, _ string, args []string) (interface{}, error) {
+	if s.get(args[0]) != nil {
+		return int64(0), nil
+	}
+	s.setString(args[0], args[1])
+	return int64(1), nil
+}
+
+func cmdGetSet(s *Server, _ string, args []string) (interface{}, error) {
+	old, ok, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	s.setString(args[0], args[1])
+	if !ok {
+		return nil, nil
+	}
+	return old, nil
+}
+
+func cmdMGet(s *Server, _ string, args []string) (interface{}, error) {
+	res := make([]interface{}, 0, len(args))
+	for _, key := range args {
+		if e := s.get(key); e != nil && e.kind == kindString {
+			res = append(res, e.str)
+		} else {
+			res = append(res, nil)
+		}
+	}
+	return res, nil
+}
+
+func cmdMSet(s *Server, name string, args []string) (interface{}, error) {
+	if len(args)%2 != 0 {
+		return nil, errWrongArgs(name)
+	}
+	for i := 0; i < len(args); i += 2 {
+		s.setString(args[i], args[i+1])
+	}
+	return "OK", nil
+}
+
+func cmdAppend(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getOrCreate(args[0], kindString)
+	if err != nil {
+		return nil, err
+	}
+	e.setStr(e.str + args[1])
+	return int64(len(e.str)), nil
+}
+
+func cmdStrLen(s *Server, _ string, args []string) (interface{}, error) {
+	v, _, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return int64(len(v)), nil
+}
+
+func cmdGetRange(s *Server, _ string, args []string) (interface{}, error) {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	v, _, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, end, ok := normRange(start, end, int64(len(v)))
+	if !ok {
+		return "", nil
+	}
+	return v[start : end+1], nil
+}
+
+func cmdSetRange(s *Server, _ string, args []string) (interface{}, error) {
+	offset, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 || offset > 512<<20 {
+		return nil, replyError("ERR offset is out of range")
+	}
+	e, err := s.getKind(args[0], kindString)
+	if err != nil {
+		return nil, err
+	}
+	value := args[2]
+	if e == nil {
+		if value == "" {
+			return int64(0), nil
+		}
+		e = s.setString(args[0], "")
+	}
+	if value == "" {
+		return int64(len(e.str)), nil
+	}
+
+	b := []byte(e.str)
+	if need := int(offset) + len(value); need > len(b) {
+		b = append(b, make([]byte, need-len(b))...)
+	}
+	copy(b[offset:], value)
+	e.setStr(string(b))
+	return int64(len(b)), nil
+}
+
+func cmdIncrBy(s *Server, name string, args []string) (interface{}, error) {
+	delta := int64(1)
+	if len(args) > 1 {
+		var err error
+		if delta, err = parseInt(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if strings.HasPrefix(name, "decr") {
+		if delta == math.MinInt64 {
+			return nil, errOverflow
+		}
+		delta = -delta
+	}
+
+	e, err := s.getOrCreate(args[0], kindString)
+	if err != nil {
+		return nil, err
+	}
+	var n int64
+	if e.str != "" {
+		if n, err = parseInt(e.str); err != nil {
+			return nil, err
+		}
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return nil, errOverflow
+	}
+	n += delta
+	e.setStr(formatInt(n))
+	return n, nil
+}
+
+func cmdIncrByFloat(s *Server, _ string, args []string) (interface{}, error) {
+	delta, err := parseFloat(args[1])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getOrCreate(args[0], kindString)
+	if err != nil {
+		return nil, err
+	}
+	var f float64
+	if e.str != "" {
+		if f, err = parseFloat(e.str); err != nil {
+			return nil, err
+		}
+	}
+	f += delta
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, replyError("ERR increment would produce NaN or Infinity")
+	}
+	e.setStr(formatFloat(f))
+	return f, nil
+}
+
+func parseBitOffset(v string) (int64, error) {
+	offset, err := parseInt(v)
+	if err != nil || offset < 0 || offset >= 4<<30 {
+		return 0, replyError("ERR bit offset is not an integer or out of range")
+	}
+	return offset, nil
+}
+
+func cmdGetBit(s *Server, _ string, args []string) (interface{}, error) {
+	offset, err := parseBitOffset(args[1])
+	if err != nil {
+		return nil, err
+	}
+	v, _, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return int64(getBit(v, offset)), nil
+}
+
+func getBit(v string, offset int64) int {
+	if offset/8 >= int64(len(v)) {
+		return 0
+	}
+	return int(v[offset/8]>>(7-uint(offset%8))) & 1
+}
+
+func cmdSetBit(s *Server, _ string, args []string) (interface{}, error) {
+	offset, err := parseBitOffset(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if args[2] != "0" && args[2] != "1" {
+		return nil, replyError("ERR bit is not an integer or out of range")
+	}
+	e, err := s.getOrCreate(args[0], kindString)
+	if err != nil {
+		return nil, err
+	}
+
+	b := []byte(e.str)
+	if need := int(offset/8) + 1; need > len(b) {
+		b = append(b, make([]byte, need-len(b))...)
+	}
+	old := int64(b[offset/8]>>(7-uint(offset%8))) & 1
+	mask := byte(1) << (7 - uint(offset%8))
+	if args[2] == "1" {
+		b[offset/8] |= mask
+	} else {
+		b[offset/8] &^= mask
+	}
+	e.setStr(string(b))
+	return old, nil
+}
+
+func cmdBitCount(s *Server, _ string, args []string) (interface{}, error) {
+	if len(args) != 1 && len(args) != 3 {
+		return nil, errSyntax
+	}
+	v, _, err := s.getString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 3 {
+		start, err := parseInt(args[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseInt(args[2])
+		if err != nil {
+			return nil, err
+		}
+		start, end, ok := normRange(start, end, int64(len(v)))
+		if !ok {
+			return int64(0), nil
+		}
+		v = v[start : end+1]
+	}
+	var n int64
+	for i := 0; i < len(v); i++ {
+		n += int64(bits.OnesCount8(v[i]))
+	}
+	return n, nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	register(map[string]command{
+		"watch":   {1, cmdWatch},
+		"unwatch": {1, cmdUnwatch},
+	})
+}
+
+// watch holds the keys watched under a token. A key is modified when a command changes its
+// value or TTL, or when it expires. Unlike redis, writing the value a key already holds
+// does not count as a modification.
+type watch struct {
+	values map[string]string
+	dirty  bool
+}
+
+// fingerprint describes the value and TTL of key, "" when it does not exist.
+func (s *Server) fingerprint(key string) string {
+	e := s.get(key)
+	if e == nil {
+		return ""
+	}
+	if e.stream != nil {
+		return fmt.Sprintf("%v %s", *e, e.stream.fingerprint())
+	}
+	return fmt.Sprintf("%v", *e)
+}
+
+// touched reports whether a watched key changed, now or since the watch was opened.
+func (s *Server) touched(w *watch) bool {
+	if w.dirty {
+		return true
+	}
+	for key, value := range w.values {
+		if s.fingerprint(key) != value {
+			return true
+		}
+	}
+	return false
+}
+
+// track runs fn, marking the watches whose keys it modifies.
+func (s *Server) track(fn func()) {
+	if len(s.watches) == 0 {
+		fn()
+		return
+	}
+	watches := make([]*watch, 0, len(s.watches))
+	before := make(map[string]string)
+	for _, w := range s.watches {
+		watches = append(watches, w)
+		for key := range w.values {
+			before[key] = s.fingerprint(key)
+		}
+	}
+	fn()
+	for _, w := range watches {
+		for key := range w.values {
+			if s.fingerprint(key) != before[key] {
+				w.dirty = true
+			}
+		}
+	}
+}
+
+// cmdWatch replies with the token to send with exec.
+func cmdWatch(s *Server, _ string, args []string) (interface{}, error) {
+	w := &watch{values: make(map[string]string)}
+	for _, key := range args {
+		w.values[key] = s.fingerprint(key)
+	}
+	s.lastWatch++
+	token := fmt.Sprintf("watch-%d", s.lastWatch)
+	s.watches[token] = w
+	return token, nil
+}
+
+func cmdUnwatch(s *Server, _ string, args []string) (interface{}, error) {
+	delete(s.watches, args[0])
+	return "OK", nil
+}
+
+// exec runs the commands of a MULTI/EXEC block, or replies with nil when a key watched
+// under token was modified. args are the token and the commands, as sent by redis.Redis.
+func (s *Server) exec(args []json.RawMessage) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errWrongArgs("exec")
+	}
+	var token string
+	if err := json.Unmarshal(args[0], &token); err != nil {
+		return nil, errSyntax
+	}
+	if token != "" {
+		w, ok := s.watches[token]
+		if !ok {
+			return nil, replyError("ERR EXEC without WATCH")
+		}
+		delete(s.watches, token)
+		if s.touched(w) {
+			return nil, nil
+		}
+	}
+
+	replies := make([]response, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		name, cmdArgs, err := decodeParam(arg)
+		if err != nil {
+			return nil, err
+		}
+		replies = append(replies, s.reply(name, cmdArgs))
+	}
+	return replies, nil
+}
@@ -0,0 +1,957 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register(map[string]command{
+		"zadd":             {3, cmdZAdd},
+		"zaddnx":           {3, cmdZAdd},
+		"zaddxx":           {3, cmdZAdd},
+		"zaddch":           {3, cmdZAdd},
+		"zaddnxch":         {3, cmdZAdd},
+		"zaddxxch":         {3, cmdZAdd},
+		"zincr":            {3, cmdZAdd},
+		"zincrnx":          {3, cmdZAdd},
+		"zincrxx":          {3, cmdZAdd},
+		"zincrby":          {3, cmdZIncrBy},
+		"zcard":            {1, cmdZCard},
+		"zcount":           {3, cmdZCount},
+		"zscore":           {2, cmdZScore},
+		"zrank":            {2, cmdZRank},
+		"zrevrank":         {2, cmdZRank},
+		"zrem":             {2, cmdZRem},
+		"zrange":           {3, cmdZRange},
+		"zrevrange":        {3, cmdZRange},
+		"zrangebyscore":    {3, cmdZRangeByScore},
+		"zrevrangebyscore": {3, cmdZRangeByScore},
+		"zremrangebyrank":  {3, cmdZRemRangeByRank},
+		"zremrangebyscore": {3, cmdZRemRangeByScore},
+		"zinterstore":      {3, cmdZStore},
+		"zunionstore":      {3, cmdZStore},
+		"zinter":           {2, cmdZCombine},
+		"zunion":           {2, cmdZCombine},
+		"zdiff":            {2, cmdZDiff},
+		"zpopmin":          {1, cmdZPop},
+		"zpopmax":          {1, cmdZPop},
+		"bzpopmin":         {2, cmdBZPop},
+		"bzpopmax":         {2, cmdBZPop},
+		"zrangebylex":      {3, cmdZRangeByLex},
+		"zrevrangebylex":   {3, cmdZRangeByLex},
+		"zlexcount":        {3, cmdZLexCount},
+		"zremrangebylex":   {3, cmdZRemRangeByLex},
+		"zrandmember":      {1, cmdZRandMember},
+		"zmscore":          {2, cmdZMScore},
+		"zrangestore":      {4, cmdZRangeStore},
+	})
+}
+
+// z is a sorted set member as it is encoded in the replies decoded into redis.Z.
+type z struct {
+	Score  float64
+	Member string
+}
+
+// getZSet returns the sorted set entry of key, nil when it does not exist.
+func (s *Server) getZSet(key string) (*entry, error) {
+	return s.getKind(key, kindZSet)
+}
+
+// sortedZ returns the members of a sorted set ordered by score, then by member.
+func sortedZ(zset map[string]float64) []z {
+	res := make([]z, 0, len(zset))
+	for member, score := range zset {
+		res = append(res, z{Score: score, Member: member})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score < res[j].Score
+		}
+		return res[i].Member < res[j].Member
+	})
+	return res
+}
+
+func reverseZ(zs []z) {
+	for i, j := 0, len(zs)-1; i < j; i, j = i+1, j-1 {
+		zs[i], zs[j] = zs[j], zs[i]
+	}
+}
+
+func zReply(zs []z, withScores bool) interface{} {
+	if withScores {
+		if zs == nil {
+			zs = []z{}
+		}
+		return zs
+	}
+	res := make([]string, 0, len(zs))
+	for _, m := range zs {
+		res = append(res, m.Member)
+	}
+	return res
+}
+
+func parseScore(v string) (float64, error) {
+	switch strings.ToLower(v) {
+	case "+inf", "inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+// scoreRange is a ZRANGEBYSCORE interval such as "(1 +inf".
+type scoreRange struct {
+	min, max         float64
+	minExcl, maxExcl bool
+}
+
+func parseScoreRange(min, max string) (*scoreRange, error) {
+	r := &scoreRange{}
+	var err error
+	if strings.HasPrefix(min, "(") {
+		r.minExcl, min = true, min[1:]
+	}
+	if strings.HasPrefix(max, "(") {
+		r.maxExcl, max = true, max[1:]
+	}
+	if r.min, err = parseScore(min); err != nil {
+		return nil, replyError("ERR min or max is not a float")
+	}
+	if r.max, err = parseScore(max); err != nil {
+		return nil, replyError("ERR min or max is not a float")
+	}
+	return r, nil
+}
+
+func (r *scoreRange) contains(score float64) bool {
+	if score < r.min || (r.minExcl && score == r.min) {
+		return false
+	}
+	if score > r.max || (r.maxExcl && score == r.max) {
+		return false
+	}
+	return true
+}
+
+func cmdZAdd(s *Server, name string, args []string) (interface{}, error) {
+	flags := strings.TrimPrefix(strings.TrimPrefix(name, "zadd"), "zincr")
+	nx, xx := strings.Contains(flags, "nx"), strings.Contains(flags, "xx")
+	ch, incr := strings.Contains(flags, "ch"), strings.HasPrefix(name, "zincr")
+
+	key, args := args[0], args[1:]
+	for len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "ch":
+			ch = true
+		case "incr":
+			incr = true
+		default:
+			goto pairs
+		}
+		args = args[1:]
+	}
+pairs:
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, errSyntax
+	}
+	if nx && xx {
+		return nil, replyError("ERR XX and NX options at the same time are not compatible")
+	}
+	if incr && len(args) != 2 {
+		return nil, replyError("ERR INCR option supports a single increment-element pair")
+	}
+	scores := make([]float64, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		score, err := parseScore(args[i])
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+
+	e, err := s.getOrCreate(key, kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	var added, changed int64
+	for i, score := range scores {
+		member := args[2*i+1]
+		old, exists := e.zset[member]
+		if (nx && exists) || (xx && !exists) {
+			if incr {
+				return nil, nil
+			}
+			continue
+		}
+		if incr {
+			score += old
+			if math.IsNaN(score) {
+				return nil, replyError("ERR resulting score is not a number (NaN)")
+			}
+			e.zset[member] = score
+			return score, nil
+		}
+		e.zset[member] = score
+		if !exists {
+			added++
+		} else if old != score {
+			changed++
+		}
+	}
+	if ch {
+		return added + changed, nil
+	}
+	return added, nil
+}
+
+func cmdZIncrBy(s *Server, _ string, args []string) (interface{}, error) {
+	return cmdZAdd(s, "zincr", args)
+}
+
+func cmdZCard(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	return int64(len(e.zset)), nil
+}
+
+func cmdZCount(s *Server, _ string, args []string) (interface{}, error) {
+	r, err := parseScoreRange(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	var n int64
+	for _, score := range e.zset {
+		if r.contains(score) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdZScore(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return nil, err
+	}
+	if score, ok := e.zset[args[1]]; ok {
+		return score, nil
+	}
+	return nil, nil
+}
+
+func cmdZRank(s *Server, name string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return nil, err
+	}
+	zs := sortedZ(e.zset)
+	if name == "zrevrank" {
+		reverseZ(zs)
+	}
+	for i, m := range zs {
+		if m.Member == args[1] {
+			return int64(i), nil
+		}
+	}
+	return nil, nil
+}
+
+func cmdZRem(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	var n int64
+	for _, member := range args[1:] {
+		if _, ok := e.zset[member]; ok {
+			delete(e.zset, member)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdZRange(s *Server, name string, args []string) (interface{}, error) {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	withScores := false
+	for _, opt := range args[3:] {
+		if strings.ToLower(opt) != "withscores" {
+			return nil, errSyntax
+		}
+		withScores = true
+	}
+
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var zs []z
+	if e != nil {
+		zs = sortedZ(e.zset)
+	}
+	if name == "zrevrange" {
+		reverseZ(zs)
+	}
+	start, stop, ok := normRange(start, stop, int64(len(zs)))
+	if !ok {
+		return zReply(nil, withScores), nil
+	}
+	return zReply(zs[start:stop+1], withScores), nil
+}
+
+func cmdZRangeByScore(s *Server, name string, args []string) (interface{}, error) {
+	rev := name == "zrevrangebyscore"
+	min, max := args[1], args[2]
+	if rev {
+		min, max = max, min
+	}
+	r, err := parseScoreRange(min, max)
+	if err != nil {
+		return nil, err
+	}
+
+	withScores := false
+	offset, count := int64(0), int64(-1)
+	for i := 3; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "withscores":
+			withScores = true
+		case "limit":
+			if i+2 >= len(args) {
+				return nil, errSyntax
+			}
+			if offset, err = parseInt(args[i+1]); err != nil {
+				return nil, err
+			}
+			if count, err = parseInt(args[i+2]); err != nil {
+				return nil, err
+			}
+			i += 2
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	zs := []z{}
+	if e != nil {
+		for _, m := range sortedZ(e.zset) {
+			if r.contains(m.Score) {
+				zs = append(zs, m)
+			}
+		}
+	}
+	if rev {
+		reverseZ(zs)
+	}
+	if offset < 0 || offset >= int64(len(zs)) {
+		return zReply(nil, withScores), nil
+	}
+	zs = zs[offset:]
+	if count >= 0 && count < int64(len(zs)) {
+		zs = zs[:count]
+	}
+	return zReply(zs, withScores), nil
+}
+
+func cmdZRemRangeByRank(s *Server, _ string, args []string) (interface{}, error) {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	zs := sortedZ(e.zset)
+	start, stop, ok := normRange(start, stop, int64(len(zs)))
+	if !ok {
+		return int64(0), nil
+	}
+	for _, m := range zs[start : stop+1] {
+		delete(e.zset, m.Member)
+	}
+	return stop - start + 1, nil
+}
+
+func cmdZRemRangeByScore(s *Server, _ string, args []string) (interface{}, error) {
+	r, err := parseScoreRange(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	var n int64
+	for member, score := range e.zset {
+		if r.contains(score) {
+			delete(e.zset, member)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// cmdZStore implements ZINTERSTORE and ZUNIONSTORE. Plain sets are accepted as
+// sorted sets whose members all score 1.
+func cmdZStore(s *Server, name string, args []string) (interface{}, error) {
+	res, err := s.zCombine(strings.TrimSuffix(name, "store"), args[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.keys, args[0])
+	if len(res) > 0 {
+		e := newEntry(kindZSet)
+		e.zset = res
+		s.keys[args[0]] = e
+	}
+	return int64(len(res)), nil
+}
+
+// cmdZCombine implements ZINTER and ZUNION.
+func cmdZCombine(s *Server, name string, args []string) (interface{}, error) {
+	withScores := strings.ToLower(args[len(args)-1]) == "withscores"
+	if withScores {
+		args = args[:len(args)-1]
+	}
+	res, err := s.zCombine(name, args)
+	if err != nil {
+		return nil, err
+	}
+	return zReply(sortedZ(res), withScores), nil
+}
+
+// zCombine computes the intersection or the union of the sorted sets of args, which starts
+// with numkeys.
+func (s *Server) zCombine(name string, args []string) (map[string]float64, error) {
+	numKeys, err := parseInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if numKeys < 1 || numKeys > int64(len(args)-1) {
+		return nil, errSyntax
+	}
+	keys := args[1 : 1+numKeys]
+	weights := make([]float64, numKeys)
+	for i := range weights {
+		weights[i] = 1
+	}
+	aggregate := "sum"
+	for i := 1 + int(numKeys); i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		case "weights":
+			if i+int(numKeys) >= len(args) {
+				return nil, errSyntax
+			}
+			for j := range weights {
+				if weights[j], err = parseScore(args[i+1+j]); err != nil {
+					return nil, replyError("ERR weight value is not a float")
+				}
+			}
+			i += int(numKeys)
+		case "aggregate":
+			if i+1 >= len(args) {
+				return nil, errSyntax
+			}
+			aggregate = strings.ToLower(args[i+1])
+			if aggregate != "sum" && aggregate != "min" && aggregate != "max" {
+				return nil, errSyntax
+			}
+			i++
+		default:
+			return nil, errSyntax
+		}
+	}
+
+	sets := make([]map[string]float64, 0, numKeys)
+	for _, key := range keys {
+		e := s.get(key)
+		switch {
+		case e == nil:
+			sets = append(sets, map[string]float64{})
+		case e.kind == kindZSet:
+			sets = append(sets, e.zset)
+		case e.kind == kindSet:
+			set := make(map[string]float64, len(e.set))
+			for member := range e.set {
+				set[member] = 1
+			}
+			sets = append(sets, set)
+		default:
+			return nil, errWrongType
+		}
+	}
+
+	res := make(map[string]float64)
+	for i, set := range sets {
+		for member, score := range set {
+			score *= weights[i]
+			if math.IsNaN(score) {
+				score = 0
+			}
+			old, ok := res[member]
+			if !ok {
+				res[member] = score
+				continue
+			}
+			switch aggregate {
+			case "sum":
+				res[member] = old + score
+			case "min":
+				res[member] = math.Min(old, score)
+			case "max":
+				res[member] = math.Max(old, score)
+			}
+		}
+	}
+	if name == "zinter" {
+		for member := range res {
+			for _, set := range sets {
+				if _, ok := set[member]; !ok {
+					delete(res, member)
+					break
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// zSets returns the sorted sets of keys, nil for the keys that do not exist.
+func (s *Server) zSets(keys []string) ([]map[string]float64, error) {
+	sets := make([]map[string]float64, len(keys))
+	for i, key := range keys {
+		e, err := s.getZSet(key)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			sets[i] = e.zset
+		}
+	}
+	return sets, nil
+}
+
+func cmdZDiff(s *Server, _ string, args []string) (interface{}, error) {
+	numKeys, err := parseInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if numKeys < 1 || numKeys > int64(len(args)-1) {
+		return nil, errSyntax
+	}
+	withScores := false
+	for _, opt := range args[1+numKeys:] {
+		if strings.ToLower(opt) != "withscores" {
+			return nil, errSyntax
+		}
+		withScores = true
+	}
+	sets, err := s.zSets(args[1 : 1+numKeys])
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]float64)
+	for member, score := range sets[0] {
+		res[member] = score
+	}
+	for _, set := range sets[1:] {
+		for member := range set {
+			delete(res, member)
+		}
+	}
+	return zReply(sortedZ(res), withScores), nil
+}
+
+// zPop removes the count members with the lowest, or highest, scores of a sorted set.
+func zPop(zset map[string]float64, max bool, count int64) []z {
+	zs := sortedZ(zset)
+	if max {
+		reverseZ(zs)
+	}
+	if count < int64(len(zs)) {
+		zs = zs[:count]
+	}
+	for _, m := range zs {
+		delete(zset, m.Member)
+	}
+	return zs
+}
+
+func cmdZPop(s *Server, name string, args []string) (interface{}, error) {
+	count := int64(1)
+	switch len(args) {
+	case 1:
+	case 2:
+		var err error
+		if count, err = parseInt(args[1]); err != nil || count < 0 {
+			return nil, replyError("ERR value is out of range, must be positive")
+		}
+	default:
+		return nil, errSyntax
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return []z{}, err
+	}
+	return zPop(e.zset, name == "zpopmax", count), nil
+}
+
+// zWithKey is a popped member as it is encoded in the replies decoded into redis.ZWithKey.
+type zWithKey struct {
+	z
+	Key string
+}
+
+func cmdBZPop(s *Server, name string, args []string) (interface{}, error) {
+	keys := args[:len(args)-1]
+	if err := parseTimeout(args[len(args)-1]); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		e, err := s.getZSet(key)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil && len(e.zset) > 0 {
+			return zWithKey{z: zPop(e.zset, name == "bzpopmax", 1)[0], Key: key}, nil
+		}
+	}
+	return nil, nil
+}
+
+// lexRange is a ZRANGEBYLEX interval such as "[a (c", "-" and "+" being the infinities.
+type lexRange struct {
+	min, max         string
+	minExcl, maxExcl bool
+	minInf, maxInf   bool
+}
+
+func parseLexBound(v string) (bound string, excl, inf bool, err error) {
+	switch {
+	case v == "-" || v == "+":
+		return v, false, true, nil
+	case strings.HasPrefix(v, "["):
+		return v[1:], false, false, nil
+	case strings.HasPrefix(v, "("):
+		return v[1:], true, false, nil
+	}
+	return "", false, false, replyError("ERR min or max not valid string range item")
+}
+
+func parseLexRange(min, max string) (*lexRange, error) {
+	r := &lexRange{}
+	var err error
+	if r.min, r.minExcl, r.minInf, err = parseLexBound(min); err != nil {
+		return nil, err
+	}
+	if r.max, r.maxExcl, r.maxInf, err = parseLexBound(max); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *lexRange) contains(member string) bool {
+	if r.minInf {
+		if r.min == "+" {
+			return false
+		}
+	} else if member < r.min || (r.minExcl && member == r.min) {
+		return false
+	}
+	if r.maxInf {
+		return r.max == "+"
+	}
+	return member < r.max || (!r.maxExcl && member == r.max)
+}
+
+// zSelect returns the members of zset between start and stop, by rank, by score or by lex
+// as ZRANGE does. With rev, start is the upper bound and the members are in reverse order.
+// A negative count returns all the members after offset.
+func zSelect(zset map[string]float64, by string, start, stop string, rev bool, offset, count int64) ([]z, error) {
+	zs := sortedZ(zset)
+	if by == "" {
+		if offset != 0 || count >= 0 {
+			return nil, replyError("ERR syntax error, LIMIT is only supported in combination with either BYSCORE or BYLEX")
+		}
+		from, err := parseInt(start)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseInt(stop)
+		if err != nil {
+			return nil, err
+		}
+		if rev {
+			reverseZ(zs)
+		}
+		from, to, ok := normRange(from, to, int64(len(zs)))
+		if !ok {
+			return []z{}, nil
+		}
+		return zs[from : to+1], nil
+	}
+
+	if rev {
+		start, stop = stop, start
+	}
+	var contains func(m z) bool
+	if by == "byscore" {
+		r, err := parseScoreRange(start, stop)
+		if err != nil {
+			return nil, err
+		}
+		contains = func(m z) bool { return r.contains(m.Score) }
+	} else {
+		r, err := parseLexRange(start, stop)
+		if err != nil {
+			return nil, err
+		}
+		contains = func(m z) bool { return r.contains(m.Member) }
+	}
+	res := []z{}
+	for _, m := range zs {
+		if contains(m) {
+			res = append(res, m)
+		}
+	}
+	if rev {
+		reverseZ(res)
+	}
+	if offset < 0 || offset >= int64(len(res)) {
+		return []z{}, nil
+	}
+	res = res[offset:]
+	if count >= 0 && count < int64(len(res)) {
+		res = res[:count]
+	}
+	return res, nil
+}
+
+// parseLimit parses the LIMIT offset count option at args[i], if any.
+func parseLimit(args []string, i int) (offset, count int64, err error) {
+	if i+2 >= len(args) {
+		return 0, 0, errSyntax
+	}
+	if offset, err = parseInt(args[i+1]); err != nil {
+		return 0, 0, err
+	}
+	if count, err = parseInt(args[i+2]); err != nil {
+		return 0, 0, err
+	}
+	return offset, count, nil
+}
+
+func cmdZRangeByLex(s *Server, name string, args []string) (interface{}, error) {
+	offset, count := int64(0), int64(-1)
+	for i := 3; i < len(args); i += 3 {
+		if strings.ToLower(args[i]) != "limit" {
+			return nil, errSyntax
+		}
+		var err error
+		if offset, count, err = parseLimit(args, i); err != nil {
+			return nil, err
+		}
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var zset map[string]float64
+	if e != nil {
+		zset = e.zset
+	}
+	zs, err := zSelect(zset, "bylex", args[1], args[2], name == "zrevrangebylex", offset, count)
+	if err != nil {
+		return nil, err
+	}
+	return zReply(zs, false), nil
+}
+
+func cmdZLexCount(s *Server, _ string, args []string) (interface{}, error) {
+	r, err := parseLexRange(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	var n int64
+	for member := range e.zset {
+		if r.contains(member) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdZRemRangeByLex(s *Server, _ string, args []string) (interface{}, error) {
+	r, err := parseLexRange(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.getZSet(args[0])
+	if err != nil || e == nil {
+		return int64(0), err
+	}
+	var n int64
+	for member := range e.zset {
+		if r.contains(member) {
+			delete(e.zset, member)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func cmdZRandMember(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var zset map[string]float64
+	if e != nil {
+		zset = e.zset
+	}
+	if len(args) == 1 {
+		if len(zset) == 0 {
+			return nil, nil
+		}
+		members := s.zRandMembers(zset, 1)
+		return members[0].Member, nil
+	}
+
+	count, err := parseInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	withScores := false
+	switch {
+	case len(args) == 3 && strings.ToLower(args[2]) == "withscores":
+		withScores = true
+	case len(args) > 2:
+		return nil, errSyntax
+	}
+	return zReply(s.zRandMembers(zset, count), withScores), nil
+}
+
+// zRandMembers picks count distinct members of zset, or -count members that may repeat.
+func (s *Server) zRandMembers(zset map[string]float64, count int64) []z {
+	zs := sortedZ(zset)
+	if count >= 0 {
+		if count > int64(len(zs)) {
+			count = int64(len(zs))
+		}
+		s.rand.Shuffle(len(zs), func(i, j int) {
+			zs[i], zs[j] = zs[j], zs[i]
+		})
+		return zs[:count]
+	}
+	res := make([]z, 0, -count)
+	for i := int64(0); i < -count && len(zs) > 0; i++ {
+		res = append(res, zs[s.rand.Intn(len(zs))])
+	}
+	return res
+}
+
+func cmdZMScore(s *Server, _ string, args []string) (interface{}, error) {
+	e, err := s.getZSet(args[0])
+	if err != nil {
+		return nil, err
+	}
+	res := make([]interface{}, len(args)-1)
+	for i, member := range args[1:] {
+		if e == nil {
+			continue
+		}
+		if score, ok := e.zset[member]; ok {
+			res[i] = score
+		}
+	}
+	return res, nil
+}
+
+func cmdZRangeStore(s *Server, _ string, args []string) (interface{}, error) {
+	by, rev := "", false
+	offset, count := int64(0), int64(-1)
+	for i := 4; i < len(args); i++ {
+		switch opt := strings.ToLower(args[i]); opt {
+		case "byscore", "bylex":
+			by = opt
+		case "rev":
+			rev = true
+		case "limit":
+			var err error
+			if offset, count, err = parseLimit(args, i); err != nil {
+				return nil, err
+			}
+			i += 2
+		default:
+			return nil, errSyntax
+		}
+	}
+	e, err := s.getZSet(args[1])
+	if err != nil {
+		return nil, err
+	}
+	var zset map[string]float64
+	if e != nil {
+		zset = e.zset
+	}
+	zs, err := zSelect(zset, by, args[2], args[3], rev, offset, count)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.keys, args[0])
+	if len(zs) > 0 {
+		dst := newEntry(kindZSet)
+		for _, m := range zs {
+			dst.zset[m.Member] = m.Score
+		}
+		s.keys[args[0]] = dst
+	}
+	return int64(len(zs)), nil
+}
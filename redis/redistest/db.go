@@ -0,0 +1,163 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	kindString = "string"
+	kindHash   = "hash"
+	kindList   = "list"
+	kindSet    = "set"
+	kindZSet   = "zset"
+	kindStream = "stream"
+)
+
+// entry is the value stored under a key. Only the field matching kind is used,
+// except for HyperLogLogs which are strings that also keep their members in hll.
+type entry struct {
+	kind     string
+	str      string
+	hll      map[string]struct{}
+	hash     map[string]string
+	list     []string
+	set      map[string]struct{}
+	zset     map[string]float64
+	stream   *stream
+	expireAt time.Time
+}
+
+func newEntry(kind string) *entry {
+	e := &entry{kind: kind}
+	switch kind {
+	case kindHash:
+		e.hash = make(map[string]string)
+	case kindSet:
+		e.set = make(map[string]struct{})
+	case kindZSet:
+		e.zset = make(map[string]float64)
+	case kindStream:
+		e.stream = newStream()
+	}
+	return e
+}
+
+func (e *entry) setStr(v string) {
+	e.str = v
+	e.hll = nil
+}
+
+func (e *entry) empty() bool {
+	switch e.kind {
+	case kindHash:
+		return len(e.hash) == 0
+	case kindList:
+		return len(e.list) == 0
+	case kindSet:
+		return len(e.set) == 0
+	case kindZSet:
+		return len(e.zset) == 0
+	}
+	return false
+}
+
+// get returns the live entry of key, or nil when it does not exist or has expired.
+func (s *Server) get(key string) *entry {
+	e, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.now().Before(e.expireAt) {
+		delete(s.keys, key)
+		return nil
+	}
+	return e
+}
+
+// getKind is get that also checks the entry holds a value of kind.
+func (s *Server) getKind(key, kind string) (*entry, error) {
+	e := s.get(key)
+	if e != nil && e.kind != kind {
+		return nil, errWrongType
+	}
+	return e, nil
+}
+
+// getOrCreate is getKind that creates the entry when key does not exist.
+func (s *Server) getOrCreate(key, kind string) (*entry, error) {
+	e, err := s.getKind(key, kind)
+	if err != nil || e != nil {
+		return e, err
+	}
+	e = newEntry(kind)
+	s.keys[key] = e
+	return e, nil
+}
+
+// sweep deletes the containers emptied by the last command, as redis does.
+func (s *Server) sweep() {
+	for key, e := range s.keys {
+		if e.empty() {
+			delete(s.keys, key)
+		}
+	}
+}
+
+func parseInt(v string) (int64, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	return n, nil
+}
+
+func parseFloat(v string) (float64, error) {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, errNotFloat
+	}
+	return f, nil
+}
+
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// normRange resolves the inclusive start and stop indexes, which may be negative,
+// against a sequence of n elements. ok is false when the range is empty.
+func normRange(start, stop, n int64) (int64, int64, bool) {
+	if start < 0 {
+		start += n
+		if start < 0 {
+			start = 0
+		}
+	}
+	if stop < 0 {
+		stop += n
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0, false
+	}
+	return start, stop, true
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,236 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// runLua runs the script src as redis does: with the KEYS and ARGV tables, the base, table,
+// string and math libraries, and the redis library running the commands on s.
+func (s *Server) runLua(src string, keys, argv []string) (interface{}, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	for _, lib := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.fn))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	L.SetGlobal("KEYS", toLua(L, keys))
+	L.SetGlobal("ARGV", toLua(L, argv))
+	lib := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"call":  func(L *lua.LState) int { return s.luaCall(L, true) },
+		"pcall": func(L *lua.LState) int { return s.luaCall(L, false) },
+		"error_reply": func(L *lua.LState) int {
+			L.Push(luaTable(L, "err", L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			L.Push(luaTable(L, "ok", L.CheckString(1)))
+			return 1
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(scriptHash(L.CheckString(1))))
+			return 1
+		},
+		"replicate_commands": func(L *lua.LState) int {
+			L.Push(lua.LTrue)
+			return 1
+		},
+		"log": func(L *lua.LState) int { return 0 },
+	})
+	for i, level := range []string{"LOG_DEBUG", "LOG_VERBOSE", "LOG_NOTICE", "LOG_WARNING"} {
+		lib.RawSetString(level, lua.LNumber(i))
+	}
+	L.SetGlobal("redis", lib)
+
+	fn, err := L.LoadString(src)
+	if err != nil {
+		return nil, replyError(fmt.Sprintf("ERR Error compiling script: %v", err))
+	}
+	L.Push(fn)
+	if err := L.PCall(0, 1, nil); err != nil {
+		// redis.call raises the error reply of the command, which the script fails with
+		if apiErr, ok := err.(*lua.ApiError); ok {
+			if t, ok := apiErr.Object.(*lua.LTable); ok {
+				if msg, ok := t.RawGetString("err").(lua.LString); ok {
+					return nil, replyError(msg)
+				}
+			}
+		}
+		return nil, replyError(fmt.Sprintf("ERR Error running script: %v", err))
+	}
+	return fromLua(L.Get(-1))
+}
+
+// luaCall runs the command given by the arguments of redis.call, raising its error, or of
+// redis.pcall, returning its error as an error reply.
+func (s *Server) luaCall(L *lua.LState, raise bool) int {
+	fail := func(msg string) int {
+		reply := luaTable(L, "err", msg)
+		if raise {
+			L.Error(reply, 1)
+		}
+		L.Push(reply)
+		return 1
+	}
+
+	n := L.GetTop()
+	if n == 0 {
+		return fail("ERR Please specify at least one argument for this redis lib call")
+	}
+	args := make([]string, n)
+	for i := range args {
+		switch v := L.Get(i + 1).(type) {
+		case lua.LString:
+			args[i] = string(v)
+		case lua.LNumber:
+			args[i] = formatLuaNumber(v)
+		default:
+			return fail("ERR Lua redis lib command arguments must be strings or integers")
+		}
+	}
+
+	reply, err := s.do(args[0], args[1:])
+	if err != nil {
+		return fail(err.Error())
+	}
+	L.Push(toLua(L, redisReply(args[0], reply)))
+	return 1
+}
+
+// formatLuaNumber formats n as redis does when a script passes it to a command.
+func formatLuaNumber(n lua.LNumber) string {
+	f := float64(n)
+	if f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'g', 17, 64)
+}
+
+func luaTable(L *lua.LState, field, value string) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString(field, lua.LString(value))
+	return t
+}
+
+// toLua converts the reply of a command to Lua as redis does: integers to numbers, nil to
+// false, arrays to tables. Floats are bulk strings in redis, maps arrays of their keys and
+// values, and the structured replies of redis.Redis the arrays they are decoded from.
+func toLua(L *lua.LState, v interface{}) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LFalse
+	case string:
+		return lua.LString(val)
+	case []byte:
+		return lua.LString(val)
+	case bool:
+		if val {
+			return lua.LNumber(1)
+		}
+		return lua.LNumber(0)
+	case int:
+		return lua.LNumber(val)
+	case int64:
+		return lua.LNumber(val)
+	case float64:
+		return lua.LString(formatFloat(val))
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return lua.LNumber(n)
+		}
+		return lua.LString(val)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		t := L.NewTable()
+		for i := 0; i < rv.Len(); i++ {
+			t.Append(toLua(L, rv.Index(i).Interface()))
+		}
+		return t
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			keys = append(keys, key)
+			values[key] = rv.MapIndex(k).Interface()
+		}
+		sort.Strings(keys)
+		t := L.NewTable()
+		for _, k := range keys {
+			t.Append(lua.LString(k))
+			t.Append(toLua(L, values[k]))
+		}
+		return t
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return lua.LString(fmt.Sprint(v))
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return lua.LString(body)
+	}
+	return toLua(L, decoded)
+}
+
+// fromLua converts the value returned by a script as redis does: numbers to integers, true
+// to 1, false to nil, tables to arrays up to their first nil, and the tables of
+// redis.error_reply and redis.status_reply to errors and statuses.
+func fromLua(v lua.LValue) (interface{}, error) {
+	switch val := v.(type) {
+	case lua.LNumber:
+		return int64(val), nil
+	case lua.LString:
+		return string(val), nil
+	case lua.LBool:
+		if val {
+			return int64(1), nil
+		}
+		return nil, nil
+	case *lua.LTable:
+		if msg, ok := val.RawGetString("err").(lua.LString); ok {
+			return nil, replyError(msg)
+		}
+		if status, ok := val.RawGetString("ok").(lua.LString); ok {
+			return string(status), nil
+		}
+		res := []interface{}{}
+		for i := 1; ; i++ {
+			item := val.RawGetInt(i)
+			if item == lua.LNil {
+				return res, nil
+			}
+			reply, err := fromLua(item)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, reply)
+		}
+	}
+	return nil, nil
+}
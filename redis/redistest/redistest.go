@@ -0,0 +1,328 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+// Package redistest provides an in-memory stand-in for the FaaS infra redis endpoint,
+// so code written against redis.IRedis can be tested without network access.
+//
+//	cli := redistest.NewRedis()
+//	cli.Set(ctx, "k", "v", time.Minute)
+//	cli.FastForward(time.Minute) // "k" is expired now
+//
+// The fake understands the commands sent by redis.Redis and answers them with the
+// semantics of a real redis server: strings, hashes, lists, sets, sorted sets, streams,
+// HyperLogLog, TTLs and MULTI/EXEC blocks guarded by WATCH. Expiry follows a clock that
+// tests can freeze or move forward. Lua scripts run in an embedded Lua 5.1 interpreter,
+// like the one of redis, with the redis library calling the fake; RegisterScript replaces
+// a script with Go code.
+package redistest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// ErrCodeCommand is the code of the error returned when a command fails, e.g. with WRONGTYPE.
+const ErrCodeCommand = "k_redistest_command_error"
+
+// Server keeps the keyspace of an in-memory redis. It implements faasinfra.RedisTransport
+// by decoding the same command payloads the FaaS infra receives and answering
+// with the same JSON responses.
+type Server struct {
+	mu     sync.Mutex
+	keys   map[string]*entry
+	frozen time.Time
+	offset time.Duration
+	rand   *rand.Rand
+
+	watches   map[string]*watch
+	lastWatch int64
+
+	// scripts maps the digests of the loaded scripts to their sources, scriptFuncs to the Go
+	// code replacing them.
+	scripts     map[string]string
+	scriptFuncs map[string]ScriptFunc
+}
+
+func NewServer() *Server {
+	return &Server{
+		keys:        make(map[string]*entry),
+		rand:        rand.New(rand.NewSource(1)),
+		watches:     make(map[string]*watch),
+		scripts:     make(map[string]string),
+		scriptFuncs: make(map[string]ScriptFunc),
+	}
+}
+
+// Redis is a redis.IRedis client connected to its own Server.
+type Redis struct {
+	*redis.Redis
+	*Server
+}
+
+// NewRedis returns a client backed by a new, empty Server.
+func NewRedis() *Redis {
+	s := NewServer()
+	return &Redis{Redis: redis.NewRedis(redis.WithTransport(s)), Server: s}
+}
+
+// Now returns the time the server uses to expire keys.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now()
+}
+
+func (s *Server) now() time.Time {
+	if s.frozen.IsZero() {
+		return time.Now().Add(s.offset)
+	}
+	return s.frozen
+}
+
+// SetTime freezes the server clock at t until the next SetTime or FastForward.
+func (s *Server) SetTime(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.frozen = t
+	s.offset = 0
+}
+
+// FastForward moves the server clock forward by d, expiring every key whose TTL runs out.
+func (s *Server) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.frozen.IsZero() {
+		s.offset += d
+	} else {
+		s.frozen = s.frozen.Add(d)
+	}
+}
+
+// FlushAll deletes all keys.
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = make(map[string]*entry)
+}
+
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+
+const (
+	errWrongType       = replyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInteger      = replyError("ERR value is not an integer or out of range")
+	errNotFloat        = replyError("ERR value is not a valid float")
+	errSyntax          = replyError("ERR syntax error")
+	errNoSuchKey       = replyError("ERR no such key")
+	errIndexOutOfRange = replyError("ERR index out of range")
+	errOverflow        = replyError("ERR increment or decrement would overflow")
+)
+
+func errWrongArgs(name string) error {
+	return replyError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+}
+
+type handler func(s *Server, name string, args []string) (interface{}, error)
+
+type command struct {
+	minArgs int
+	fn      handler
+}
+
+var commands = map[string]command{}
+
+func register(cmds map[string]command) {
+	for name, cmd := range cmds {
+		commands[name] = cmd
+	}
+}
+
+type response struct {
+	Code string      `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data"`
+}
+
+func (s *Server) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, err := s.request(param)
+	return body, nil, err
+}
+
+// DoRequestRedisPipeline runs the commands of a pipeline one after the other, without
+// running the commands of other clients in between.
+func (s *Server) DoRequestRedisPipeline(ctx context.Context, params []interface{}) ([][]byte, map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bodies := make([][]byte, 0, len(params))
+	for _, param := range params {
+		body, err := s.request(param)
+		if err != nil {
+			return nil, nil, err
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil, nil
+}
+
+func (s *Server) request(param interface{}) ([]byte, error) {
+	name, rawArgs, err := decodeRequest(param)
+	if err != nil {
+		return nil, err
+	}
+
+	var res response
+	if strings.ToLower(name) == "exec" {
+		data, err := s.exec(rawArgs)
+		if _, ok := err.(replyError); err != nil && !ok {
+			return nil, err
+		}
+		res = newResponse(data, err)
+	} else {
+		args, err := decodeArgs(rawArgs)
+		if err != nil {
+			return nil, err
+		}
+		res = s.reply(name, args)
+	}
+	body, err := json.Marshal(res)
+	if err != nil {
+		return nil, cExceptions.InternalError("[redistest] marshal response failed, err: %v", err)
+	}
+	return body, nil
+}
+
+// reply runs a single command and returns its response.
+func (s *Server) reply(name string, args []string) response {
+	var data interface{}
+	var err error
+	s.track(func() {
+		data, err = s.do(name, args)
+	})
+	return newResponse(data, err)
+}
+
+func newResponse(data interface{}, err error) response {
+	if err != nil {
+		return response{Code: ErrCodeCommand, Msg: err.Error()}
+	}
+	return response{Code: cExceptions.SCSuccess, Data: data}
+}
+
+// Do runs a single command and returns its reply, nil when redis would reply with a nil.
+func (s *Server) Do(name string, args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var data interface{}
+	var err error
+	s.track(func() {
+		data, err = s.do(name, args)
+	})
+	return data, err
+}
+
+func (s *Server) do(name string, args []string) (interface{}, error) {
+	name = strings.ToLower(name)
+	cmd, ok := commands[name]
+	if !ok {
+		return nil, replyError(fmt.Sprintf("ERR unknown command '%s'", name))
+	}
+	if len(args) < cmd.minArgs {
+		return nil, errWrongArgs(name)
+	}
+
+	defer s.sweep()
+	return cmd.fn(s, name, args)
+}
+
+// decodeParam turns the redisArgumentList sent by redis.Redis into a command name and
+// its arguments, formatted the way a redis client writes them on the wire.
+func decodeParam(param interface{}) (string, []string, error) {
+	name, rawArgs, err := decodeRequest(param)
+	if err != nil {
+		return "", nil, err
+	}
+	args, err := decodeArgs(rawArgs)
+	return name, args, err
+}
+
+func decodeRequest(param interface{}) (string, []json.RawMessage, error) {
+	body, err := json.Marshal(param)
+	if err != nil {
+		return "", nil, cExceptions.InvalidParamError("[redistest] marshal param failed, err: %v", err)
+	}
+	var req struct {
+		Cmd  string            `json:"cmd"`
+		Args []json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", nil, cExceptions.InvalidParamError("[redistest] unmarshal param failed, err: %v", err)
+	}
+	return req.Cmd, req.Args, nil
+}
+
+func decodeArgs(rawArgs []json.RawMessage) ([]string, error) {
+	args := make([]string, 0, len(rawArgs))
+	for _, raw := range rawArgs {
+		var arg interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&arg); err != nil {
+			return nil, cExceptions.InvalidParamError("[redistest] unmarshal param failed, err: %v", err)
+		}
+		args = appendArg(args, arg)
+	}
+	return args, nil
+}
+
+func appendArg(args []string, arg interface{}) []string {
+	switch v := arg.(type) {
+	case []interface{}:
+		for _, item := range v {
+			args = append(args, formatArg(item))
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			args = append(args, k, formatArg(v[k]))
+		}
+	default:
+		args = append(args, formatArg(v))
+	}
+	return args
+}
+
+func formatArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
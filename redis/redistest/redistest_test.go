@@ -0,0 +1,982 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/redis"
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+)
+
+var (
+	ctx = context.Background()
+
+	_ redis.IRedis = NewRedis()
+)
+
+// int64s returns pointers to vs.
+func int64s(vs ...int64) []*int64 {
+	res := make([]*int64, len(vs))
+	for i := range vs {
+		res[i] = &vs[i]
+	}
+	return res
+}
+
+func TestString(t *testing.T) {
+	cli := NewRedis()
+
+	_, err := cli.Get(ctx, "k").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	assert.Equal(t, "OK", cli.Set(ctx, "k", "hello", 0).Val())
+	assert.Equal(t, "hello", cli.Get(ctx, "k").Val())
+	assert.EqualValues(t, 11, cli.Append(ctx, "k", " world").Val())
+	assert.Equal(t, "world", cli.GetRange(ctx, "k", -5, -1).Val())
+	assert.EqualValues(t, 11, cli.SetRange(ctx, "k", 0, "HELLO").Val())
+	assert.Equal(t, "HELLO world", cli.GetSet(ctx, "k", "v").Val())
+	assert.EqualValues(t, 1, cli.StrLen(ctx, "k").Val())
+
+	assert.False(t, cli.SetNX(ctx, "k", "x", 0).Val())
+	assert.True(t, cli.SetXX(ctx, "k", "x").Val())
+	assert.False(t, cli.SetXX(ctx, "missing", "x").Val())
+	assert.True(t, cli.SetNX(ctx, "nx", "x", time.Minute).Val())
+
+	assert.Equal(t, "OK", cli.MSet(ctx, map[string]interface{}{"a": 1, "b": "2"}).Val())
+	assert.Equal(t, []interface{}{"1", "2", nil}, cli.MGet(ctx, "a", "b", "c").Val())
+
+	assert.EqualValues(t, 2, cli.Incr(ctx, "a").Val())
+	assert.EqualValues(t, 12, cli.IncrBy(ctx, "a", 10).Val())
+	assert.EqualValues(t, 7, cli.DecrBy(ctx, "a", 5).Val())
+	assert.EqualValues(t, 6, cli.Decr(ctx, "a").Val())
+	assert.InDelta(t, 6.5, cli.IncrByFloat(ctx, "a", 0.5).Val(), 1e-9)
+	assert.Error(t, cli.Incr(ctx, "a").Err())
+
+	assert.EqualValues(t, 0, cli.SetBit(ctx, "bits", 7, 1).Val())
+	assert.EqualValues(t, 1, cli.GetBit(ctx, "bits", 7).Val())
+	assert.EqualValues(t, 0, cli.GetBit(ctx, "bits", 100).Val())
+	assert.EqualValues(t, 1, cli.BitCount(ctx, "bits", nil).Val())
+	assert.EqualValues(t, 0, cli.BitCount(ctx, "bits", &redis.BitCountArgs{Start: 1, End: -1}).Val())
+}
+
+func TestBit(t *testing.T) {
+	cli := NewRedis()
+
+	cli.Set(ctx, "a", "a", 0)
+	cli.Set(ctx, "b", "b", 0)
+	assert.EqualValues(t, 1, cli.BitOpAnd(ctx, "and", "a", "b").Val())
+	assert.Equal(t, "`", cli.Get(ctx, "and").Val())
+	assert.EqualValues(t, 1, cli.BitOpOr(ctx, "or", "a", "b", "missing").Val())
+	assert.Equal(t, "c", cli.Get(ctx, "or").Val())
+	assert.EqualValues(t, 1, cli.BitOpXor(ctx, "xor", "a", "b").Val())
+	assert.Equal(t, "\x03", cli.Get(ctx, "xor").Val())
+	assert.EqualValues(t, 1, cli.BitOpNot(ctx, "not", "xor").Val())
+	assert.EqualValues(t, 6, cli.BitCount(ctx, "not", nil).Val())
+	assert.EqualValues(t, 0, cli.BitOpAnd(ctx, "and", "missing").Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "and").Val())
+
+	assert.EqualValues(t, 1, cli.BitPos(ctx, "a", 1).Val())
+	assert.EqualValues(t, 0, cli.BitPos(ctx, "a", 0).Val())
+	assert.EqualValues(t, -1, cli.BitPos(ctx, "a", 1, 1).Val())
+	assert.EqualValues(t, -1, cli.BitPos(ctx, "missing", 1).Val())
+	for i := int64(0); i < 8; i++ {
+		cli.SetBit(ctx, "ones", i, 1)
+	}
+	assert.EqualValues(t, 8, cli.BitPos(ctx, "ones", 0).Val())
+	assert.EqualValues(t, -1, cli.BitPos(ctx, "ones", 0, 0, 0).Val())
+	assert.Error(t, cli.BitPos(ctx, "ones", 0, 0, 0, 0).Err())
+
+	vals, err := cli.BitField(ctx, "bf", redis.NewBitFieldArgs().IncrBy("i5", 100, 1).Get("u4", 0)).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64s(1, 0), vals)
+
+	ops := redis.NewBitFieldArgs().IncrBy("u2", 102, 1).Overflow(redis.OverflowSat).IncrBy("u2", 104, 1)
+	for _, want := range [][]int64{{1, 1}, {2, 2}, {3, 3}, {0, 3}} {
+		assert.Equal(t, int64s(want...), cli.BitField(ctx, "counters", ops).Val())
+	}
+	// an overflow under OverflowFail replies with nil, unlike a 0
+	fail := redis.NewBitFieldArgs().Overflow(redis.OverflowFail).IncrBy("u2", 104, 1).Get("u2", 104).IncrBy("u2", 104, -3)
+	assert.Equal(t, []*int64{nil, int64s(3)[0], int64s(0)[0]}, cli.BitField(ctx, "counters", fail).Val())
+
+	vals = cli.BitField(ctx, "bf2", redis.NewBitFieldArgs().Set("u8", "#1", 200).Get("u8", 8).Set("i8", 8, -1).Get("u8", 8)).Val()
+	assert.Equal(t, int64s(0, 200, -56, 255), vals)
+	vals = cli.BitField(ctx, "bf2", redis.NewBitFieldArgs().Get("u16", 0).IncrBy("i8", 8, -128)).Val()
+	assert.Equal(t, int64s(255, 127), vals)
+	assert.Error(t, cli.BitField(ctx, "bf2", redis.NewBitFieldArgs().Get("u64", 0)).Err())
+}
+
+func TestKeys(t *testing.T) {
+	cli := NewRedis()
+	cli.SetTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	cli.Set(ctx, "k", "v", 10*time.Second)
+	cli.Set(ctx, "p", "v", 0)
+	assert.Equal(t, 10*time.Second, cli.TTL(ctx, "k").Val())
+	assert.Equal(t, 10*time.Second, cli.PTTL(ctx, "k").Val())
+	assert.Equal(t, time.Duration(-1), cli.TTL(ctx, "p").Val())
+	assert.Equal(t, time.Duration(-2), cli.TTL(ctx, "missing").Val())
+	assert.Equal(t, "string", cli.Type(ctx, "k").Val())
+	assert.Equal(t, "none", cli.Type(ctx, "missing").Val())
+
+	cli.FastForward(9 * time.Second)
+	assert.EqualValues(t, 2, cli.Exists(ctx, "k", "p").Val())
+	assert.Equal(t, time.Second, cli.PTTL(ctx, "k").Val())
+	cli.FastForward(time.Second)
+	_, err := cli.Get(ctx, "k").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	assert.True(t, cli.Expire(ctx, "p", time.Minute).Val())
+	assert.True(t, cli.Persist(ctx, "p").Val())
+	assert.False(t, cli.Persist(ctx, "p").Val())
+	assert.True(t, cli.PExpire(ctx, "p", 1500*time.Millisecond).Val())
+	assert.Equal(t, 1500*time.Millisecond, cli.PTTL(ctx, "p").Val())
+	assert.True(t, cli.ExpireAt(ctx, "p", cli.Now().Add(time.Hour)).Val())
+	assert.Equal(t, time.Hour, cli.TTL(ctx, "p").Val())
+	assert.True(t, cli.PExpireAt(ctx, "p", cli.Now().Add(-time.Second)).Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "p").Val())
+	assert.False(t, cli.Expire(ctx, "p", time.Minute).Val())
+
+	cli.Set(ctx, "a", "1", 0)
+	cli.Set(ctx, "b", "1", 0)
+	assert.EqualValues(t, 2, cli.Del(ctx, "a", "b", "c").Val())
+}
+
+func TestHash(t *testing.T) {
+	cli := NewRedis()
+
+	assert.True(t, cli.HSet(ctx, "h", "f1", "v1").Val())
+	assert.False(t, cli.HSet(ctx, "h", "f1", "v2").Val())
+	assert.False(t, cli.HSetNX(ctx, "h", "f1", "v3").Val())
+	assert.Equal(t, "OK", cli.HMSet(ctx, "h", map[string]interface{}{"f2": 2, "f3": "3"}).Val())
+	assert.Equal(t, "v2", cli.HGet(ctx, "h", "f1").Val())
+	_, err := cli.HGet(ctx, "h", "missing").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	assert.Equal(t, map[string]string{"f1": "v2", "f2": "2", "f3": "3"}, cli.HGetAll(ctx, "h").Val())
+	assert.Equal(t, []interface{}{"v2", nil}, cli.HMGet(ctx, "h", "f1", "f4").Val())
+	assert.Equal(t, []string{"f1", "f2", "f3"}, cli.HKeys(ctx, "h").Val())
+	assert.Equal(t, []string{"v2", "2", "3"}, cli.HVals(ctx, "h").Val())
+	assert.EqualValues(t, 3, cli.HLen(ctx, "h").Val())
+	assert.True(t, cli.HExists(ctx, "h", "f2").Val())
+
+	assert.EqualValues(t, 5, cli.HIncrBy(ctx, "h", "f2", 3).Val())
+	assert.InDelta(t, 3.5, cli.HIncrByFloat(ctx, "h", "f3", 0.5).Val(), 1e-9)
+	assert.Error(t, cli.HIncrBy(ctx, "h", "f1", 1).Err())
+
+	assert.EqualValues(t, 3, cli.HDel(ctx, "h", "f1", "f2", "f3").Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "h").Val())
+	assert.Empty(t, cli.HGetAll(ctx, "h").Val())
+}
+
+func TestHash_Struct(t *testing.T) {
+	cli := NewRedis()
+
+	type user struct {
+		Name     string        `redis:"name"`
+		Age      int           `redis:"age"`
+		Score    float64       `redis:"score"`
+		Admin    bool          `redis:"admin"`
+		Created  time.Time     `redis:"created"`
+		Session  time.Duration `redis:"session"`
+		Nickname *string       `redis:"nickname"`
+		Note     string        `redis:"note,omitempty"`
+		Ignored  string
+	}
+	nickname := "bob"
+	in := user{
+		Name:     "Robert",
+		Age:      42,
+		Score:    9.5,
+		Admin:    true,
+		Created:  time.Date(2022, 1, 2, 3, 4, 5, 6, time.UTC),
+		Session:  90 * time.Second,
+		Nickname: &nickname,
+		Ignored:  "ignored",
+	}
+	assert.EqualValues(t, 7, cli.HSetStruct(ctx, "user", &in).Val())
+	assert.Equal(t, "1m30s", cli.HGet(ctx, "user", "session").Val())
+	assert.False(t, cli.HExists(ctx, "user", "note").Val())
+
+	var out user
+	assert.NoError(t, cli.HGetAll(ctx, "user").Scan(&out))
+	in.Ignored = ""
+	assert.Equal(t, in, out)
+
+	var partial user
+	assert.NoError(t, cli.HMGet(ctx, "user", "name", "missing", "age").Scan(&partial))
+	assert.Equal(t, user{Name: "Robert", Age: 42}, partial)
+
+	cli.HSet(ctx, "bad", "age", "old")
+	assert.Error(t, cli.HGetAll(ctx, "bad").Scan(&out))
+	assert.Error(t, cli.HGetAll(ctx, "user").Scan(out))
+	assert.Error(t, cli.HSetStruct(ctx, "user", "not a struct").Err())
+
+	var keys struct {
+		A int    `redis:"a"`
+		B string `redis:"b"`
+	}
+	cli.Set(ctx, "a", 1, 0)
+	assert.NoError(t, cli.MGet(ctx, "a", "b").Scan(&keys))
+	assert.Equal(t, 1, keys.A)
+	assert.Equal(t, "", keys.B)
+}
+
+func TestList(t *testing.T) {
+	cli := NewRedis()
+
+	assert.EqualValues(t, 0, cli.LPushX(ctx, "l", "a").Val())
+	assert.EqualValues(t, 3, cli.RPush(ctx, "l", "a", "b", "c").Val())
+	assert.EqualValues(t, 5, cli.LPush(ctx, "l", "y", "z").Val())
+	assert.Equal(t, []string{"z", "y", "a", "b", "c"}, cli.LRange(ctx, "l", 0, -1).Val())
+	assert.EqualValues(t, 6, cli.RPushX(ctx, "l", "a").Val())
+	assert.Equal(t, "c", cli.LIndex(ctx, "l", -2).Val())
+	assert.EqualValues(t, 7, cli.LInsert(ctx, "l", "before", "b", "x").Val())
+	assert.EqualValues(t, -1, cli.LInsert(ctx, "l", "after", "missing", "x").Val())
+	assert.EqualValues(t, 2, cli.LRem(ctx, "l", 0, "a").Val())
+	assert.Equal(t, "OK", cli.LSet(ctx, "l", 0, "Z").Val())
+	assert.Error(t, cli.LSet(ctx, "l", 10, "Z").Err())
+	assert.Equal(t, []string{"Z", "y", "x", "b", "c"}, cli.LRange(ctx, "l", 0, -1).Val())
+
+	assert.Equal(t, "OK", cli.LTrim(ctx, "l", 1, -2).Val())
+	assert.EqualValues(t, 3, cli.LLen(ctx, "l").Val())
+	assert.Equal(t, "y", cli.LPop(ctx, "l").Val())
+	assert.Equal(t, "b", cli.RPop(ctx, "l").Val())
+	assert.Equal(t, "x", cli.RPop(ctx, "l").Val())
+	_, err := cli.LPop(ctx, "l").Result()
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, []string{}, cli.LRange(ctx, "l", 0, -1).Val())
+}
+
+func TestList_Modern(t *testing.T) {
+	cli := NewRedis()
+
+	_, err := cli.BLPop(ctx, time.Second, "l1", "l2").Result()
+	assert.Equal(t, redis.Nil, err)
+	cli.RPush(ctx, "l2", "a", "b", "c", "b")
+	assert.Equal(t, []string{"l2", "a"}, cli.BLPop(ctx, time.Second, "l1", "l2").Val())
+	assert.Equal(t, []string{"l2", "b"}, cli.BRPop(ctx, 0, "l1", "l2").Val())
+
+	assert.Equal(t, "b", cli.LMove(ctx, "l2", "l1", "LEFT", "RIGHT").Val())
+	assert.Equal(t, "c", cli.BLMove(ctx, "l2", "l1", "RIGHT", "LEFT", time.Second).Val())
+	assert.Equal(t, []string{"c", "b"}, cli.LRange(ctx, "l1", 0, -1).Val())
+	_, err = cli.LMove(ctx, "l2", "l1", "LEFT", "LEFT").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	cli.RPush(ctx, "pos", "a", "b", "c", "b", "b")
+	assert.EqualValues(t, 1, cli.LPos(ctx, "pos", "b", redis.LPosArgs{}).Val())
+	assert.EqualValues(t, 3, cli.LPos(ctx, "pos", "b", redis.LPosArgs{Rank: 2}).Val())
+	assert.EqualValues(t, 4, cli.LPos(ctx, "pos", "b", redis.LPosArgs{Rank: -1}).Val())
+	_, err = cli.LPos(ctx, "pos", "b", redis.LPosArgs{MaxLen: 1}).Result()
+	assert.Equal(t, redis.Nil, err)
+	assert.Equal(t, []int64{1, 3, 4}, cli.LPosCount(ctx, "pos", "b", 0, redis.LPosArgs{}).Val())
+	assert.Equal(t, []int64{4, 3}, cli.LPosCount(ctx, "pos", "b", 2, redis.LPosArgs{Rank: -1}).Val())
+
+	key, vals, err := cli.LMPop(ctx, "RIGHT", 2, "missing", "pos").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "pos", key)
+	assert.Equal(t, []string{"b", "b"}, vals)
+	_, _, err = cli.LMPop(ctx, "LEFT", 1, "missing").Result()
+	assert.Equal(t, redis.Nil, err)
+}
+
+// TestBlockTimeout checks the timeouts sent with the blocking commands end before the
+// deadline of ctx and the API timeout.
+func TestBlockTimeout(t *testing.T) {
+	cli := redis.NewRedis(redis.WithTransport(NewServer()), redis.WithApiTimeout(3*time.Second))
+	timeoutArg := func(cmd redis.Cmder) string {
+		args := cmd.Args()
+		return args[len(args)-1].(string)
+	}
+
+	assert.Equal(t, "1", timeoutArg(cli.BLPop(ctx, time.Second, "l")))
+	assert.Equal(t, "0.25", timeoutArg(cli.BZPopMin(ctx, 250*time.Millisecond, "z")))
+	assert.Equal(t, "2.5", timeoutArg(cli.BRPop(ctx, 0, "l")))
+	assert.Equal(t, "2.5", timeoutArg(cli.BLPop(ctx, time.Minute, "l")))
+
+	deadlineCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	timeout, err := strconv.ParseFloat(timeoutArg(cli.BLMove(deadlineCtx, "a", "b", "LEFT", "LEFT", 0)), 64)
+	assert.NoError(t, err)
+	assert.True(t, timeout > 1.4 && timeout <= 1.5, timeout)
+
+	blockArg := func(cmd redis.Cmder) interface{} {
+		args := cmd.Args()
+		for i, arg := range args {
+			if arg == "block" {
+				return args[i+1]
+			}
+		}
+		return nil
+	}
+	assert.EqualValues(t, 2500, blockArg(cli.XRead(ctx, &redis.XReadArgs{Streams: []string{"s", "$"}, Block: time.Minute})))
+	assert.EqualValues(t, 2500, blockArg(cli.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "g", Consumer: "c", Streams: []string{"s", ">"}, Block: time.Minute})))
+
+	// without WithApiTimeout, only the deadline of ctx bounds the timeouts
+	unbounded := redis.NewRedis(redis.WithTransport(NewServer()))
+	assert.Equal(t, "60", timeoutArg(unbounded.BLPop(ctx, time.Minute, "l")))
+	assert.EqualValues(t, 60000, blockArg(unbounded.XRead(ctx, &redis.XReadArgs{Streams: []string{"s", "$"}, Block: time.Minute})))
+	longCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	timeout, err = strconv.ParseFloat(timeoutArg(unbounded.BRPop(longCtx, 0, "l")), 64)
+	assert.NoError(t, err)
+	assert.True(t, timeout > 29.4 && timeout <= 29.5, timeout)
+
+	shortCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, cli.BLPop(shortCtx, time.Second, "l").Err())
+	assert.Equal(t, context.DeadlineExceeded, cli.XRead(shortCtx, &redis.XReadArgs{Streams: []string{"s", "$"}, Block: time.Second}).Err())
+
+	// in a pipeline, Exec reports the error and sends the other commands
+	_, err = cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		p.BLPop(shortCtx, time.Second, "l")
+		p.Set(ctx, "k", "v", 0)
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, "v", cli.Get(ctx, "k").Val())
+	// a transaction is not run at all
+	cmds, err := cli.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, "k", "w", 0)
+		p.BZPopMin(shortCtx, time.Second, "z")
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, context.DeadlineExceeded, cmds[0].Err())
+	assert.Equal(t, "v", cli.Get(ctx, "k").Val())
+}
+
+// deadlineTransport records the deadlines of the requests it sends.
+type deadlineTransport struct {
+	*Server
+	deadlines []time.Duration
+}
+
+func (t *deadlineTransport) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	var d time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		d = time.Until(deadline).Round(time.Second)
+	}
+	t.deadlines = append(t.deadlines, d)
+	return t.Server.DoRequestRedis(ctx, param)
+}
+
+func TestApiTimeout(t *testing.T) {
+	transport := &deadlineTransport{Server: NewServer()}
+	redis.NewRedis(redis.WithTransport(transport)).Get(ctx, "k")
+	redis.NewRedis(redis.WithTransport(transport), redis.WithApiTimeout(3*time.Second)).Get(ctx, "k")
+	assert.Equal(t, []time.Duration{0, 3 * time.Second}, transport.deadlines)
+}
+
+func TestSet(t *testing.T) {
+	cli := NewRedis()
+
+	assert.EqualValues(t, 3, cli.SAdd(ctx, "s1", "a", "b", "c").Val())
+	assert.EqualValues(t, 1, cli.SAdd(ctx, "s1", "a", "d").Val())
+	cli.SAdd(ctx, "s2", "c", "d", "e")
+
+	assert.EqualValues(t, 4, cli.SCard(ctx, "s1").Val())
+	assert.True(t, cli.SIsMember(ctx, "s1", "a").Val())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, cli.SMembers(ctx, "s1").Val())
+	assert.Equal(t, []string{"a", "b"}, cli.SDiff(ctx, "s1", "s2").Val())
+	assert.Equal(t, []string{"c", "d"}, cli.SInter(ctx, "s1", "s2").Val())
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, cli.SUnion(ctx, "s1", "s2").Val())
+	assert.EqualValues(t, 2, cli.SInterStore("s3", ctx, "s1", "s2").Val())
+	assert.EqualValues(t, 1, cli.SDiffStore("s4", ctx, "s2", "s1").Val())
+	assert.EqualValues(t, 5, cli.SUnionStore("s5", ctx, "s1", "s2").Val())
+	assert.Equal(t, []string{"c", "d"}, cli.SMembers(ctx, "s3").Val())
+
+	assert.True(t, cli.SMove(ctx, "s1", "s4", "a").Val())
+	assert.False(t, cli.SMove(ctx, "s1", "s4", "a").Val())
+	assert.EqualValues(t, 1, cli.SRem(ctx, "s4", "a", "x").Val())
+
+	assert.Contains(t, []string{"b", "c", "d"}, cli.SRandMember(ctx, "s1").Val())
+	assert.Len(t, cli.SRandMemberN(ctx, "s1", 10).Val(), 3)
+	assert.Len(t, cli.SRandMemberN(ctx, "s1", -5).Val(), 5)
+	assert.Len(t, cli.SPopN(ctx, "s1", 2).Val(), 2)
+	assert.NoError(t, cli.SPop(ctx, "s1").Err())
+	_, err := cli.SPop(ctx, "s1").Result()
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestZSet(t *testing.T) {
+	cli := NewRedis()
+
+	assert.EqualValues(t, 3, cli.ZAdd(ctx, "z", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}, &redis.Z{Score: 3, Member: "c"}).Val())
+	assert.EqualValues(t, 0, cli.ZAddNX(ctx, "z", &redis.Z{Score: 10, Member: "a"}).Val())
+	assert.EqualValues(t, 0, cli.ZAddXX(ctx, "z", &redis.Z{Score: 10, Member: "x"}).Val())
+	assert.EqualValues(t, 1, cli.ZAddCh(ctx, "z", &redis.Z{Score: 4, Member: "c"}).Val())
+	assert.EqualValues(t, 1, cli.ZAddNXCh(ctx, "z", &redis.Z{Score: 5, Member: "d"}).Val())
+	assert.EqualValues(t, 1, cli.ZAddXXCh(ctx, "z", &redis.Z{Score: 6, Member: "d"}).Val())
+	assert.InDelta(t, 3, cli.ZIncr(ctx, "z", &redis.Z{Score: 2, Member: "a"}).Val(), 1e-9)
+	_, err := cli.ZIncrNX(ctx, "z", &redis.Z{Score: 2, Member: "a"}).Result()
+	assert.Equal(t, redis.Nil, err)
+	assert.InDelta(t, 1, cli.ZIncrXX(ctx, "z", &redis.Z{Score: -2, Member: "a"}).Val(), 1e-9)
+	assert.InDelta(t, 2.5, cli.ZIncrBy(ctx, "z", 0.5, "b").Val(), 1e-9)
+
+	// a:1 b:2.5 c:4 d:6
+	assert.EqualValues(t, 4, cli.ZCard(ctx, "z").Val())
+	assert.EqualValues(t, 2, cli.ZCount(ctx, "z", "(1", "4").Val())
+	assert.InDelta(t, 4, cli.ZScore(ctx, "z", "c").Val(), 1e-9)
+	_, err = cli.ZScore(ctx, "z", "x").Result()
+	assert.Equal(t, redis.Nil, err)
+	assert.EqualValues(t, 1, cli.ZRank(ctx, "z", "b").Val())
+	assert.EqualValues(t, 0, cli.ZRevRank(ctx, "z", "d").Val())
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, cli.ZRange(ctx, "z", 0, -1).Val())
+	assert.Equal(t, []string{"d", "c"}, cli.ZRevRange(ctx, "z", 0, 1).Val())
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 2.5, Member: "b"}}, cli.ZRangeWithScores(ctx, "z", 0, 1).Val())
+	assert.Equal(t, []redis.Z{{Score: 6, Member: "d"}}, cli.ZRevRangeWithScores(ctx, "z", 0, 0).Val())
+	assert.Equal(t, []string{"b", "c"}, cli.ZRangeByScore(ctx, "z", &redis.ZRangeBy{Min: "2", Max: "+inf", Count: 2}).Val())
+	assert.Equal(t, []redis.Z{{Score: 4, Member: "c"}}, cli.ZRangeByScoreWithScores(ctx, "z", &redis.ZRangeBy{Min: "(2.5", Max: "(6"}).Val())
+	assert.Equal(t, []string{"c", "b"}, cli.ZRevRangeByScore(ctx, "z", &redis.ZRangeBy{Min: "-inf", Max: "4", Offset: 0, Count: 2}).Val())
+	assert.Equal(t, []redis.Z{{Score: 6, Member: "d"}, {Score: 4, Member: "c"}}, cli.ZRevRangeByScoreWithScores(ctx, "z", &redis.ZRangeBy{Min: "3", Max: "+inf"}).Val())
+
+	cli.ZAdd(ctx, "y", &redis.Z{Score: 10, Member: "a"}, &redis.Z{Score: 10, Member: "x"})
+	assert.EqualValues(t, 5, cli.ZUnionStore(ctx, "u", &redis.ZStore{Keys: []string{"z", "y"}}).Val())
+	assert.InDelta(t, 11, cli.ZScore(ctx, "u", "a").Val(), 1e-9)
+	assert.EqualValues(t, 1, cli.ZInterStore(ctx, "i", &redis.ZStore{Keys: []string{"z", "y"}, Weights: []float64{2, 1}, Aggregate: "MAX"}).Val())
+	assert.InDelta(t, 10, cli.ZScore(ctx, "i", "a").Val(), 1e-9)
+
+	assert.EqualValues(t, 2, cli.ZRem(ctx, "z", "a", "b", "x").Val())
+	assert.EqualValues(t, 1, cli.ZRemRangeByRank(ctx, "z", 0, 0).Val())
+	assert.EqualValues(t, 1, cli.ZRemRangeByScore(ctx, "z", "-inf", "+inf").Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "z").Val())
+}
+
+// TestZRem_Args checks ZRem sends the key followed by the members: it used to send an extra
+// empty member after the key.
+func TestZRem_Args(t *testing.T) {
+	cli := NewRedis()
+	cli.ZAdd(ctx, "z", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: ""})
+
+	cmd := cli.ZRem(ctx, "z", "a")
+	assert.Equal(t, []interface{}{"z", "a"}, cmd.Args())
+	assert.EqualValues(t, 1, cmd.Val())
+	assert.Equal(t, []string{""}, cli.ZRange(ctx, "z", 0, -1).Val())
+}
+
+// TestZRevRangeByScoreWithScores_Bounds checks the bounds are sent as max then min, as
+// ZREVRANGEBYSCORE expects: they used to be swapped, which selected nothing.
+func TestZRevRangeByScoreWithScores_Bounds(t *testing.T) {
+	cli := NewRedis()
+	cli.ZAdd(ctx, "z", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}, &redis.Z{Score: 3, Member: "c"})
+
+	cmd := cli.ZRevRangeByScoreWithScores(ctx, "z", &redis.ZRangeBy{Min: "2", Max: "3"})
+	assert.Equal(t, []interface{}{"z", "3", "2", "withscores"}, cmd.Args())
+	assert.Equal(t, []redis.Z{{Score: 3, Member: "c"}, {Score: 2, Member: "b"}}, cmd.Val())
+}
+
+func TestZSet_Modern(t *testing.T) {
+	cli := NewRedis()
+
+	cli.ZAdd(ctx, "z", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}, &redis.Z{Score: 3, Member: "c"})
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}}, cli.ZPopMin(ctx, "z").Val())
+	assert.Equal(t, []redis.Z{{Score: 3, Member: "c"}, {Score: 2, Member: "b"}}, cli.ZPopMax(ctx, "z", 5).Val())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "z").Val())
+
+	cli.ZAdd(ctx, "z2", &redis.Z{Score: 5, Member: "x"})
+	res, err := cli.BZPopMin(ctx, time.Second, "z", "z2").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, &redis.ZWithKey{Z: redis.Z{Score: 5, Member: "x"}, Key: "z2"}, res)
+	_, err = cli.BZPopMax(ctx, time.Second, "z", "z2").Result()
+	assert.Equal(t, redis.Nil, err)
+
+	for _, m := range []string{"a", "b", "c", "d", "e"} {
+		cli.ZAdd(ctx, "lex", &redis.Z{Member: m})
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, cli.ZRangeByLex(ctx, "lex", &redis.ZRangeBy{Min: "-", Max: "[c"}).Val())
+	assert.Equal(t, []string{"c", "d"}, cli.ZRangeByLex(ctx, "lex", &redis.ZRangeBy{Min: "(b", Max: "+", Count: 2}).Val())
+	assert.Equal(t, []string{"d", "c"}, cli.ZRevRangeByLex(ctx, "lex", &redis.ZRangeBy{Min: "[c", Max: "(e"}).Val())
+	assert.EqualValues(t, 3, cli.ZLexCount(ctx, "lex", "[b", "[d").Val())
+	assert.EqualValues(t, 2, cli.ZRemRangeByLex(ctx, "lex", "[d", "+").Val())
+	assert.Equal(t, []string{"a", "b", "c"}, cli.ZRange(ctx, "lex", 0, -1).Val())
+
+	assert.Len(t, cli.ZRandMember(ctx, "lex", 2).Val(), 2)
+	assert.Len(t, cli.ZRandMember(ctx, "lex", 5).Val(), 3)
+	assert.Len(t, cli.ZRandMember(ctx, "lex", -5).Val(), 5)
+	assert.Len(t, cli.ZRandMemberWithScores(ctx, "lex", 2).Val(), 2)
+	assert.Equal(t, []string{}, cli.ZRandMember(ctx, "missing", 2).Val())
+
+	cli.ZAdd(ctx, "s1", &redis.Z{Score: 1, Member: "a"}, &redis.Z{Score: 2, Member: "b"}, &redis.Z{Score: 3, Member: "c"})
+	cli.ZAdd(ctx, "s2", &redis.Z{Score: 10, Member: "b"}, &redis.Z{Score: 20, Member: "d"})
+	assert.Equal(t, []float64{1, 0, 3}, cli.ZMScore(ctx, "s1", "a", "missing", "c").Val())
+
+	assert.EqualValues(t, 2, cli.ZRangeStore(ctx, "dst", redis.ZRangeArgs{Key: "s1", Start: "(1", Stop: "+inf", ByScore: true}).Val())
+	assert.Equal(t, []string{"b", "c"}, cli.ZRange(ctx, "dst", 0, -1).Val())
+	assert.EqualValues(t, 1, cli.ZRangeStore(ctx, "dst", redis.ZRangeArgs{Key: "s1", Start: 0, Stop: 0, Rev: true}).Val())
+	assert.Equal(t, []string{"c"}, cli.ZRange(ctx, "dst", 0, -1).Val())
+	assert.EqualValues(t, 2, cli.ZRangeStore(ctx, "dst", redis.ZRangeArgs{Key: "lex", Start: "[c", Stop: "-", ByLex: true, Rev: true, Count: 2}).Val())
+	assert.Equal(t, []string{"b", "c"}, cli.ZRange(ctx, "dst", 0, -1).Val())
+
+	assert.Equal(t, []string{"a", "c"}, cli.ZDiff(ctx, "s1", "s2").Val())
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 3, Member: "c"}}, cli.ZDiffWithScores(ctx, "s1", "s2").Val())
+	assert.Equal(t, []string{"b"}, cli.ZInter(ctx, &redis.ZStore{Keys: []string{"s1", "s2"}}).Val())
+	assert.Equal(t, []redis.Z{{Score: 12, Member: "b"}}, cli.ZInterWithScores(ctx, &redis.ZStore{Keys: []string{"s1", "s2"}}).Val())
+	assert.Equal(t, []string{"a", "c", "b", "d"}, cli.ZUnion(ctx, &redis.ZStore{Keys: []string{"s1", "s2"}, Aggregate: "MAX"}).Val())
+	assert.Equal(t, []redis.Z{{Score: 1, Member: "a"}, {Score: 2, Member: "b"}, {Score: 3, Member: "c"}, {Score: 40, Member: "d"}},
+		cli.ZUnionWithScores(ctx, &redis.ZStore{Keys: []string{"s1", "s2"}, Weights: []float64{1, 2}, Aggregate: "MIN"}).Val())
+}
+
+func TestHyperLogLog(t *testing.T) {
+	cli := NewRedis()
+
+	assert.EqualValues(t, 1, cli.PFAdd(ctx, "h1", "a", "b", "c").Val())
+	assert.EqualValues(t, 0, cli.PFAdd(ctx, "h1", "a").Val())
+	cli.PFAdd(ctx, "h2", "c", "d")
+	assert.EqualValues(t, 3, cli.PFCount(ctx, "h1").Val())
+	assert.EqualValues(t, 4, cli.PFCount(ctx, "h1", "h2").Val())
+	assert.Equal(t, "OK", cli.PFMerge(ctx, "h3", "h1", "h2").Val())
+	assert.EqualValues(t, 4, cli.PFCount(ctx, "h3").Val())
+	assert.Equal(t, "string", cli.Type(ctx, "h3").Val())
+
+	cli.Set(ctx, "s", "v", 0)
+	assert.Error(t, cli.PFAdd(ctx, "s", "a").Err())
+}
+
+func TestWrongType(t *testing.T) {
+	cli := NewRedis()
+
+	cli.LPush(ctx, "l", "a")
+	err := cli.Get(ctx, "l").Err()
+	assert.Error(t, err)
+	assert.NotEqual(t, redis.Nil, err)
+	assert.Contains(t, err.Error(), "WRONGTYPE")
+
+	assert.Equal(t, "OK", cli.Set(ctx, "l", "v", 0).Val())
+	assert.Equal(t, "v", cli.Get(ctx, "l").Val())
+}
+
+func TestTx(t *testing.T) {
+	cli := NewRedis()
+
+	cmds, err := cli.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Incr(ctx, "n")
+		p.Incr(ctx, "n")
+		p.HGet(ctx, "h", "f")
+		return nil
+	})
+	assert.Equal(t, redis.Nil, err)
+	assert.Len(t, cmds, 3)
+	assert.EqualValues(t, 2, cmds[1].(*redis.IntCmd).Val())
+	assert.Equal(t, redis.Nil, cmds[2].Err())
+
+	// a failed command does not roll back the others, as in redis
+	_, err = cli.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, "n", "f", "v")
+		p.Incr(ctx, "n")
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "WRONGTYPE")
+	assert.Equal(t, "3", cli.Get(ctx, "n").Val())
+}
+
+func TestWatch(t *testing.T) {
+	cli := NewRedis()
+	other := redis.NewRedis(redis.WithTransport(cli.Server))
+
+	incr := func(tx *redis.Tx, interfere bool) error {
+		n, err := tx.Get(ctx, "n").Int64()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if interfere {
+			other.Set(ctx, "n", 100, 0)
+		}
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Set(ctx, "n", n+1, 0)
+			return nil
+		})
+		return err
+	}
+
+	assert.NoError(t, cli.Watch(ctx, func(tx *redis.Tx) error { return incr(tx, false) }, "n"))
+	assert.Equal(t, "1", cli.Get(ctx, "n").Val())
+
+	assert.Equal(t, redis.TxFailedErr, cli.Watch(ctx, func(tx *redis.Tx) error { return incr(tx, true) }, "n"))
+	assert.Equal(t, "100", cli.Get(ctx, "n").Val())
+
+	// an expiring key is modified too
+	cli.Set(ctx, "k", "v", time.Second)
+	err := cli.Watch(ctx, func(tx *redis.Tx) error {
+		cli.FastForward(time.Minute)
+		_, err := tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Set(ctx, "k", "w", 0)
+			return nil
+		})
+		return err
+	}, "k")
+	assert.Equal(t, redis.TxFailedErr, err)
+	assert.EqualValues(t, 0, cli.Exists(ctx, "k").Val())
+
+	// the watch is released when fn returns without running its transaction
+	assert.NoError(t, cli.Watch(ctx, func(tx *redis.Tx) error { return nil }, "n"))
+	assert.NoError(t, cli.Watch(ctx, func(tx *redis.Tx) error { return incr(tx, false) }, "n"))
+	assert.Equal(t, "101", cli.Get(ctx, "n").Val())
+}
+
+func TestTx_NotSupported(t *testing.T) {
+	for _, cli := range []*redis.Redis{redis.NewRedis(), redis.NewRedis(redis.WithTransport(faasinfra.NewHttpTransport()))} {
+		called := false
+		err := cli.Watch(ctx, func(tx *redis.Tx) error {
+			called = true
+			return nil
+		}, "n")
+		assert.Equal(t, redis.TxNotSupportedErr, err)
+		assert.False(t, called)
+
+		cmds, err := cli.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.Incr(ctx, "n")
+			return nil
+		})
+		assert.Equal(t, redis.TxNotSupportedErr, err)
+		assert.Equal(t, redis.TxNotSupportedErr, cmds[0].Err())
+	}
+}
+
+func TestScript(t *testing.T) {
+	cli := NewRedis()
+	script := redis.NewScript(`return redis.call("INCRBY", KEYS[1], ARGV[1])`)
+
+	assert.Equal(t, []bool{false}, script.Exists(ctx, cli).Val())
+	_, err := script.EvalSha(ctx, cli, []string{"n"}, 2).Result()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "NOSCRIPT")
+
+	n, err := script.Run(ctx, cli, []string{"n"}, 2).Int64()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Equal(t, []bool{true}, script.Exists(ctx, cli).Val())
+	n, err = script.Run(ctx, cli, []string{"n"}, 3).Int64()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, n)
+
+	cli.Do("script", "flush")
+	assert.Equal(t, script.Hash(), script.Load(ctx, cli).Val())
+	text, err := script.EvalSha(ctx, cli, []string{"n"}, 1).Text()
+	assert.NoError(t, err)
+	assert.Equal(t, "6", text)
+
+	// replies are converted as from Lua
+	b, err := cli.Eval(ctx, "return true", nil).Bool()
+	assert.NoError(t, err)
+	assert.True(t, b)
+	assert.Equal(t, redis.Nil, cli.Eval(ctx, "return false", nil).Err())
+	assert.Equal(t, []interface{}{int64(1), "a", int64(1)}, cli.Eval(ctx, "return {1, 'a', true}", nil).Val())
+	strs, err := cli.Eval(ctx, "return {1, 'a', true}", nil).StringSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "a", "1"}, strs)
+	assert.Equal(t, []interface{}{int64(1), int64(2)}, cli.Eval(ctx, "return {1, 2, nil, 4}", nil).Val())
+	assert.EqualValues(t, 3, cli.Eval(ctx, "return 3.7", nil).Val())
+	assert.Equal(t, "OK", cli.Eval(ctx, "return redis.status_reply('OK')", nil).Val())
+	assert.Contains(t, cli.Eval(ctx, "return redis.error_reply('boom')", nil).Err().Error(), "boom")
+
+	// and so are the replies of the commands called by scripts
+	cli.SetTime(time.Unix(1700000000, 0))
+	cli.Set(ctx, "s", "v", 0)
+	cli.PExpire(ctx, "s", time.Minute)
+	cli.ZAdd(ctx, "z", &redis.Z{Score: 1.5, Member: "m"})
+	cli.HSet(ctx, "h", "f", "v")
+	res, err := cli.Eval(ctx, `return {
+		redis.call("GET", "missing") == false,
+		redis.call("GET", KEYS[1]),
+		redis.call("PTTL", KEYS[1]),
+		redis.call("ZSCORE", KEYS[2], "m"),
+		redis.call("ZRANGE", KEYS[2], 0, -1, "WITHSCORES"),
+		redis.call("HGETALL", KEYS[3]),
+	}`, []string{"s", "z", "h"}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		int64(1), "v", int64(60000), "1.5", []interface{}{"m", "1.5"}, []interface{}{"f", "v"},
+	}, res)
+
+	// redis.call fails the script with the error of the command, redis.pcall returns it
+	assert.Contains(t, cli.Eval(ctx, `return redis.call("INCR", KEYS[1])`, []string{"s"}).Err().Error(), "not an integer")
+	caught := `local reply = redis.pcall("INCR", KEYS[1]) return reply.err`
+	assert.Contains(t, cli.Eval(ctx, caught, []string{"s"}).Val(), "not an integer")
+	assert.Contains(t, cli.Eval(ctx, "return nosuch()", nil).Err().Error(), "Error running script")
+	assert.Contains(t, cli.Eval(ctx, "return (", nil).Err().Error(), "Error compiling script")
+
+	// RegisterScript replaces a script with Go code
+	assert.Equal(t, redis.NewScript("return 1").Hash(), cli.RegisterScript("return 1", func(call Call, keys, args []string) (interface{}, error) {
+		return call("incrby", keys[0], args[0])
+	}))
+	assert.EqualValues(t, 10, cli.Eval(ctx, "return 1", []string{"m"}, 10).Val())
+
+	// a pipeline only queues EvalSha
+	cmds, err := cli.Pipelined(ctx, func(p redis.Pipeliner) error {
+		script.Run(ctx, p, []string{"n"}, 4)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, cmds[0].(*redis.Cmd).Val())
+}
+
+func TestScan(t *testing.T) {
+	cli := NewRedis()
+	for i := 0; i < 25; i++ {
+		cli.Set(ctx, fmt.Sprintf("user:%02d", i), i, 0)
+	}
+	cli.HSet(ctx, "user:hash", "f", "v")
+	cli.Set(ctx, "other", "v", 0)
+
+	keys, cursor, err := cli.Scan(ctx, 0, "", 10).Result()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 10)
+	assert.NotZero(t, cursor)
+
+	var all []string
+	iter := cli.Scan(ctx, 0, "user:*", 7).Iterator()
+	for iter.Next(ctx) {
+		all = append(all, iter.Val())
+	}
+	assert.NoError(t, iter.Err())
+	assert.Len(t, all, 26)
+	assert.NotContains(t, all, "other")
+
+	keys, _, err = cli.ScanType(ctx, 0, "", 100, "hash").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user:hash"}, keys)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	defer cancel()
+	iter = cli.Scan(cancelled, 0, "", 5).Iterator()
+	var n int
+	for iter.Next(cancelled) {
+		if n++; n == 5 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 5, n)
+	assert.Equal(t, context.Canceled, iter.Err())
+}
+
+func TestScan_Collections(t *testing.T) {
+	cli := NewRedis()
+	cli.HMSet(ctx, "h", "a", "1", "b", "2", "c", "3")
+	cli.SAdd(ctx, "s", "x", "y", "z")
+	cli.ZAdd(ctx, "z", &redis.Z{Score: 1, Member: "m1"}, &redis.Z{Score: 2.5, Member: "m2"})
+
+	fields, cursor, err := cli.HScan(ctx, "h", 0, "[ab]", 0).Result()
+	assert.NoError(t, err)
+	assert.Zero(t, cursor)
+	assert.Equal(t, []string{"a", "1", "b", "2"}, fields)
+
+	var members []string
+	iter := cli.SScan(ctx, "s", 0, "", 1).Iterator()
+	for iter.Next(ctx) {
+		members = append(members, iter.Val())
+	}
+	assert.Equal(t, []string{"x", "y", "z"}, members)
+
+	elems, _, err := cli.ZScan(ctx, "z", 0, "", 0).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "1", "m2", "2.5"}, elems)
+
+	elems, cursor, err = cli.SScan(ctx, "missing", 0, "", 0).Result()
+	assert.NoError(t, err)
+	assert.Zero(t, cursor)
+	assert.Empty(t, elems)
+	assert.Error(t, cli.HScan(ctx, "s", 0, "", 0).Err())
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"*", "", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"*:*:end", "a:b:c:end", true},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.match, matchGlob(c.pattern, c.s), "%s %s", c.pattern, c.s)
+	}
+}
+
+func TestStream(t *testing.T) {
+	cli := NewRedis()
+	cli.SetTime(time.Unix(1700000000, 0))
+
+	id, err := cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", Values: map[string]interface{}{"n": 1}}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "1700000000000-0", id)
+	assert.Equal(t, "1700000000000-1", cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", Values: []string{"n", "2"}}).Val())
+	assert.Equal(t, "1700000000001-0", cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", ID: "1700000000001-0", Values: []interface{}{"n", 3}}).Val())
+	assert.Error(t, cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", ID: "1-0", Values: []string{"n", "0"}}).Err())
+	assert.Equal(t, redis.Nil, cli.XAdd(ctx, &redis.XAddArgs{Stream: "missing", NoMkStream: true, Values: []string{"n", "0"}}).Err())
+	assert.EqualValues(t, 3, cli.XLen(ctx, "s").Val())
+	assert.Equal(t, "stream", cli.Type(ctx, "s").Val())
+
+	msgs, err := cli.XRange(ctx, "s", "-", "+").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.XMessage{
+		{ID: "1700000000000-0", Values: map[string]interface{}{"n": "1"}},
+		{ID: "1700000000000-1", Values: map[string]interface{}{"n": "2"}},
+		{ID: "1700000000001-0", Values: map[string]interface{}{"n": "3"}},
+	}, msgs)
+	assert.Len(t, cli.XRange(ctx, "s", "1700000000000", "1700000000000").Val(), 2)
+	assert.Len(t, cli.XRange(ctx, "s", "(1700000000000-0", "+").Val(), 2)
+	msgs = cli.XRevRangeN(ctx, "s", "+", "-", 1).Val()
+	assert.Equal(t, "1700000000001-0", msgs[0].ID)
+	assert.Empty(t, cli.XRange(ctx, "missing", "-", "+").Val())
+
+	streams, err := cli.XRead(ctx, &redis.XReadArgs{Streams: []string{"s", "1700000000000-0"}, Count: 1}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.XStream{{Stream: "s", Messages: []redis.XMessage{{ID: "1700000000000-1", Values: map[string]interface{}{"n": "2"}}}}}, streams)
+	assert.Equal(t, redis.Nil, cli.XReadStreams(ctx, "s", "$").Err())
+	assert.Equal(t, redis.Nil, cli.XRead(ctx, &redis.XReadArgs{Streams: []string{"s", "$"}, Block: time.Second}).Err())
+
+	assert.EqualValues(t, 1, cli.XDel(ctx, "s", "1700000000000-1", "9-9").Val())
+	assert.EqualValues(t, 1, cli.XTrimMaxLen(ctx, "s", 1).Val())
+	assert.Equal(t, "1700000000001-0", cli.XRange(ctx, "s", "-", "+").Val()[0].ID)
+	cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", MaxLen: 1, Approx: true, Values: []string{"n", "4"}})
+	assert.EqualValues(t, 1, cli.XLen(ctx, "s").Val())
+	assert.EqualValues(t, 1, cli.XTrimMinID(ctx, "s", "9999999999999").Val())
+	assert.EqualValues(t, 0, cli.XLen(ctx, "s").Val())
+	assert.EqualValues(t, 1, cli.Exists(ctx, "s").Val())
+}
+
+func TestStream_Group(t *testing.T) {
+	cli := NewRedis()
+	cli.SetTime(time.Unix(1700000000, 0))
+
+	assert.Error(t, cli.XGroupCreate(ctx, "s", "g", "$").Err())
+	assert.Equal(t, "OK", cli.XGroupCreateMkStream(ctx, "s", "g", "$").Val())
+	assert.Contains(t, cli.XGroupCreate(ctx, "s", "g", "$").Err().Error(), "BUSYGROUP")
+	for _, id := range []string{"1-0", "2-0", "3-0"} {
+		cli.XAdd(ctx, &redis.XAddArgs{Stream: "s", ID: id, Values: []string{"id", id}})
+	}
+
+	read := func(consumer, id string, count int64) ([]redis.XStream, error) {
+		return cli.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "g", Consumer: consumer, Streams: []string{"s", id}, Count: count}).Result()
+	}
+	streams, err := read("alice", ">", 2)
+	assert.NoError(t, err)
+	assert.Len(t, streams[0].Messages, 2)
+	streams, err = read("bob", ">", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "3-0", streams[0].Messages[0].ID)
+	_, err = read("bob", ">", 0)
+	assert.Equal(t, redis.Nil, err)
+
+	pending, err := cli.XPending(ctx, "s", "g").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, &redis.XPending{Count: 3, Lower: "1-0", Higher: "3-0", Consumers: map[string]int64{"alice": 2, "bob": 1}}, pending)
+
+	assert.EqualValues(t, 1, cli.XAck(ctx, "s", "g", "1-0", "9-0").Val())
+	// the history of alice holds the messages she did not acknowledge
+	streams, err = read("alice", "0", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.XMessage{{ID: "2-0", Values: map[string]interface{}{"id": "2-0"}}}, streams[0].Messages)
+
+	cli.FastForward(time.Minute)
+	exts, err := cli.XPendingExt(ctx, &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "-", End: "+", Count: 10}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.XPendingExt{
+		{ID: "2-0", Consumer: "alice", Idle: time.Minute, RetryCount: 2},
+		{ID: "3-0", Consumer: "bob", Idle: time.Minute, RetryCount: 1},
+	}, exts)
+
+	// bob takes over the message alice did not process in time
+	assert.Empty(t, cli.XClaim(ctx, &redis.XClaimArgs{Stream: "s", Group: "g", Consumer: "bob", MinIdle: time.Hour, Messages: []string{"2-0"}}).Val())
+	msgs, err := cli.XClaim(ctx, &redis.XClaimArgs{Stream: "s", Group: "g", Consumer: "bob", MinIdle: time.Second, Messages: []string{"2-0"}}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "2-0", msgs[0].ID)
+	exts = cli.XPendingExt(ctx, &redis.XPendingExtArgs{Stream: "s", Group: "g", Start: "-", End: "+", Count: 10, Consumer: "bob"}).Val()
+	assert.Len(t, exts, 2)
+	assert.Equal(t, int64(3), exts[0].RetryCount)
+	assert.Equal(t, []string{"3-0"}, cli.XClaimJustID(ctx, &redis.XClaimArgs{Stream: "s", Group: "g", Consumer: "alice", Messages: []string{"3-0"}}).Val())
+
+	assert.EqualValues(t, 1, cli.XGroupDelConsumer(ctx, "s", "g", "alice").Val())
+	assert.EqualValues(t, 1, cli.XPending(ctx, "s", "g").Val().Count)
+	assert.Equal(t, "OK", cli.XGroupSetID(ctx, "s", "g", "0").Val())
+	streams, err = cli.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "g", Consumer: "carol", Streams: []string{"s", ">"}, NoAck: true}).Result()
+	assert.NoError(t, err)
+	assert.Len(t, streams[0].Messages, 3)
+	assert.EqualValues(t, 1, cli.XPending(ctx, "s", "g").Val().Count)
+
+	assert.EqualValues(t, 1, cli.XGroupDestroy(ctx, "s", "g").Val())
+	_, err = read("alice", ">", 0)
+	assert.Contains(t, err.Error(), "NOGROUP")
+}
+
+func TestGeo(t *testing.T) {
+	cli := NewRedis()
+	n, err := cli.GeoAdd(ctx, "sicily",
+		&redis.GeoLocation{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		&redis.GeoLocation{Name: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+	).Result()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.Error(t, cli.GeoAdd(ctx, "sicily", &redis.GeoLocation{Name: "x", Longitude: 200}).Err())
+
+	// the values redis replies with for the same commands
+	dist, err := cli.GeoDist(ctx, "sicily", "Palermo", "Catania", "").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, 166.2742, dist, 0.0001)
+	assert.Equal(t, redis.Nil, cli.GeoDist(ctx, "sicily", "Palermo", "Rome", "m").Err())
+	assert.Equal(t, []string{"sqc8b49rny0", "sqdtr74hyu0", ""}, cli.GeoHash(ctx, "sicily", "Palermo", "Catania", "Rome").Val())
+
+	pos, err := cli.GeoPos(ctx, "sicily", "Palermo", "Rome").Result()
+	assert.NoError(t, err)
+	assert.InDelta(t, 13.361389, pos[0].Longitude, 0.00001)
+	assert.InDelta(t, 38.115556, pos[0].Latitude, 0.00001)
+	assert.Nil(t, pos[1])
+
+	locs, err := cli.GeoRadius(ctx, "sicily", 15, 37, &redis.GeoRadiusQuery{Radius: 200, Unit: "km", WithDist: true, Sort: "ASC"}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.GeoLocation{{Name: "Catania", Dist: 56.4413}, {Name: "Palermo", Dist: 190.4424}}, locs)
+	locs, err = cli.GeoRadius(ctx, "sicily", 15, 37, &redis.GeoRadiusQuery{Radius: 100, WithCoord: true}).Result()
+	assert.NoError(t, err)
+	assert.Len(t, locs, 1)
+	assert.InDelta(t, 15.087269, locs[0].Longitude, 0.00001)
+	assert.Error(t, cli.GeoRadius(ctx, "sicily", 15, 37, &redis.GeoRadiusQuery{Radius: 100, Store: "dest"}).Err())
+
+	locs, err = cli.GeoRadiusByMember(ctx, "sicily", "Palermo", &redis.GeoRadiusQuery{Radius: 200, Sort: "DESC", Count: 1}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.GeoLocation{{Name: "Catania"}}, locs)
+	n, err = cli.GeoRadiusStore(ctx, "sicily", 15, 37, &redis.GeoRadiusQuery{Radius: 200, StoreDist: "dists"}).Result()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	assert.InDelta(t, 56.4413, cli.ZScore(ctx, "dists", "Catania").Val(), 0.0001)
+	assert.Error(t, cli.GeoRadiusStore(ctx, "sicily", 15, 37, &redis.GeoRadiusQuery{Radius: 200}).Err())
+	assert.Error(t, cli.GeoRadiusByMember(ctx, "sicily", "Palermo", &redis.GeoRadiusQuery{Radius: 200, Store: "dest"}).Err())
+	assert.Error(t, cli.GeoRadiusByMemberStore(ctx, "sicily", "Palermo", &redis.GeoRadiusQuery{Radius: 200}).Err())
+	assert.EqualValues(t, 0, cli.Exists(ctx, "dest").Val())
+
+	names, err := cli.GeoSearch(ctx, "sicily", &redis.GeoSearchQuery{Longitude: 15, Latitude: 37, BoxWidth: 400, BoxHeight: 400, Sort: "ASC"}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Catania", "Palermo"}, names)
+	names, err = cli.GeoSearch(ctx, "sicily", &redis.GeoSearchQuery{Longitude: 15, Latitude: 37, BoxWidth: 100, BoxHeight: 400}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Catania"}, names)
+	locs, err = cli.GeoSearchLocation(ctx, "sicily", &redis.GeoSearchLocationQuery{
+		GeoSearchQuery: redis.GeoSearchQuery{Member: "Catania", Radius: 1, RadiusUnit: "m"},
+		WithHash:       true,
+	}).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.GeoLocation{{Name: "Catania", GeoHash: 3479447370796909}}, locs)
+
+	n, err = cli.GeoSearchStore(ctx, "sicily", "near", &redis.GeoSearchStoreQuery{GeoSearchQuery: redis.GeoSearchQuery{Member: "Palermo", Radius: 10}}).Result()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.Equal(t, []string{"Palermo"}, cli.ZRange(ctx, "near", 0, -1).Val())
+	assert.Empty(t, cli.GeoSearch(ctx, "missing", &redis.GeoSearchQuery{Member: "x", Radius: 10}).Val())
+}
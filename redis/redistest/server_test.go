@@ -0,0 +1,39 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redistest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+func TestServer_DoRequestRedis(t *testing.T) {
+	s := NewServer()
+	s.SetTime(time.Unix(1700000000, 0))
+
+	body, _, err := s.DoRequestRedis(context.Background(), map[string]interface{}{"cmd": "set", "args": []interface{}{"k", "v", "ex", 10}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code": "`+cExceptions.SCSuccess+`", "msg": "", "data": "OK"}`, string(body))
+
+	body, _, err = s.DoRequestRedis(context.Background(), map[string]interface{}{"cmd": "lpush", "args": []interface{}{"k", "a"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"code": "`+ErrCodeCommand+`", "msg": "`+string(errWrongType)+`", "data": null}`, string(body))
+
+	reply, err := s.Do("ttl", "k")
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, reply)
+
+	s.FastForward(10 * time.Second)
+	reply, err = s.Do("get", "k")
+	assert.NoError(t, err)
+	assert.Nil(t, reply)
+
+	_, err = s.Do("nosuchcommand")
+	assert.Error(t, err)
+}
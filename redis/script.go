@@ -0,0 +1,82 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// Scripter is the part of IRedis a Script runs on.
+type Scripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *Cmd
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *Cmd
+	ScriptExists(ctx context.Context, hashes ...string) *BoolSliceCmd
+	ScriptLoad(ctx context.Context, script string) *StringCmd
+}
+
+var _ Scripter = (*Redis)(nil)
+
+// Script is a Lua script sent by its SHA1 digest, the source being sent only when the
+// server does not know it yet.
+//
+//	var incrBy = redis.NewScript(`return redis.call("INCRBY", KEYS[1], ARGV[1])`)
+//
+//	n, err := incrBy.Run(ctx, cli, []string{"counter"}, 2).Int64()
+type Script struct {
+	src, hash string
+}
+
+func NewScript(src string) *Script {
+	h := sha1.New()
+	_, _ = io.WriteString(h, src)
+	return &Script{
+		src:  src,
+		hash: hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+// Src returns the source of the script.
+func (s *Script) Src() string {
+	return s.src
+}
+
+// Hash returns the SHA1 digest of the script source.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+func (s *Script) Load(ctx context.Context, c Scripter) *StringCmd {
+	return c.ScriptLoad(ctx, s.src)
+}
+
+func (s *Script) Exists(ctx context.Context, c Scripter) *BoolSliceCmd {
+	return c.ScriptExists(ctx, s.hash)
+}
+
+func (s *Script) Eval(ctx context.Context, c Scripter, keys []string, args ...interface{}) *Cmd {
+	return c.Eval(ctx, s.src, keys, args...)
+}
+
+func (s *Script) EvalSha(ctx context.Context, c Scripter, keys []string, args ...interface{}) *Cmd {
+	return c.EvalSha(ctx, s.hash, keys, args...)
+}
+
+// Run runs the script with EvalSha, and with Eval when the server replies NOSCRIPT.
+// In a pipeline the reply is not known yet, so that only EvalSha is queued: load the
+// script first.
+func (s *Script) Run(ctx context.Context, c Scripter, keys []string, args ...interface{}) *Cmd {
+	cmd := s.EvalSha(ctx, c, keys, args...)
+	if isNoScriptErr(cmd.Err()) {
+		return s.Eval(ctx, c, keys, args...)
+	}
+	return cmd
+}
+
+func isNoScriptErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "NOSCRIPT")
+}
@@ -3,6 +3,8 @@
 
 package redis
 
+import "time"
+
 type Z struct {
 	Score  float64
 	Member interface{}
@@ -10,6 +12,12 @@ type Z struct {
 
 type ZSlice []Z
 
+// ZWithKey is a member popped by BZPopMin and BZPopMax, with the key it was popped from.
+type ZWithKey struct {
+	Z
+	Key string
+}
+
 type ZStore struct {
 	Keys    []string
 	Weights []float64
@@ -22,6 +30,172 @@ type ZRangeBy struct {
 	Offset, Count int64
 }
 
+// ZRangeArgs is a ZRANGESTORE query. Start and Stop are ranks by default, scores such as
+// "(1" or "+inf" with ByScore, and lex bounds such as "[a" or "-" with ByLex. With Rev,
+// Start is the upper bound and the members are taken in reverse order.
+type ZRangeArgs struct {
+	Key   string
+	Start interface{}
+	Stop  interface{}
+
+	ByScore bool
+	ByLex   bool
+	Rev     bool
+
+	// Offset and Count limit the members of a ByScore or ByLex range.
+	Offset, Count int64
+}
+
+type LPosArgs struct {
+	// Rank is the 1-based rank of the match to return, counting from the tail when negative.
+	Rank int64
+	// MaxLen bounds the number of elements compared, 0 comparing all of them.
+	MaxLen int64
+}
+
 type BitCountArgs struct {
 	Start, End int64
 }
+
+type XAddArgs struct {
+	Stream     string
+	NoMkStream bool
+	// MaxLen and MinID trim the stream, approximately when Approx is set, at most Limit
+	// entries being evicted when Limit > 0.
+	MaxLen int64
+	MinID  string
+	Approx bool
+	Limit  int64
+	// ID defaults to "*", letting redis generate the ID.
+	ID string
+	// Values can be a map[string]interface{}, or a []string or []interface{} of fields
+	// followed by their values.
+	Values interface{}
+}
+
+type XMessage struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+type XStream struct {
+	Stream   string
+	Messages []XMessage
+}
+
+// XReadArgs lists the streams to read followed by their IDs, e.g. {"s1", "s2", "0", "$"}.
+// Block > 0 waits up to Block for new messages when there are none; reads do not block by default.
+type XReadArgs struct {
+	Streams []string
+	Count   int64
+	Block   time.Duration
+}
+
+type XReadGroupArgs struct {
+	Group    string
+	Consumer string
+	// Streams lists the streams to read followed by their IDs, ">" reading the messages
+	// never delivered to the group.
+	Streams []string
+	Count   int64
+	Block   time.Duration
+	NoAck   bool
+}
+
+type XPending struct {
+	Count     int64
+	Lower     string
+	Higher    string
+	Consumers map[string]int64
+}
+
+type XPendingExt struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+type XPendingExtArgs struct {
+	Stream string
+	Group  string
+	// Idle only returns the messages idle for at least Idle.
+	Idle     time.Duration
+	Start    string
+	End      string
+	Count    int64
+	Consumer string
+}
+
+type XClaimArgs struct {
+	Stream   string
+	Group    string
+	Consumer string
+	MinIdle  time.Duration
+	Messages []string
+}
+
+// GeoLocation is a member of a geospatial index. Dist and GeoHash are only set by the
+// queries asking for them.
+type GeoLocation struct {
+	Name                      string
+	Longitude, Latitude, Dist float64
+	GeoHash                   int64
+}
+
+type GeoPos struct {
+	Longitude, Latitude float64
+}
+
+// GeoRadiusQuery is a query of GeoRadius. Unit can be m, km, mi or ft, and defaults to km.
+type GeoRadiusQuery struct {
+	Radius float64
+	Unit   string
+	// WithCoord, WithDist and WithGeoHash ask for the fields of GeoLocation that are not set by default.
+	WithCoord   bool
+	WithDist    bool
+	WithGeoHash bool
+	Count       int
+	// Can be ASC or DESC, the order of the distances to the center.
+	Sort string
+	// Store and StoreDist are the keys GeoRadiusStore stores the members or their distances to.
+	Store     string
+	StoreDist string
+}
+
+// GeoSearchQuery is a query of GeoSearch, from Member or from Longitude and Latitude, within
+// Radius or within a BoxWidth x BoxHeight box.
+type GeoSearchQuery struct {
+	Member string
+
+	Longitude float64
+	Latitude  float64
+
+	Radius     float64
+	RadiusUnit string
+
+	BoxWidth  float64
+	BoxHeight float64
+	BoxUnit   string
+
+	// Can be ASC or DESC, the order of the distances to the center.
+	Sort  string
+	Count int
+	// CountAny returns the first Count members found rather than the Count nearest ones.
+	CountAny bool
+}
+
+type GeoSearchLocationQuery struct {
+	GeoSearchQuery
+
+	WithCoord bool
+	WithDist  bool
+	WithHash  bool
+}
+
+type GeoSearchStoreQuery struct {
+	GeoSearchQuery
+
+	// StoreDist stores the distances to the center as scores, rather than the positions.
+	StoreDist bool
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 ByteDance Ltd. and/or its affiliates
+// SPDX-License-Identifier: MIT
+
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+const (
+	// TxFailedErr is returned by the Exec of a transaction aborted because a watched key changed.
+	TxFailedErr = ErrorRedis("redis: transaction failed")
+	// TxNotSupportedErr is returned by the transactions of a client sending its commands over
+	// HttpTransport, the FaaS infra redis API not supporting them yet.
+	TxNotSupportedErr = ErrorRedis("redis: transactions not supported by the FaaS infra redis API")
+)
+
+// Tx runs the commands of a Watch. Its commands are sent right away, except the ones queued
+// by TxPipelined, which run in a MULTI/EXEC block that fails with TxFailedErr when one of the
+// watched keys was modified since Watch.
+//
+// The transactions rely on requests the FaaS infra redis API does not support yet, so they
+// fail with TxNotSupportedErr over HttpTransport, and are not part of IRedis. Only redistest
+// implements them:
+//
+//   - {"cmd": "watch", "args": [key, ...]} watches the keys and replies with a token.
+//   - {"cmd": "exec", "args": [token, cmd, ...]} runs the commands in a MULTI/EXEC block,
+//     see execCmd, and replies with null when a key watched under token was modified.
+//   - {"cmd": "unwatch", "args": [token]} releases a watch whose transaction was not run.
+type Tx struct {
+	*Redis
+	token string
+}
+
+// TxPipeline returns a Pipeliner whose Exec runs the queued commands in a MULTI/EXEC block:
+// no command of another client runs in between.
+func (c *Redis) TxPipeline() Pipeliner {
+	return c.newPipeline(func(ctx context.Context, cmds []Cmder) error {
+		if err := c.txSupported(); err != nil {
+			for _, cmd := range cmds {
+				cmd.base().err = err
+			}
+			return err
+		}
+		if err := abortTx(cmds); err != nil {
+			return err
+		}
+		return c.execTx(ctx, "", cmds)
+	})
+}
+
+// TxPipelined queues the commands issued by fn and runs them in a MULTI/EXEC block.
+func (c *Redis) TxPipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return pipelined(ctx, c.TxPipeline(), fn)
+}
+
+// Watch watches keys and runs fn, the transaction of fn failing with TxFailedErr when one of
+// keys is modified before it runs. The error of fn is returned, so that callers can retry:
+//
+//	err := cli.Watch(ctx, func(tx *redis.Tx) error {
+//		n, err := tx.Get(ctx, key).Int64()
+//		if err != nil && err != redis.Nil {
+//			return err
+//		}
+//		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+//			p.Set(ctx, key, n+1, 0)
+//			return nil
+//		})
+//		return err
+//	}, key)
+func (c *Redis) Watch(ctx context.Context, fn func(*Tx) error, keys ...string) error {
+	if err := c.txSupported(); err != nil {
+		return err
+	}
+	tx := &Tx{Redis: c}
+	if len(keys) > 0 {
+		args := make([]interface{}, len(keys))
+		for i, key := range keys {
+			args[i] = key
+		}
+		watch := NewStringCmd(c, "watch", args...)
+		watch.request(ctx)
+		if watch.err != nil {
+			return watch.err
+		}
+		tx.token = watch.val
+	}
+
+	err := fn(tx)
+	if tx.token != "" {
+		// fn returned before running its transaction, release the watch.
+		NewStatusCmd(c, "unwatch", tx.token).request(ctx)
+	}
+	return err
+}
+
+// TxPipeline returns a Pipeliner whose Exec runs the queued commands in a MULTI/EXEC block
+// guarded by the watched keys. The keys are unwatched after the first Exec.
+func (tx *Tx) TxPipeline() Pipeliner {
+	return tx.Redis.newPipeline(func(ctx context.Context, cmds []Cmder) error {
+		if err := abortTx(cmds); err != nil {
+			// the keys stay watched until Watch returns
+			return err
+		}
+		token := tx.token
+		tx.token = ""
+		return tx.Redis.execTx(ctx, token, cmds)
+	})
+}
+
+// TxPipelined queues the commands issued by fn and runs them in a MULTI/EXEC block guarded
+// by the watched keys.
+func (tx *Tx) TxPipelined(ctx context.Context, fn func(Pipeliner) error) ([]Cmder, error) {
+	return pipelined(ctx, tx.TxPipeline(), fn)
+}
+
+// txSupported returns TxNotSupportedErr when c sends its commands over HttpTransport.
+func (c *Redis) txSupported() error {
+	if _, ok := c.getTransport().(*faasinfra.HttpTransport); ok {
+		return TxNotSupportedErr
+	}
+	return nil
+}
+
+// abortTx fails all cmds with the error of the first one failed before being sent, as redis
+// discards a transaction with a command it could not queue, and returns it. It returns nil
+// when all cmds can be sent.
+func abortTx(cmds []Cmder) error {
+	err := firstCmdErr(cmds)
+	if err == nil {
+		return nil
+	}
+	for _, cmd := range cmds {
+		if cmd.Err() == nil {
+			cmd.base().err = err
+		}
+	}
+	return err
+}
+
+// execTx sends cmds in one exec request, see newExecCmd, and fills them with its replies.
+func (c *Redis) execTx(ctx context.Context, token string, cmds []Cmder) error {
+	exec := newExecCmd(c, token, cmds)
+	exec.request(ctx)
+
+	err := exec.err
+	if err == nil && len(exec.replies) != len(cmds) {
+		err = cExceptions.InternalError("[Redis] exec got %d replies for %d commands", len(exec.replies), len(cmds))
+	}
+	if err == Nil {
+		err = TxFailedErr
+	}
+	for i, cmd := range cmds {
+		if err != nil {
+			cmd.base().err = err
+		} else {
+			cmd.base().readReply(exec.replies[i], nil, nil)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return firstCmdErr(cmds)
+}
+
+// execCmd is the request running a MULTI/EXEC block:
+//
+//	{"cmd": "exec", "args": [token, {"cmd": "incr", "args": ["n"]}, ...]}
+//
+// token is the one returned by the watch command, or "" when no key is watched. The data of
+// the response is null when the transaction was aborted, otherwise the list of the responses
+// of the commands, each with its own code, msg and data.
+type execCmd struct {
+	baseCmd
+	replies []json.RawMessage
+}
+
+func newExecCmd(client *Redis, token string, cmds []Cmder) *execCmd {
+	args := make([]interface{}, 0, len(cmds)+1)
+	args = append(args, token)
+	for _, cmd := range cmds {
+		args = append(args, cmd.base().argumentList())
+	}
+	cmd := &execCmd{
+		baseCmd: baseCmd{
+			client: client,
+			name:   "exec",
+			args:   args,
+			result: &result{},
+		},
+	}
+	cmd.result.bind(&cmd.replies)
+	return cmd
+}
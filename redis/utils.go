@@ -56,27 +56,3 @@ func appendArg(destination []interface{}, argument interface{}) []interface{} {
 		return append(destination, curArg)
 	}
 }
-
-func tranIntCmd2BoolCmd(intCmd *IntCmd) *BoolCmd {
-	boolCmd := NewBoolCmd(intCmd.client, "expire", intCmd.args...)
-	boolCmd.result = intCmd.result
-	boolCmd.err = intCmd.err
-	if intCmd.val == int64(0) {
-		boolCmd.val = false
-	} else {
-		boolCmd.val = true
-	}
-	return boolCmd
-}
-
-func tranStatusCmd2BoolCmd(intCmd *StatusCmd) *BoolCmd {
-	boolCmd := NewBoolCmd(intCmd.client, "expire", intCmd.args...)
-	boolCmd.result = intCmd.result
-	boolCmd.err = intCmd.err
-	if intCmd.val == "" {
-		boolCmd.val = false
-	} else {
-		boolCmd.val = true
-	}
-	return boolCmd
-}
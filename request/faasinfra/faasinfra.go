@@ -28,7 +28,9 @@ import (
 	cUtils "github.com/byted-apaas/server-common-go/utils"
 )
 
-type requestFaaSInfra struct{}
+type requestFaaSInfra struct {
+	transport TaskTransport
+}
 
 var (
 	reqFaaSInfra     request.IRequestFaaSInfra
@@ -44,6 +46,12 @@ func GetInstance() request.IRequestFaaSInfra {
 	return reqFaaSInfra
 }
 
+// NewRequestFaaSInfra returns a request.IRequestFaaSInfra sending its requests through transport,
+// or over HTTP when it is nil.
+func NewRequestFaaSInfra(transport TaskTransport) request.IRequestFaaSInfra {
+	return &requestFaaSInfra{transport: transport}
+}
+
 func (r *requestFaaSInfra) InvokeFunctionDistributed(ctx context.Context, appCtx *structs.AppCtx, dataset interface{}, handlerFunc string, progressCallbackFunc string, completedCallbackFunc string, options *tasks.Options) (int64, error) {
 	v := reflect.ValueOf(dataset)
 	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
@@ -86,7 +94,7 @@ func (r *requestFaaSInfra) InvokeFunctionDistributed(ctx context.Context, appCtx
 		"x-kunlun-loop-masks":   lookMask,
 	}
 
-	data, err := cUtils.ErrorWrapper(getFaaSInfraClient().PostJson(utils.SetAppConfToCtx(ctx, appCtx), GetPathInvokeFunctionDistributed(namespace), headers, body, cHttp.AppTokenMiddleware))
+	data, err := getTaskTransport(r.transport).DoRequestDistributedTask(utils.SetAppConfToCtx(ctx, appCtx), namespace, headers, body)
 	if err != nil {
 		return 0, err
 	}
@@ -107,6 +115,10 @@ func DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]
 	return data, extra, err
 }
 
+func doRequestDistributedTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	return cUtils.ErrorWrapper(getFaaSInfraClient().PostJson(ctx, GetPathInvokeFunctionDistributed(namespace), headers, body, cHttp.AppTokenMiddleware))
+}
+
 func DoRequestFile(ctx context.Context, contentType string, body *bytes.Buffer) ([]byte, error) {
 	return cUtils.ErrorWrapper(getFaaSInfraClient().PostFormData(ctx, GetFaaSInfraPathFile(), map[string][]string{
 		cConstants.HttpHeaderKeyContentType: {contentType},
@@ -123,8 +135,8 @@ func doRequestMongodb(ctx context.Context, param interface{}) ([]byte, error) {
 	return base64.StdEncoding.DecodeString(string(data))
 }
 
-func BatchCreate(ctx context.Context, param interface{}) ([]primitive.ObjectID, error) {
-	data, err := doRequestMongodb(ctx, param)
+func BatchCreate(ctx context.Context, transport MongodbTransport, param interface{}) ([]primitive.ObjectID, error) {
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +150,8 @@ func BatchCreate(ctx context.Context, param interface{}) ([]primitive.ObjectID,
 	return result.IDs, nil
 }
 
-func Create(ctx context.Context, param interface{}) (*structs.RecordOnlyId, error) {
-	ids, err := BatchCreate(ctx, param)
+func Create(ctx context.Context, transport MongodbTransport, param interface{}) (*structs.RecordOnlyId, error) {
+	ids, err := BatchCreate(ctx, transport, param)
 	if err != nil {
 		return nil, err
 	}
@@ -151,13 +163,13 @@ func Create(ctx context.Context, param interface{}) (*structs.RecordOnlyId, erro
 	return nil, nil
 }
 
-func Find(ctx context.Context, param, results interface{}) error {
+func Find(ctx context.Context, transport MongodbTransport, param, results interface{}) error {
 	resultsVal := reflect.ValueOf(results)
 	if resultsVal.Kind() != reflect.Ptr {
 		return fmt.Errorf("[Find] results argument must be a pointer to a slice, but was a %s", resultsVal.Kind())
 	}
 
-	data, err := doRequestMongodb(ctx, param)
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return err
 	}
@@ -173,14 +185,14 @@ func Find(ctx context.Context, param, results interface{}) error {
 	return err
 }
 
-func FindOne(ctx context.Context, param, result interface{}) error {
+func FindOne(ctx context.Context, transport MongodbTransport, param, result interface{}) error {
 
 	resultsVal := reflect.ValueOf(result)
 	if resultsVal.Kind() != reflect.Ptr {
 		return fmt.Errorf("[FindOne] results argument must be a pointer to a slice, but was a %s", resultsVal.Kind())
 	}
 
-	data, err := doRequestMongodb(ctx, param)
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return err
 	}
@@ -195,8 +207,8 @@ func FindOne(ctx context.Context, param, result interface{}) error {
 	return nil
 }
 
-func Count(ctx context.Context, param interface{}) (int64, error) {
-	data, err := doRequestMongodb(ctx, param)
+func Count(ctx context.Context, transport MongodbTransport, param interface{}) (int64, error) {
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return 0, err
 	}
@@ -211,13 +223,13 @@ func Count(ctx context.Context, param interface{}) (int64, error) {
 	return result.Data.Count, nil
 }
 
-func Distinct(ctx context.Context, param interface{}, results interface{}) error {
+func Distinct(ctx context.Context, transport MongodbTransport, param interface{}, results interface{}) error {
 	resultsVal := reflect.ValueOf(results)
 	if resultsVal.Kind() != reflect.Ptr {
 		return fmt.Errorf("[Distinct] results argument must be a pointer to a slice, but was a %s", resultsVal.Kind())
 	}
 
-	data, err := doRequestMongodb(ctx, param)
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return err
 	}
@@ -225,7 +237,7 @@ func Distinct(ctx context.Context, param interface{}, results interface{}) error
 	res := &inner.RawResult{}
 	res.Bind(results)
 
-	err = bson.Unmarshal(data, &res)
+	err = bson.Unmarshal(data, res)
 	if err != nil {
 		return cExceptions.InternalError("[Distinct] Unmarshal failed, err: %v", err)
 	}
@@ -233,8 +245,8 @@ func Distinct(ctx context.Context, param interface{}, results interface{}) error
 	return nil
 }
 
-func Update(ctx context.Context, param interface{}) error {
-	_, err := doRequestMongodb(ctx, param)
+func Update(ctx context.Context, transport MongodbTransport, param interface{}) error {
+	_, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return err
 	}
@@ -242,8 +254,25 @@ func Update(ctx context.Context, param interface{}) error {
 	return nil
 }
 
-func Delete(ctx context.Context, param interface{}) error {
-	_, err := doRequestMongodb(ctx, param)
+// UpdateWithResult is Update returning the numbers of records matched and modified, and the
+// _id of the record an upsert inserted.
+func UpdateWithResult(ctx context.Context, transport MongodbTransport, param interface{}) (*structs.UpdateResult, error) {
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	var result inner.UpdateResult
+	err = bson.Unmarshal(data, &result)
+	if err != nil {
+		return nil, cExceptions.InternalError("[UpdateWithResult] Unmarshal failed, err: %v", err)
+	}
+
+	return &result.Data, nil
+}
+
+func Delete(ctx context.Context, transport MongodbTransport, param interface{}) error {
+	_, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
 	if err != nil {
 		return err
 	}
@@ -251,6 +280,22 @@ func Delete(ctx context.Context, param interface{}) error {
 	return nil
 }
 
+// DeleteWithResult is Delete returning the number of records deleted.
+func DeleteWithResult(ctx context.Context, transport MongodbTransport, param interface{}) (*structs.DeleteResult, error) {
+	data, err := getMongodbTransport(transport).DoRequestMongodb(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+
+	var result inner.DeleteResult
+	err = bson.Unmarshal(data, &result)
+	if err != nil {
+		return nil, cExceptions.InternalError("[DeleteWithResult] Unmarshal failed, err: %v", err)
+	}
+
+	return &result.Data, nil
+}
+
 func ReadFromURL(ctx context.Context, targetURL string) ([]byte, error) {
 
 	u, err := url.Parse(targetURL)
@@ -280,7 +325,7 @@ func ReadFromURL(ctx context.Context, targetURL string) ([]byte, error) {
 	return b, err
 }
 
-func UploadWithContent(ctx context.Context, name string, content []byte, option *structs.Option) (*structs.UploadResult, error) {
+func UploadWithContent(ctx context.Context, transport FileTransport, name string, content []byte, option *structs.Option) (*structs.UploadResult, error) {
 	if !cUtils.IsExternalFaaS() {
 		return nil, cExceptions.InvalidParamError("unsupport oss")
 	}
@@ -317,7 +362,7 @@ func UploadWithContent(ctx context.Context, name string, content []byte, option
 		return nil, err
 	}
 
-	out, err := DoRequestFile(ctx, writer.FormDataContentType(), body)
+	out, err := getFileTransport(transport).DoRequestFile(ctx, writer.FormDataContentType(), body)
 	if err != nil {
 		return nil, err
 	}
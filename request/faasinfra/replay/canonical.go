@@ -0,0 +1,141 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// canonical re-encodes a JSON document with sorted object keys, so that requests built
+// from Go maps compare equal whatever their iteration order.
+func canonical(data []byte) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func jsonRequest(param interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(param)
+	if err != nil {
+		return nil, cExceptions.InvalidParamError("[replay] marshal request failed, err: %v", err)
+	}
+	return canonical(data)
+}
+
+func mongodbRequest(param interface{}) (json.RawMessage, error) {
+	data, err := bson.MarshalExtJSON(param, false, false)
+	if err != nil {
+		return nil, cExceptions.InvalidParamError("[replay] marshal mongodb request failed, err: %v", err)
+	}
+	return canonical(data)
+}
+
+type filePart struct {
+	Name     string `json:"name"`
+	FileName string `json:"fileName,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Base64   string `json:"base64,omitempty"`
+}
+
+// fileRequest decodes a multipart form into its parts, leaving out the random boundary.
+func fileRequest(contentType string, body []byte) (json.RawMessage, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		return jsonRequest(map[string]interface{}{"contentType": contentType, "body": string(body)})
+	}
+
+	parts := []filePart{}
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, cExceptions.InvalidParamError("[replay] read multipart request failed, err: %v", err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, cExceptions.InvalidParamError("[replay] read multipart request failed, err: %v", err)
+		}
+		p := filePart{Name: part.FormName(), FileName: part.FileName()}
+		if utf8.Valid(content) {
+			p.Content = string(content)
+		} else {
+			p.Base64 = base64.StdEncoding.EncodeToString(content)
+		}
+		parts = append(parts, p)
+	}
+	return jsonRequest(map[string]interface{}{"contentType": mediaType, "parts": parts})
+}
+
+func compact(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
+
+func indent(data []byte) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// diff returns a line diff of a and b, prefixing removed lines with "-", added lines
+// with "+" and unchanged ones with " ".
+func diff(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			sb.WriteString("  " + x[i] + "\n")
+			i++
+			j++
+		case j < len(y) && (i == len(x) || lcs[i][j+1] > lcs[i+1][j]):
+			sb.WriteString("+ " + y[j] + "\n")
+			j++
+		default:
+			sb.WriteString("- " + x[i] + "\n")
+			i++
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,314 @@
+// Package replay records the requests going through a faasinfra.Transport into a golden file
+// and replays them later without network access.
+//
+//	func TestCache(t *testing.T) {
+//		transport, err := replay.New("testdata/cache.json")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		defer func() { assert.NoError(t, transport.Close()) }()
+//		cli := redis.NewRedis(redis.WithTransport(transport))
+//		...
+//	}
+//
+// Run the tests once with BAAS_SDK_RECORD=1 against a real environment to (re)write the
+// golden files; every later run replays them. A request that was not recorded fails with
+// a diff against the closest recorded one.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
+)
+
+// EnvRecord is the environment variable that switches New to record mode.
+const EnvRecord = "BAAS_SDK_RECORD"
+
+type Mode int
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+const (
+	KindMongodb         = "mongodb"
+	KindRedis           = "redis"
+	KindFile            = "file"
+	KindAsyncTask       = "asyncTask"
+	KindDistributedTask = "distributedTask"
+)
+
+// Call is a request and its response as stored in a golden file. Requests are canonical JSON:
+// Mongo params as relaxed extended JSON, redis commands as sent, multipart forms as their parts.
+type Call struct {
+	Kind    string          `json:"kind"`
+	Request json.RawMessage `json:"request"`
+	// Response is the JSON response body; mongodb responses are canonical extended JSON.
+	Response json.RawMessage `json:"response,omitempty"`
+	// ResponseText is the response body when it is not JSON.
+	ResponseText string                 `json:"responseText,omitempty"`
+	Extra        map[string]interface{} `json:"extra,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+
+	used bool
+}
+
+type golden struct {
+	Calls []*Call `json:"calls"`
+}
+
+// Transport is a faasinfra.Transport that records or replays calls.
+type Transport struct {
+	mode Mode
+	path string
+	next faasinfra.Transport
+
+	mu    sync.Mutex
+	calls []*Call
+}
+
+var _ faasinfra.Transport = (*Transport)(nil)
+
+// New returns a recorder sending requests over HTTP when EnvRecord is set, a replayer otherwise.
+func New(path string) (*Transport, error) {
+	if os.Getenv(EnvRecord) != "" {
+		return NewRecorder(path, faasinfra.NewHttpTransport()), nil
+	}
+	return NewReplayer(path)
+}
+
+// NewRecorder returns a Transport sending requests through next and recording them.
+// Close writes the golden file at path.
+func NewRecorder(path string, next faasinfra.Transport) *Transport {
+	return &Transport{mode: ModeRecord, path: path, next: next}
+}
+
+// NewReplayer returns a Transport answering requests from the golden file at path.
+func NewReplayer(path string) (*Transport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, cExceptions.InvalidParamError("[replay] read golden file %s failed, err: %v", path, err)
+	}
+	var g golden
+	if err = json.Unmarshal(data, &g); err != nil {
+		return nil, cExceptions.InvalidParamError("[replay] unmarshal golden file %s failed, err: %v", path, err)
+	}
+	return &Transport{mode: ModeReplay, path: path, calls: g.Calls}, nil
+}
+
+func (t *Transport) Mode() Mode {
+	return t.mode
+}
+
+// Close writes the golden file in record mode. In replay mode it reports the recorded calls
+// that were never requested.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mode == ModeRecord {
+		data, err := json.MarshalIndent(golden{Calls: t.calls}, "", "  ")
+		if err != nil {
+			return cExceptions.InternalError("[replay] marshal golden file failed, err: %v", err)
+		}
+		if err = os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+			return cExceptions.InternalError("[replay] create golden file dir failed, err: %v", err)
+		}
+		if err = ioutil.WriteFile(t.path, append(data, '\n'), 0644); err != nil {
+			return cExceptions.InternalError("[replay] write golden file %s failed, err: %v", t.path, err)
+		}
+		return nil
+	}
+
+	var unused int
+	var first *Call
+	for _, call := range t.calls {
+		if !call.used {
+			if first == nil {
+				first = call
+			}
+			unused++
+		}
+	}
+	if unused > 0 {
+		return cExceptions.InvalidParamError("[replay] %d recorded calls of %s were not requested, the first one is %s %s",
+			unused, t.path, first.Kind, indent(first.Request))
+	}
+	return nil
+}
+
+// do records the result of send, or replays the recorded call matching request.
+func (t *Transport) do(kind string, request json.RawMessage, send func() *Call) (*Call, error) {
+	if t.mode == ModeRecord {
+		call := send()
+		call.Kind, call.Request = kind, request
+		t.mu.Lock()
+		t.calls = append(t.calls, call)
+		t.mu.Unlock()
+		return call, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var closest *Call
+	for _, call := range t.calls {
+		if call.used || call.Kind != kind {
+			continue
+		}
+		if bytes.Equal(compact(call.Request), compact(request)) {
+			call.used = true
+			return call, nil
+		}
+		if closest == nil {
+			closest = call
+		}
+	}
+	if closest == nil {
+		return nil, cExceptions.InvalidParamError("[replay] no recorded %s call left in %s for request %s", kind, t.path, indent(request))
+	}
+	return nil, cExceptions.InvalidParamError("[replay] %s request does not match the next recorded one in %s (-recorded +actual):\n%s",
+		kind, t.path, diff(indent(closest.Request), indent(request)))
+}
+
+func (c *Call) err() error {
+	if c.Error == "" {
+		return nil
+	}
+	return errors.New(c.Error)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (t *Transport) DoRequestMongodb(ctx context.Context, param interface{}) ([]byte, error) {
+	request, err := mongodbRequest(param)
+	if err != nil {
+		return nil, err
+	}
+	call, err := t.do(KindMongodb, request, func() *Call {
+		data, err := t.next.DoRequestMongodb(ctx, param)
+		call := &Call{Error: errString(err)}
+		if err == nil {
+			if call.Response, err = bson.MarshalExtJSON(bson.Raw(data), true, false); err != nil {
+				call.Error = err.Error()
+			}
+		}
+		return call
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = call.err(); err != nil {
+		return nil, err
+	}
+
+	var doc bson.D
+	if err = bson.UnmarshalExtJSON(call.Response, true, &doc); err != nil {
+		return nil, cExceptions.InternalError("[replay] unmarshal recorded mongodb response failed, err: %v", err)
+	}
+	return bson.Marshal(doc)
+}
+
+func (t *Transport) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	request, err := jsonRequest(param)
+	if err != nil {
+		return nil, nil, err
+	}
+	call, err := t.do(KindRedis, request, func() *Call {
+		data, extra, err := t.next.DoRequestRedis(ctx, param)
+		call := &Call{Extra: extra, Error: errString(err)}
+		setResponse(call, data)
+		return call
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return response(call), call.Extra, call.err()
+}
+
+func (t *Transport) DoRequestFile(ctx context.Context, contentType string, body *bytes.Buffer) ([]byte, error) {
+	request, err := fileRequest(contentType, body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	call, err := t.do(KindFile, request, func() *Call {
+		data, err := t.next.DoRequestFile(ctx, contentType, body)
+		call := &Call{Error: errString(err)}
+		setResponse(call, data)
+		return call
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response(call), call.err()
+}
+
+func (t *Transport) DoRequestAsyncTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	return t.doTask(KindAsyncTask, namespace, headers, body, func() ([]byte, error) {
+		return t.next.DoRequestAsyncTask(ctx, namespace, headers, body)
+	})
+}
+
+func (t *Transport) DoRequestDistributedTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	return t.doTask(KindDistributedTask, namespace, headers, body, func() ([]byte, error) {
+		return t.next.DoRequestDistributedTask(ctx, namespace, headers, body)
+	})
+}
+
+func (t *Transport) doTask(kind, namespace string, headers map[string][]string, body interface{}, send func() ([]byte, error)) ([]byte, error) {
+	request, err := jsonRequest(map[string]interface{}{
+		"namespace": namespace,
+		"headers":   headers,
+		"body":      body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	call, err := t.do(kind, request, func() *Call {
+		data, err := send()
+		call := &Call{Error: errString(err)}
+		setResponse(call, data)
+		return call
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response(call), call.err()
+}
+
+func setResponse(call *Call, data []byte) {
+	if data == nil {
+		return
+	}
+	if json.Valid(data) {
+		call.Response = append(json.RawMessage(nil), data...)
+	} else {
+		call.ResponseText = string(data)
+	}
+}
+
+func response(call *Call) []byte {
+	if call.Response != nil {
+		return compact(call.Response)
+	}
+	if call.ResponseText != "" {
+		return []byte(call.ResponseText)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package faasinfra
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/byted-apaas/baas-sdk-go/request/openapi"
+)
+
+// Transport sends every request the MongoDB, Redis, OSS and Tasks clients make.
+// HttpTransport is the default implementation; a custom one can be injected per client
+// instance, e.g. to record and replay requests, to serve them locally or to intercept them.
+type Transport interface {
+	MongodbTransport
+	RedisTransport
+	FileTransport
+	TaskTransport
+}
+
+// MongodbTransport sends a mongodb request and returns the decoded BSON response body.
+type MongodbTransport interface {
+	DoRequestMongodb(ctx context.Context, param interface{}) ([]byte, error)
+}
+
+// RedisTransport sends a redis command and returns the JSON response body.
+type RedisTransport interface {
+	DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error)
+}
+
+// RedisPipelineTransport is implemented by the redis transports able to send several commands
+// in one round trip. It returns one JSON response body per command.
+type RedisPipelineTransport interface {
+	DoRequestRedisPipeline(ctx context.Context, params []interface{}) ([][]byte, map[string]interface{}, error)
+}
+
+// FileTransport uploads a multipart form and returns the raw response body.
+type FileTransport interface {
+	DoRequestFile(ctx context.Context, contentType string, body *bytes.Buffer) ([]byte, error)
+}
+
+// TaskTransport creates async tasks through the openapi and distributed tasks through the FaaS infra.
+type TaskTransport interface {
+	openapi.Transport
+	DoRequestDistributedTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error)
+}
+
+var _ Transport = (*HttpTransport)(nil)
+
+// HttpTransport sends requests to the FaaS infra over HTTP.
+type HttpTransport struct {
+	openapi.HttpTransport
+}
+
+func NewHttpTransport() *HttpTransport {
+	return &HttpTransport{}
+}
+
+func (t *HttpTransport) DoRequestMongodb(ctx context.Context, param interface{}) ([]byte, error) {
+	return doRequestMongodb(ctx, param)
+}
+
+func (t *HttpTransport) DoRequestRedis(ctx context.Context, param interface{}) ([]byte, map[string]interface{}, error) {
+	return DoRequestRedis(ctx, param)
+}
+
+func (t *HttpTransport) DoRequestFile(ctx context.Context, contentType string, body *bytes.Buffer) ([]byte, error) {
+	return DoRequestFile(ctx, contentType, body)
+}
+
+func (t *HttpTransport) DoRequestDistributedTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	return doRequestDistributedTask(ctx, namespace, headers, body)
+}
+
+func getMongodbTransport(transport MongodbTransport) MongodbTransport {
+	if transport == nil {
+		return NewHttpTransport()
+	}
+	return transport
+}
+
+// GetRedisTransport returns transport, or the HTTP transport when it is nil.
+func GetRedisTransport(transport RedisTransport) RedisTransport {
+	if transport == nil {
+		return NewHttpTransport()
+	}
+	return transport
+}
+
+func getFileTransport(transport FileTransport) FileTransport {
+	if transport == nil {
+		return NewHttpTransport()
+	}
+	return transport
+}
+
+func getTaskTransport(transport TaskTransport) TaskTransport {
+	if transport == nil {
+		return NewHttpTransport()
+	}
+	return transport
+}
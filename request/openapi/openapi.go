@@ -10,11 +10,17 @@ import (
 	"github.com/byted-apaas/baas-sdk-go/common/utils"
 	reqCommon "github.com/byted-apaas/baas-sdk-go/request/common"
 	cConstants "github.com/byted-apaas/server-common-go/constants"
-	cHttp "github.com/byted-apaas/server-common-go/http"
 	cUtils "github.com/byted-apaas/server-common-go/utils"
 )
 
-type RequestHttp struct{}
+type RequestHttp struct {
+	transport Transport
+}
+
+// NewRequestHttp returns a RequestHttp sending its requests through transport, or over HTTP when it is nil.
+func NewRequestHttp(transport Transport) *RequestHttp {
+	return &RequestHttp{transport: transport}
+}
 
 func (r *RequestHttp) InvokeFunctionAsync(ctx context.Context, appCtx *structs.AppCtx, apiName string, params map[string]interface{}) (int64, error) {
 	body, err := reqCommon.BuildInvokeParamsStr(ctx, apiName, params, appCtx == nil || appCtx.Credential == nil || appCtx.Mode != structs.AppModeOpenSDK)
@@ -35,7 +41,7 @@ func (r *RequestHttp) InvokeFunctionAsync(ctx context.Context, appCtx *structs.A
 		cConstants.HttpHeaderKeyUser:   {strconv.FormatInt(cUtils.GetUserIDFromCtx(ctx), 10)},
 	}
 
-	data, err := cUtils.ErrorWrapper(getOpenapiClient().PostJson(utils.SetAppConfToCtx(ctx, appCtx), GetPathInvokeFunctionAsync(namespace), headers, body, cHttp.AppTokenMiddleware))
+	data, err := getTransport(r.transport).DoRequestAsyncTask(utils.SetAppConfToCtx(ctx, appCtx), namespace, headers, body)
 	if err != nil {
 		return 0, err
 	}
@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	"context"
+
+	cHttp "github.com/byted-apaas/server-common-go/http"
+	cUtils "github.com/byted-apaas/server-common-go/utils"
+)
+
+// Transport sends the requests of RequestHttp.
+type Transport interface {
+	DoRequestAsyncTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error)
+}
+
+// HttpTransport sends requests to the openapi over HTTP.
+type HttpTransport struct{}
+
+func (t *HttpTransport) DoRequestAsyncTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	return cUtils.ErrorWrapper(getOpenapiClient().PostJson(ctx, GetPathInvokeFunctionAsync(namespace), headers, body, cHttp.AppTokenMiddleware))
+}
+
+func getTransport(transport Transport) Transport {
+	if transport == nil {
+		return &HttpTransport{}
+	}
+	return transport
+}
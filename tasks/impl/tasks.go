@@ -6,21 +6,50 @@ import (
 	"github.com/byted-apaas/baas-sdk-go/common/structs"
 	"github.com/byted-apaas/baas-sdk-go/request"
 	"github.com/byted-apaas/baas-sdk-go/request/faasinfra"
+	"github.com/byted-apaas/baas-sdk-go/request/openapi"
 	"github.com/byted-apaas/baas-sdk-go/tasks"
 	cExceptions "github.com/byted-apaas/server-common-go/exceptions"
 	cUtils "github.com/byted-apaas/server-common-go/utils"
 )
 
 type Tasks struct {
-	appCtx *structs.AppCtx
+	appCtx    *structs.AppCtx
+	transport faasinfra.TaskTransport
 }
 
-func NewTasks(s *structs.AppCtx) tasks.ITasks {
-	return &Tasks{appCtx: s}
+type Option func(t *Tasks)
+
+// WithTransport replaces the transport used to create tasks, e.g. with an in-memory one in tests.
+func WithTransport(transport faasinfra.TaskTransport) Option {
+	return func(t *Tasks) {
+		t.transport = transport
+	}
+}
+
+func NewTasks(s *structs.AppCtx, opts ...Option) tasks.ITasks {
+	t := &Tasks{appCtx: s}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Tasks) requestOpenapi(ctx context.Context) request.IRequestOpenapi {
+	if t.transport == nil {
+		return request.GetInstance(ctx)
+	}
+	return openapi.NewRequestHttp(t.transport)
+}
+
+func (t *Tasks) requestFaaSInfra() request.IRequestFaaSInfra {
+	if t.transport == nil {
+		return faasinfra.GetInstance()
+	}
+	return faasinfra.NewRequestFaaSInfra(t.transport)
 }
 
 func (t *Tasks) CreateAsyncTask(ctx context.Context, funcAPIName string, params map[string]interface{}) (int64, error) {
-	return request.GetInstance(ctx).InvokeFunctionAsync(ctx, t.appCtx, funcAPIName, params)
+	return t.requestOpenapi(ctx).InvokeFunctionAsync(ctx, t.appCtx, funcAPIName, params)
 }
 
 // CreateDistributedTask
@@ -56,5 +85,5 @@ func (t *Tasks) CreateDistributedTask(ctx context.Context, dataset interface{},
 	if options.ProgressCallbackStep <= 0 || options.ProgressCallbackStep > 100 {
 		return 0, cExceptions.InvalidParamError("parameter option.progressCallbackStep is not between 0 and 100")
 	}
-	return faasinfra.GetInstance().InvokeFunctionDistributed(ctx, t.appCtx, dataset, handlerFunc, progressCallbackFunc, completedCallbackFunc, options)
+	return t.requestFaaSInfra().InvokeFunctionDistributed(ctx, t.appCtx, dataset, handlerFunc, progressCallbackFunc, completedCallbackFunc, options)
 }
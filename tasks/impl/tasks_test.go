@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byted-apaas/baas-sdk-go/tasks"
+)
+
+type fakeTransport struct {
+	bodies []interface{}
+}
+
+func (f *fakeTransport) DoRequestAsyncTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	f.bodies = append(f.bodies, body)
+	return []byte(`{"task_id": 7}`), nil
+}
+
+func (f *fakeTransport) DoRequestDistributedTask(ctx context.Context, namespace string, headers map[string][]string, body interface{}) ([]byte, error) {
+	f.bodies = append(f.bodies, body)
+	return []byte(`8`), nil
+}
+
+func TestTasks_WithTransport(t *testing.T) {
+	ctx := context.Background()
+	transport := &fakeTransport{}
+	cli := NewTasks(nil, WithTransport(transport))
+
+	taskID, err := cli.CreateAsyncTask(ctx, "handler", map[string]interface{}{"k": "v"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 7, taskID)
+
+	taskID, err = cli.CreateDistributedTask(ctx, []int{1, 2}, "handler", "", "", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 8, taskID)
+
+	assert.Len(t, transport.bodies, 2)
+	body := transport.bodies[1].(map[string]interface{})
+	assert.Equal(t, "handler", body["handlerFunc"])
+	assert.Equal(t, tasks.NewOptions(5, 5, 1), body["options"])
+}